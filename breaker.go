@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by SendNotification while the circuit breaker
+// is open and there is no fallback.
+var ErrCircuitOpen = errors.New("notify: notification server unavailable, circuit open")
+
+// WithCircuitBreaker stops SendNotification from calling the server for
+// cooldown after failures consecutive sends failed with a transient error
+// (see WithRetry), so applications don't pay a D-Bus timeout on every
+// notification when no notification daemon is running. Sends in that time
+// return ErrCircuitOpen right away, or go to the fallback set with
+// WithFallback. After the cooldown one send is let through: if it works the
+// circuit closes again, if not it stays open for another cooldown.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	if failures < 1 {
+		failures = 1
+	}
+	return func(n *notifier) {
+		n.staged = true
+		n.breaker = &breaker{threshold: failures, cooldown: cooldown}
+	}
+}
+
+// WithFallback sends notifications with fallback while the circuit breaker
+// is open, e.g. a Notifier using the notification portal or a logging one.
+// IDs returned by the fallback are its own, and its signals are not
+// delivered on this Notifier's channels.
+func WithFallback(fallback Notifier) Option {
+	return func(n *notifier) {
+		n.fallback = fallback
+	}
+}
+
+// breaker is the state of the circuit breaker.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	lock      sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool // a send is let through after the cooldown
+}
+
+// allow reports whether a send may go to the server at now.
+func (b *breaker) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || now.Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the state with the outcome of a send finished at now.
+func (b *breaker) record(err error, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.probing = false
+	if err == nil || !isTransient(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
+
+// withBreaker calls fn unless the circuit is open.
+func (n *notifier) withBreaker(note Notification, fn func() (uint32, error)) (uint32, error) {
+	if n.breaker == nil {
+		return fn()
+	}
+	if !n.breaker.allow(n.clock.Now()) {
+		n.tracef("circuit open, not calling %v", callNotify)
+		if n.fallback != nil {
+			return n.fallback.SendNotification(note)
+		}
+		return 0, ErrCircuitOpen
+	}
+	id, err := fn()
+	n.breaker.record(err, n.clock.Now())
+	return id, err
+}
@@ -0,0 +1,288 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+// signalBufferSize is the size of the channel signals are received on.
+const signalBufferSize = 10
+
+// Rule maps the signals it matches to a notification.
+// Interface and Member are required, the other match fields are optional.
+type Rule struct {
+	Interface string            `json:"interface"`
+	Member    string            `json:"member"`
+	Path      dbus.ObjectPath   `json:"path,omitempty"`
+	Sender    string            `json:"sender,omitempty"` // well-known or unique bus name
+	Args      map[string]string `json:"args,omitempty"`   // string arguments by index, e.g. {"0": "org.freedesktop.UPower.Device"}
+
+	// Summary and Body are templates, executed with a Signal.
+	Summary string `json:"summary"`
+	Body    string `json:"body,omitempty"`
+
+	AppName       string          `json:"app_name,omitempty"`
+	AppIcon       string          `json:"app_icon,omitempty"`
+	Urgency       *notify.Urgency `json:"urgency,omitempty"`
+	ExpireTimeout int32           `json:"expire_timeout,omitempty"` // 0 means the server default, unlike in Notification
+	// Replace makes each notification of the rule replace the previous one,
+	// for state changes where only the latest matters.
+	Replace bool `json:"replace,omitempty"`
+}
+
+// Signal is what the templates of a Rule are executed with.
+type Signal struct {
+	Sender    string
+	Path      dbus.ObjectPath
+	Interface string
+	Member    string
+	Args      []interface{}
+}
+
+// Load reads a JSON array of rules from the file at path.
+func Load(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("bridge: %v: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Bridge sends notifications for the signals matching its rules.
+type Bridge struct {
+	conn   *dbus.Conn
+	n      notify.Notifier
+	rules  []*rule
+	signal chan *dbus.Signal
+	done   chan struct{}
+}
+
+// rule is a Rule with its templates parsed.
+type rule struct {
+	Rule
+	summary *template.Template
+	body    *template.Template
+	args    map[int]string
+
+	lock sync.Mutex
+	last uint32 // ID of the previous notification, see Replace
+}
+
+// New creates a Bridge that watches conn for signals matching rules and
+// sends notifications through n. n may be on another bus than conn, e.g. the
+// session bus to report signals of the system bus.
+//
+// Caller is responsible to call Close() to remove the match rules.
+func New(conn *dbus.Conn, n notify.Notifier, rules []Rule) (*Bridge, error) {
+	b := &Bridge{
+		conn:   conn,
+		n:      n,
+		signal: make(chan *dbus.Signal, signalBufferSize),
+		done:   make(chan struct{}),
+	}
+	for i, r := range rules {
+		parsed, err := parseRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("bridge: rule %d: %w", i, err)
+		}
+		b.rules = append(b.rules, parsed)
+	}
+	for i, r := range b.rules {
+		if err := conn.AddMatchSignal(r.match()...); err != nil {
+			b.removeMatches(b.rules[:i])
+			return nil, err
+		}
+	}
+	conn.Signal(b.signal)
+	go b.run()
+	return b, nil
+}
+
+func parseRule(r Rule) (*rule, error) {
+	if r.Interface == "" || r.Member == "" {
+		return nil, fmt.Errorf("interface and member are required")
+	}
+	summary, err := template.New("summary").Parse(r.Summary)
+	if err != nil {
+		return nil, err
+	}
+	body, err := template.New("body").Parse(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	args := make(map[int]string, len(r.Args))
+	for k, v := range r.Args {
+		var i int
+		if _, err := fmt.Sscan(k, &i); err != nil || i < 0 || i > 63 {
+			return nil, fmt.Errorf("invalid argument index %q", k)
+		}
+		args[i] = v
+	}
+	return &rule{Rule: r, summary: summary, body: body, args: args}, nil
+}
+
+func (r *rule) match() []dbus.MatchOption {
+	opts := []dbus.MatchOption{
+		dbus.WithMatchInterface(r.Interface),
+		dbus.WithMatchMember(r.Member),
+	}
+	if r.Path != "" {
+		opts = append(opts, dbus.WithMatchObjectPath(r.Path))
+	}
+	if r.Sender != "" {
+		opts = append(opts, dbus.WithMatchSender(r.Sender))
+	}
+	for i, v := range r.args {
+		opts = append(opts, dbus.WithMatchArg(i, v))
+	}
+	return opts
+}
+
+// matches reports whether signal is one for r. The bus already filtered by
+// sender, which may be given as a well-known name, so that isn't checked.
+func (r *rule) matches(signal *dbus.Signal) bool {
+	if signal.Name != r.Interface+"."+r.Member {
+		return false
+	}
+	if r.Path != "" && signal.Path != r.Path {
+		return false
+	}
+	for i, v := range r.args {
+		if i >= len(signal.Body) {
+			return false
+		}
+		if s, ok := signal.Body[i].(string); !ok || s != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Bridge) run() {
+	for {
+		select {
+		case signal := <-b.signal:
+			if signal == nil {
+				return
+			}
+			for _, r := range b.rules {
+				if r.matches(signal) {
+					if err := b.notify(r, signal); err != nil {
+						log.Printf("bridge: error notifying about %v: %v", signal.Name, err)
+					}
+				}
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *Bridge) notify(r *rule, signal *dbus.Signal) error {
+	data := Signal{
+		Sender:    signal.Sender,
+		Path:      signal.Path,
+		Interface: r.Interface,
+		Member:    r.Member,
+		Args:      unwrapAll(signal.Body),
+	}
+	summary, err := render(r.summary, data)
+	if err != nil || summary == "" {
+		return err
+	}
+	body, err := render(r.body, data)
+	if err != nil {
+		return err
+	}
+	note := notify.Notification{
+		AppName:       r.AppName,
+		AppIcon:       r.AppIcon,
+		Summary:       summary,
+		Body:          body,
+		ExpireTimeout: r.ExpireTimeout,
+	}
+	if note.ExpireTimeout == 0 {
+		note.ExpireTimeout = -1
+	}
+	if r.Urgency != nil {
+		note.SetUrgency(*r.Urgency)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.Replace {
+		note.ReplacesID = r.last
+	}
+	id, err := b.n.SendNotification(note)
+	if err != nil {
+		return err
+	}
+	r.last = id
+	return nil
+}
+
+func render(t *template.Template, data Signal) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// unwrapAll unwraps the arguments of a signal for templates.
+func unwrapAll(values []interface{}) []interface{} {
+	ret := make([]interface{}, len(values))
+	for i, v := range values {
+		ret[i] = unwrap(v)
+	}
+	return ret
+}
+
+// unwrap replaces variants by their values, in dictionaries and arrays too.
+func unwrap(value interface{}) interface{} {
+	switch v := value.(type) {
+	case dbus.Variant:
+		return unwrap(v.Value())
+	case []dbus.Variant:
+		ret := make([]interface{}, len(v))
+		for i, e := range v {
+			ret[i] = unwrap(e)
+		}
+		return ret
+	case map[string]dbus.Variant:
+		ret := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			ret[k] = unwrap(e)
+		}
+		return ret
+	}
+	return value
+}
+
+func (b *Bridge) removeMatches(rules []*rule) {
+	for _, r := range rules {
+		b.conn.RemoveMatchSignal(r.match()...)
+	}
+}
+
+// Close removes the match rules and stops sending notifications.
+// The connection and the Notifier are left open.
+func (b *Bridge) Close() error {
+	close(b.done)
+	b.conn.RemoveSignal(b.signal)
+	b.removeMatches(b.rules)
+	return nil
+}
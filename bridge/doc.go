@@ -0,0 +1,31 @@
+/*
+Package bridge turns D-Bus signals into desktop notifications.
+
+A Bridge installs a match rule for each of its Rules on a bus connection,
+the session or the system bus, and sends a notification for every signal
+matching one. Summary and body are text/template templates executed with a
+Signal, so no code is needed for the common cases. Rules are usually kept in
+a JSON file, see Load and the notify-bridge command:
+
+	[
+	  {
+	    "interface": "org.freedesktop.UPower",
+	    "member": "DeviceAdded",
+	    "summary": "Power device added",
+	    "body": "{{index .Args 0}}"
+	  },
+	  {
+	    "interface": "org.freedesktop.NetworkManager",
+	    "member": "StateChanged",
+	    "summary": "{{if eq (index .Args 0) 70}}Network connected{{end}}",
+	    "replace": true
+	  }
+	]
+
+Arguments of the signal are available as .Args, with variants unwrapped and
+a{sv} dictionaries as maps, so the properties of a PropertiesChanged signal
+are reached with {{index .Args 1 "Percentage"}}. A summary that renders to
+the empty string sends nothing, which lets templates pick the signals worth
+a notification.
+*/
+package bridge
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"log"
+	"sync"
+)
+
+// Overflow is what a Notifier does with a signal when the channel it goes
+// to is full, see WithBuffering.
+type Overflow int
+
+const (
+	// Block waits until the consumer makes room. Nothing is lost, but
+	// every signal waiting holds a goroutine, and signals may reach the
+	// channel out of order. The default.
+	Block Overflow = iota
+	// DropOldest discards the oldest signal in the channel to make room.
+	DropOldest
+	// DropNewest discards the signal that doesn't fit.
+	DropNewest
+	// Coalesce replaces the pending signals for the same notification with
+	// the new one, e.g. repeated ActionInvoked signals of a notification
+	// clicked again and again, and otherwise discards the oldest one.
+	Coalesce
+)
+
+func (o Overflow) String() string {
+	switch o {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop-oldest"
+	case DropNewest:
+		return "drop-newest"
+	case Coalesce:
+		return "coalesce"
+	default:
+		return "unknown"
+	}
+}
+
+// Buffering sizes the channels of a Notifier, see WithBuffering.
+type Buffering struct {
+	// Signals is the number of dbus signals buffered before the
+	// Notifier handles them.
+	Signals int
+	// Events is the size of the channels returned by NotificationClosed
+	// and ActionInvoked.
+	Events int
+	// Overflow is what happens to a signal when its channel is full.
+	Overflow Overflow
+}
+
+// WithBuffering sets the sizes of the signal channels of the Notifier and
+// what happens when a consumer doesn't keep up with them; sizes below 1
+// keep the default of 10. With any Overflow but Block the signals are
+// handled in the order they arrive and nothing waits on a slow consumer,
+// at the cost of the signals discarded.
+func WithBuffering(b Buffering) Option {
+	return func(n *notifier) {
+		n.buffering = b
+	}
+}
+
+// outbox delivers signals to a channel handed out to the application,
+// applying the overflow policy.
+type outbox[T any] struct {
+	ch       chan T
+	overflow Overflow
+	id       func(T) uint32 // the notification of a signal, for Coalesce
+	lock     sync.Mutex     // held while making room
+}
+
+func newOutbox[T any](size int, overflow Overflow, id func(T) uint32) *outbox[T] {
+	if size < 1 {
+		size = channelBufferSize
+	}
+	return &outbox[T]{ch: make(chan T, size), overflow: overflow, id: id}
+}
+
+// put delivers v, waiting for room with Block until closing is closed.
+func (o *outbox[T]) put(v T, closing <-chan struct{}) {
+	if o.overflow == Block {
+		select {
+		case o.ch <- v:
+		case <-closing:
+		}
+		return
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	for {
+		select {
+		case o.ch <- v:
+			return
+		default:
+		}
+		switch o.overflow {
+		case DropNewest:
+			log.Printf("channel full, dropping signal: %+v", v)
+			return
+		case Coalesce:
+			if o.coalesce(v) {
+				return
+			}
+			fallthrough
+		default:
+			select {
+			case old := <-o.ch:
+				log.Printf("channel full, dropping signal: %+v", old)
+			default:
+			}
+		}
+	}
+}
+
+// coalesce replaces the pending signals for the notification of v with v
+// and reports whether there were any.
+func (o *outbox[T]) coalesce(v T) bool {
+	var pending []T
+	found := false
+	for len(o.ch) > 0 {
+		select {
+		case old := <-o.ch:
+			if o.id(old) == o.id(v) {
+				found = true
+				continue
+			}
+			pending = append(pending, old)
+		default:
+		}
+	}
+	if found {
+		pending = append(pending, v)
+	}
+	// the consumer only took signals meanwhile, so these all fit.
+	for _, p := range pending {
+		o.ch <- p
+	}
+	return found
+}
@@ -0,0 +1,41 @@
+package notify
+
+// child is a Notifier sending as another application through its parent.
+type child struct {
+	Notifier
+	appName string
+	appIcon string
+}
+
+// NewChild returns a Notifier that sends through parent, with appName as the
+// AppName of every notification and appIcon as the AppIcon of notifications
+// that don't set one. Frameworks hosting several logical applications or
+// plugins in one process give each its own child, sharing one connection.
+//
+// The child shares the signal channels of parent, which carry the signals
+// for all notifications, and Close on it does nothing: the parent owns the
+// connection. Notifier implementations use NewChild for WithApp.
+func NewChild(parent Notifier, appName, appIcon string) Notifier {
+	if c, ok := parent.(*child); ok {
+		parent = c.Notifier
+	}
+	return &child{Notifier: parent, appName: appName, appIcon: appIcon}
+}
+
+func (c *child) SendNotification(note Notification) (uint32, error) {
+	note.AppName = c.appName
+	if note.AppIcon == "" {
+		note.AppIcon = c.appIcon
+	}
+	return c.Notifier.SendNotification(note)
+}
+
+// WithApp returns a sibling of c with another application identity.
+func (c *child) WithApp(appName, appIcon string) Notifier {
+	return NewChild(c.Notifier, appName, appIcon)
+}
+
+// Close does nothing, the parent Notifier owns the connection.
+func (c *child) Close() error {
+	return nil
+}
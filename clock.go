@@ -0,0 +1,46 @@
+package notify
+
+import "time"
+
+// Clock tells the time and runs functions later. Everything in notify that
+// depends on time reads it from a Clock: quiet hours, storm guards, delivery
+// receipts, the circuit breaker and the waits between retries, as well as
+// the reminder scheduler, the expiry timers of server and the rate limiter
+// of notifyhttp. Tests replace SystemClock with a fake one, like the one of
+// notifytest, to check time-dependent behavior without waiting.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine once d has passed.
+	AfterFunc(d time.Duration, f func()) Alarm
+}
+
+// Alarm is a call scheduled with Clock.AfterFunc.
+type Alarm interface {
+	// Stop cancels the call, reporting whether it hadn't happened yet.
+	Stop() bool
+}
+
+// SystemClock is the Clock of the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Alarm {
+	return time.AfterFunc(d, f)
+}
+
+// Sleep waits for d to pass on c.
+func Sleep(c Clock, d time.Duration) {
+	done := make(chan struct{})
+	c.AfterFunc(d, func() { close(done) })
+	<-done
+}
+
+// WithClock makes the Notifier read the time from c instead of SystemClock.
+func WithClock(c Clock) Option {
+	return func(n *notifier) {
+		n.clock = c
+	}
+}
@@ -0,0 +1,49 @@
+package notify
+
+import "github.com/godbus/dbus/v5"
+
+// A Notification holds a map and a slice, so copies of it share their Hints
+// and Actions: SetHint on one changes all of them. Clone and the With
+// helpers return notifications that share nothing with the original, so a
+// base notification can be reused, also across goroutines:
+//
+//	base := notify.Notification{AppName: "backup", AppIcon: "drive-harddisk"}
+//	n.SendNotification(base.WithSummary("Backup done").WithHint(notify.HintUrgency, byte(notify.UrgencyLow)))
+
+// Clone returns a copy of n with its own Hints and Actions.
+// Values inside hints, e.g. the pixels of image-data, are still shared;
+// they are not meant to be changed in place.
+func (n Notification) Clone() Notification {
+	if n.Actions != nil {
+		n.Actions = append([]string{}, n.Actions...)
+	}
+	if n.Hints != nil {
+		hints := make(map[string]dbus.Variant, len(n.Hints))
+		for k, v := range n.Hints {
+			hints[k] = v
+		}
+		n.Hints = hints
+	}
+	return n
+}
+
+// WithSummary returns a clone of n with the summary set.
+func (n Notification) WithSummary(summary string) Notification {
+	c := n.Clone()
+	c.Summary = summary
+	return c
+}
+
+// WithBody returns a clone of n with the body set.
+func (n Notification) WithBody(body string) Notification {
+	c := n.Clone()
+	c.Body = body
+	return c
+}
+
+// WithHint returns a clone of n with hint key set to value, see SetHint.
+func (n Notification) WithHint(key string, value interface{}) Notification {
+	c := n.Clone()
+	c.SetHint(key, value)
+	return c
+}
@@ -0,0 +1,55 @@
+// Command notify-bridge sends desktop notifications for D-Bus signals, as
+// configured by a JSON file of rules. See package bridge for the format.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/bridge"
+	"github.com/godbus/dbus/v5"
+)
+
+func main() {
+	config := flag.String("config", "", "JSON file with the rules")
+	system := flag.Bool("system", false, "watch the system bus instead of the session bus")
+	flag.Parse()
+	if *config == "" {
+		log.Fatalln("-config is required")
+	}
+	rules, err := bridge.Load(*config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	session, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	n, err := notify.New(session)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer n.Close()
+	go notify.Drain(n)
+
+	watched := session
+	if *system {
+		if watched, err = dbus.SystemBus(); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	b, err := bridge.New(watched, n, rules)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer b.Close()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+}
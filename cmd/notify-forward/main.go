@@ -0,0 +1,179 @@
+// Command notify-forward gets the notifications of one machine shown on
+// another.
+//
+// On the machine showing the notifications, listen for them over TLS:
+//
+//	notify-forward -listen :7070 -cert laptop.crt -key laptop.key -ca ca.crt
+//
+// On the machine sending them, run it as the notification server of the
+// session and forward everything to the first machine:
+//
+//	notify-forward -to laptop:7070 -cert server.crt -key server.key -ca ca.crt
+//
+// With -ca both sides verify each other's certificate. -listen refuses to
+// run without it, as anyone reaching the port could then show notifications,
+// unless the notifications are sealed (see below) and
+// -insecure-no-client-auth is given: the key then keeps out strangers, but
+// they can still connect. To go over SSH
+// instead, run notify-relay on the showing machine, forward its socket with
+// ssh -R /tmp/notify.sock:/run/user/1000/notify-relay.sock, and use
+// -to unix:/tmp/notify.sock.
+//
+// With -seal both sides also seal the notifications with a pre-shared key,
+// so whatever relays them in between, a jump host or a shared socket, can't
+// read them. Create the key file with
+//
+//	head -c 32 /dev/urandom | base64 > notify.seal
+//
+// and copy it to both machines.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/relay"
+	"github.com/esiqveland/notify/seal"
+	"github.com/esiqveland/notify/server"
+	"github.com/godbus/dbus/v5"
+)
+
+func main() {
+	listen := flag.String("listen", "", "address to receive notifications on")
+	to := flag.String("to", "", "address to forward notifications to, or unix:path")
+	certFile := flag.String("cert", "", "certificate file")
+	keyFile := flag.String("key", "", "private key file")
+	caFile := flag.String("ca", "", "CA certificate to verify the other side with")
+	sealFile := flag.String("seal", "", "file with the pre-shared key to seal notifications with")
+	noClientAuth := flag.Bool("insecure-no-client-auth", false, "with -listen and -seal, accept clients without verifying their certificate")
+	flag.Parse()
+
+	if (*listen == "") == (*to == "") {
+		log.Fatalln("exactly one of -listen and -to is required")
+	}
+	if *listen != "" && *caFile == "" {
+		if *sealFile == "" || !*noClientAuth {
+			log.Fatalln("-listen requires -ca, or -seal with -insecure-no-client-auth")
+		}
+	}
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	config, err := tlsConfig(*certFile, *keyFile, *caFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var key *seal.Key
+	if *sealFile != "" {
+		k, err := seal.LoadKey(*sealFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		key = &k
+	}
+	if *listen != "" {
+		receive(conn, *listen, config, key)
+	} else {
+		forward(conn, *to, config, key)
+	}
+}
+
+// receive shows the notifications received on address.
+func receive(conn *dbus.Conn, address string, config *tls.Config, key *seal.Key) {
+	if len(config.Certificates) == 0 {
+		log.Fatalln("-listen requires -cert and -key")
+	}
+	if config.ClientCAs != nil {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	n, err := notify.New(conn)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer n.Close()
+	go notify.Drain(n)
+
+	l, err := tls.Listen("tcp", address, config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	go func() {
+		waitForSignal()
+		l.Close()
+	}()
+	serve := relay.Serve
+	if key != nil {
+		serve = func(l net.Listener, n notify.Notifier) error {
+			return relay.ServeSealed(l, n, *key)
+		}
+	}
+	if err := serve(l, n); err != nil {
+		log.Printf("error accepting connections: %v", err)
+	}
+}
+
+// forward becomes the notification server of the session and forwards
+// every notification to address.
+func forward(conn *dbus.Conn, address string, config *tls.Config, key *seal.Key) {
+	dial := func() (net.Conn, error) {
+		return tls.Dial("tcp", address, config)
+	}
+	if path := strings.TrimPrefix(address, "unix:"); path != address {
+		dial = func() (net.Conn, error) {
+			return net.Dial("unix", path)
+		}
+	}
+	forwarder := relay.NewForwarder(dial)
+	if key != nil {
+		forwarder = relay.NewSealedForwarder(dial, *key)
+	}
+	defer forwarder.Close()
+
+	srv, err := server.New(conn, forwarder)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer srv.Close()
+	waitForSignal()
+}
+
+func tlsConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates in " + caFile)
+		}
+		config.RootCAs = pool
+		config.ClientCAs = pool
+	}
+	return config, nil
+}
+
+func waitForSignal() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+}
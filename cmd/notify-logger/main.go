@@ -0,0 +1,266 @@
+// Command notify-logger keeps a history of the desktop notifications of all
+// applications, whatever the notification server, and searches and exports
+// it:
+//
+//	notify-logger run                    record notifications until interrupted
+//	notify-logger search [-app name] [-category name] [-since when] [-until when]
+//	                     [-outcome list] [-oldest] [-offset n] [-limit n] [text]
+//	notify-logger export [-format json|csv|report] [search flags] [text]
+//
+// The history is kept in the file given by -db, see history.DefaultPath.
+// Times given to search are dates, 2006-01-02, RFC 3339 times, or durations
+// before now, like 24h.
+//
+// Notifications are kept for 30 days by default, and transient ones not at
+// all; run prunes the history by the retention flags, and search and export
+// leave out what it would prune:
+//
+//	-max-age 720h          remove notifications shown longer ago, 0 keeps them
+//	-max-count 0           keep at most this many, 0 for no limit
+//	-keep-transient        keep notifications with the transient hint
+//	-exclude-categories im,email
+//	                       don't keep these categories and their subcategories
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/history"
+	"github.com/esiqveland/notify/monitor"
+	"github.com/godbus/dbus/v5"
+)
+
+func main() {
+	log.SetFlags(0)
+	db := flag.String("db", history.DefaultPath(), "history file")
+	var retention history.Retention
+	flag.DurationVar(&retention.MaxAge, "max-age", 30*24*time.Hour, "remove notifications shown longer ago, 0 keeps them")
+	flag.IntVar(&retention.MaxCount, "max-count", 0, "keep at most this many notifications, 0 for no limit")
+	keepTransient := flag.Bool("keep-transient", false, "keep notifications with the transient hint")
+	exclude := flag.String("exclude-categories", "", "comma separated categories not to keep, with their subcategories")
+	flag.Usage = usage
+	flag.Parse()
+	retention.ExcludeTransient = !*keepTransient
+	for _, c := range strings.Split(*exclude, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			retention.ExcludeCategories = append(retention.ExcludeCategories, c)
+		}
+	}
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	// only the recorder writes, and prunes, the history: a prune replaces
+	// the file, which a recorder running meanwhile would go on appending
+	// to the old one of. The others apply the retention in memory.
+	open := history.OpenReadOnly
+	if flag.Arg(0) == "run" {
+		open = history.Open
+	}
+	s, err := open(*db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer s.Close()
+	if n := s.Corrupt(); n > 0 {
+		log.Printf("skipped %v corrupt lines of %v", n, *db)
+	}
+	if err := s.SetRetention(retention); err != nil {
+		log.Fatalln(err)
+	}
+
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "run":
+		err = run(s)
+	case "search":
+		err = search(s, args)
+	case "export":
+		err = export(s, args)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run records the notifications of the session bus until interrupted.
+func run(s *history.Store) error {
+	// a monitor connection is good for nothing else, so a private one.
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return err
+	}
+	// the shared connection looks up who sends the notifications.
+	bus, err := dbus.SessionBus()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	m, err := monitor.New(conn, monitor.WithCredentials(bus))
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("becoming a bus monitor: %w", err)
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		m.Close()
+	}()
+
+	for e := range m.Events() {
+		switch e.Kind {
+		case monitor.Notified:
+			_, err = s.Add(history.Entry{Time: e.Time, ID: e.ID, Sender: e.Sender, Credentials: e.Credentials,
+				Notification: e.Notification})
+		case monitor.Closed:
+			err = s.MarkClosed(e.ID, e.Reason, e.Time)
+		case monitor.ActionInvoked:
+			err = s.MarkInvoked(e.ID, e.ActionKey, e.Time)
+		}
+		if err != nil {
+			log.Printf("error recording notification: %v", err)
+		}
+	}
+	return nil
+}
+
+// search prints the entries matching the flags, with the words of text in
+// their summary or body.
+func search(s *history.Store, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	query := queryFlags(fs)
+	fs.Parse(args)
+	q, err := query()
+	if err != nil {
+		return err
+	}
+	page := s.Query(q)
+	for _, e := range page.Entries {
+		printEntry(e)
+	}
+	if next, more := q.Next(page); more {
+		fmt.Fprintf(os.Stderr, "%v of %v, next page with -offset %v\n", len(page.Entries), page.Total, next.Offset)
+	}
+	return nil
+}
+
+// queryFlags defines the flags of a history.Query on fs, and returns the
+// function making the query once fs is parsed. The arguments left are the
+// text searched for.
+func queryFlags(fs *flag.FlagSet) func() (history.Query, error) {
+	var q history.Query
+	fs.StringVar(&q.App, "app", "", "only notifications of the application with this name")
+	fs.StringVar(&q.Category, "category", "", "only notifications of this category and its subcategories")
+	since := fs.String("since", "", "only notifications shown at or after this time")
+	until := fs.String("until", "", "only notifications shown before this time")
+	outcomes := fs.String("outcome", "", "only notifications with one of these comma separated outcomes, e.g. Dismissed,Expired")
+	oldest := fs.Bool("oldest", false, "oldest first")
+	fs.IntVar(&q.Offset, "offset", 0, "skip this many notifications")
+	fs.IntVar(&q.Limit, "limit", 0, "at most this many notifications")
+	return func() (history.Query, error) {
+		q.Text = strings.Join(fs.Args(), " ")
+		if *oldest {
+			q.Order = history.OldestFirst
+		}
+		var err error
+		if q.Since, err = parseTime(*since); err != nil {
+			return q, err
+		}
+		if q.Until, err = parseTime(*until); err != nil {
+			return q, err
+		}
+		if *outcomes != "" {
+			for _, name := range strings.Split(*outcomes, ",") {
+				var o notify.Outcome
+				if err := o.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+					return q, err
+				}
+				q.Outcomes = append(q.Outcomes, o)
+			}
+		}
+		return q, nil
+	}
+}
+
+// parseTime parses a date, an RFC 3339 time or a duration before now.
+// Empty is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want 2006-01-02, RFC 3339 or a duration like 24h", s)
+	}
+	return t, nil
+}
+
+func printEntry(e history.Entry) {
+	note := e.Notification
+	line := e.Time.Local().Format("2006-01-02 15:04:05") + " "
+	if note.AppName != "" {
+		line += notify.StripControl(note.AppName) + ": "
+	}
+	line += notify.StripControl(note.Summary)
+	if body := notify.StripControl(strings.Join(strings.Fields(note.Body), " ")); body != "" {
+		line += " - " + body
+	}
+	fmt.Printf("%v [%v]\n", line, e.Outcome)
+}
+
+// export writes the entries matching the flags, oldest first, in the format
+// asked for.
+func export(s *history.Store, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "json", "json, csv or report")
+	query := queryFlags(fs)
+	fs.Parse(args)
+	q, err := query()
+	if err != nil {
+		return err
+	}
+	q.Order = history.OldestFirst
+	entries := s.Query(q).Entries
+	switch *format {
+	case "json":
+		return history.WriteJSON(os.Stdout, entries)
+	case "csv":
+		return history.WriteCSV(os.Stdout, entries)
+	case "report":
+		return history.WriteReport(os.Stdout, entries, time.Local)
+	default:
+		return fmt.Errorf("unknown format: %v", *format)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: notify-logger [-db path] [-max-age d] [-max-count n] [-keep-transient]
+                     [-exclude-categories list] command [arguments]
+
+commands:
+  run                          record notifications until interrupted
+  search [-app name] [-category name] [-since when] [-until when]
+         [-outcome list] [-oldest] [-offset n] [-limit n] [text]
+                               print notifications containing the words of text
+  export [-format json|csv|report] [search flags] [text]
+                               write the notifications matching the flags`)
+}
@@ -0,0 +1,235 @@
+// Command notify-probe shows what the running notification server really
+// supports. It reports the server and the capabilities it advertises, then
+// sends a gallery of notifications (markup, links, icons, images, urgencies,
+// actions, progress and sounds) and asks after each whether it showed as
+// described:
+//
+//	notify-probe [-no-ask] [-delay 3s] [-wait 15s]
+//
+// Actions are checked by clicking them; the rest is answered on the
+// terminal. With -no-ask the gallery is only shown, -delay apart. The report
+// at the end puts what was advertised next to what was seen, since servers
+// often advertise more, or less, than they render.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+// item is one notification of the gallery.
+type item struct {
+	name     string
+	cap      string // the capability advertising it, if any
+	question string // asked after it is shown
+	note     notify.Notification
+	action   string // key of the action to click, checked by itself
+}
+
+// result is what became of an item.
+type result struct {
+	item
+	advertised string // yes, no or -
+	seen       string // yes, no, skipped or failed
+}
+
+// square returns an image-data hint of a size by size square of one color.
+func square(size int, r, g, b byte) notify.ImageData {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Rect, image.NewUniform(color.RGBA{r, g, b, 0xff}), image.Point{}, draw.Src)
+	return notify.NewImageData(img)
+}
+
+func gallery() []item {
+	note := func(summary, body string) notify.Notification {
+		return notify.Notification{
+			AppName:       "notify-probe",
+			Summary:       summary,
+			Body:          body,
+			ExpireTimeout: 0,
+		}
+	}
+	var items []item
+
+	plain := note("Plain", "A summary and this body.")
+	items = append(items, item{name: "body", cap: "body", question: "Is the body text shown?", note: plain})
+
+	markup := note("Markup", "<b>bold</b>, <i>italic</i> and <u>underlined</u>")
+	items = append(items, item{name: "markup", cap: "body-markup",
+		question: "Are the words styled, without the tags showing?", note: markup})
+
+	link := note("Hyperlinks", `<a href="https://specifications.freedesktop.org/notification-spec/">the spec</a>`)
+	items = append(items, item{name: "hyperlinks", cap: "body-hyperlinks",
+		question: `Is "the spec" shown as a link?`, note: link})
+
+	icon := note("Icon", "This one has the dialog-information icon.")
+	icon.AppIcon = "dialog-information"
+	items = append(items, item{name: "icon", cap: "icon-static", question: "Is an information icon shown?", note: icon})
+
+	image := note("Image", "This one has a red square as its image.")
+	image.SetHint("image-data", square(48, 0xd0, 0x20, 0x20))
+	items = append(items, item{name: "image", question: "Is a red square shown?", note: image})
+
+	low := note("Low urgency", "This one is of low urgency.")
+	low.SetUrgency(notify.UrgencyLow)
+	items = append(items, item{name: "urgency-low", question: "Does it look different from the plain one?", note: low})
+
+	critical := note("Critical urgency", "This one is critical.")
+	critical.SetUrgency(notify.UrgencyCritical)
+	items = append(items, item{name: "urgency-critical", question: "Is it shown as critical, e.g. in red?", note: critical})
+
+	actions := note("Actions", "Click Try me.")
+	actions.Actions = []string{"try", "Try me"}
+	items = append(items, item{name: "actions", cap: "actions", note: actions, action: "try"})
+
+	progress := note("Progress", "This one is 60% done.")
+	progress.SetProgress(60)
+	items = append(items, item{name: "progress", question: "Is a progress bar shown?", note: progress})
+
+	sound := note("Sound", "This one plays the message-new-instant sound.")
+	sound.SetHint(notify.HintSoundName, "message-new-instant")
+	items = append(items, item{name: "sound", cap: "sound", question: "Did a sound play?", note: sound})
+
+	return items
+}
+
+func main() {
+	log.SetFlags(0)
+	noAsk := flag.Bool("no-ask", false, "only show the gallery, without asking")
+	delay := flag.Duration("delay", 3*time.Second, "time between notifications with -no-ask")
+	wait := flag.Duration("wait", 15*time.Second, "how long to wait for an action to be clicked")
+	flag.Parse()
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	n, err := notify.New(conn)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer n.Close()
+	go func() {
+		for range n.NotificationClosed() {
+		}
+	}()
+
+	info, err := n.GetServerInformation()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		log.Fatalf("no notification server: %v\n\n%v", err, notify.Diagnose(ctx))
+	}
+	caps, err := n.GetCapabilities()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("server: %v %v (%v), spec %v\n", info.Name, info.Version, info.Vendor, info.SpecVersion)
+	fmt.Printf("capabilities: %v\n\n", strings.Join(caps, " "))
+	advertised := make(map[string]bool)
+	for _, c := range caps {
+		advertised[c] = true
+	}
+
+	answers := bufio.NewScanner(os.Stdin)
+	var results []result
+	for _, it := range gallery() {
+		r := result{item: it, advertised: "-"}
+		if it.cap != "" {
+			r.advertised = yesNo(advertised[it.cap])
+		}
+		id, err := n.SendNotification(it.note)
+		if err != nil {
+			fmt.Printf("%v: %v\n", it.name, err)
+			r.seen = "failed"
+			results = append(results, r)
+			continue
+		}
+		switch {
+		case it.action != "":
+			fmt.Printf("%v: click %q within %v\n", it.name, it.note.Actions[1], *wait)
+			r.seen = yesNo(clicked(n, id, it.action, *wait))
+		case *noAsk:
+			fmt.Printf("%v: %v\n", it.name, it.question)
+			time.Sleep(*delay)
+			r.seen = "skipped"
+		default:
+			r.seen = ask(answers, fmt.Sprintf("%v: %v [y/n/s] ", it.name, it.question))
+		}
+		n.CloseNotification(id)
+		results = append(results, r)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FEATURE\tCAPABILITY\tADVERTISED\tSEEN\t")
+	for _, r := range results {
+		capability := r.cap
+		if capability == "" {
+			capability = "-"
+		}
+		mismatch := ""
+		if r.advertised != "-" && (r.seen == "yes" || r.seen == "no") && r.advertised != r.seen {
+			mismatch = "mismatch"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", r.name, capability, r.advertised, r.seen, mismatch)
+	}
+	w.Flush()
+}
+
+// clicked waits for the action key of notification id to be invoked.
+func clicked(n notify.Notifier, id uint32, key string, wait time.Duration) bool {
+	timeout := time.After(wait)
+	for {
+		select {
+		case a, ok := <-n.ActionInvoked():
+			if !ok {
+				return false
+			}
+			if a.Id == id && a.ActionKey == key {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+// ask asks question on the terminal until it is answered.
+func ask(answers *bufio.Scanner, question string) string {
+	for {
+		fmt.Print(question)
+		if !answers.Scan() {
+			fmt.Println()
+			return "skipped"
+		}
+		switch strings.ToLower(strings.TrimSpace(answers.Text())) {
+		case "y", "yes":
+			return "yes"
+		case "n", "no":
+			return "no"
+		case "s", "skip":
+			return "skipped"
+		}
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
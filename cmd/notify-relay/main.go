@@ -0,0 +1,79 @@
+// Command notify-relay runs in a user session and forwards notifications
+// from system services to the session notification server.
+// See package relay for the protocol.
+//
+// By default it listens on notify-relay.sock in $XDG_RUNTIME_DIR, which only
+// the user can enter, so only the user's own processes can connect. To let
+// service users in, give -mode with group or other bits and a -socket in a
+// directory they can reach, e.g. one owned by the user and a group of the
+// services with mode 0750:
+//
+//	notify-relay -mode 0660 -socket /run/notify-relay/alice.sock
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/relay"
+	"github.com/godbus/dbus/v5"
+)
+
+func main() {
+	path := flag.String("socket", "", "path of the socket to listen on, notify-relay.sock in $XDG_RUNTIME_DIR by default")
+	mode := flag.Uint("mode", 0600, "permissions of the socket; allow the group or others, with -socket, to let service users connect")
+	flag.Parse()
+
+	if *mode&^0777 != 0 {
+		log.Fatalf("invalid -mode %#o", *mode)
+	}
+	if *path == "" {
+		// the runtime directory is private to the user: a more permissive
+		// mode there would suggest access that doesn't exist.
+		if *mode&0077 != 0 {
+			log.Fatalln("-mode allowing the group or others needs a -socket in a directory they can reach")
+		}
+		*path = relay.SocketPath(os.Getuid())
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			*path = filepath.Join(dir, "notify-relay.sock")
+		}
+	}
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	n, err := notify.New(conn)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer n.Close()
+	go notify.Drain(n)
+
+	os.Remove(*path)
+	// create the socket with its mode, rather than chmod it after it was
+	// already open to connections for a moment.
+	umask := syscall.Umask(0777 &^ int(*mode))
+	l, err := net.Listen("unix", *path)
+	syscall.Umask(umask)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		l.Close()
+	}()
+
+	if err := relay.Serve(l, n); err != nil {
+		log.Printf("error accepting connections: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+// Command notify-tui is a notification daemon showing notifications in the
+// terminal it runs in. Useful for headless and SSH sessions, and for testing
+// applications without a graphical notification daemon.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/esiqveland/notify/server"
+	"github.com/godbus/dbus/v5"
+	"golang.org/x/term"
+)
+
+func main() {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	tui := server.NewTerminal(os.Stdin, os.Stdout)
+	srv, err := server.New(conn, tui)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer srv.Close()
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer term.Restore(fd, state)
+	}
+
+	if err := tui.Run(srv); err != nil {
+		log.Printf("error reading terminal: %v", err)
+	}
+}
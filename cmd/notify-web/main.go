@@ -0,0 +1,51 @@
+// Command notify-web is a notification daemon showing notifications on a
+// web page, for kiosks and remote displays:
+//
+//	notify-web [-addr localhost:8080] [-hosts kiosk.lan,192.168.1.20]
+//
+// Open http://localhost:8080/ in a browser. Anyone who can open the page
+// can see the notifications and invoke their actions, so only listen on
+// other addresses in trusted networks. Requests are only answered for
+// localhost, the host of -addr and -hosts, the names the page is opened
+// under.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/esiqveland/notify/server"
+	"github.com/esiqveland/notify/server/web"
+	"github.com/godbus/dbus/v5"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to serve the page on")
+	hostList := flag.String("hosts", "", "comma separated host names the page may be opened under besides localhost")
+	flag.Parse()
+
+	var hosts []string
+	if *hostList != "" {
+		hosts = strings.Split(*hostList, ",")
+	}
+	if host, _, err := net.SplitHostPort(*addr); err == nil && host != "" {
+		hosts = append(hosts, host)
+	}
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	r := web.New(hosts...)
+	srv, err := server.New(conn, r)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer srv.Close()
+
+	log.Printf("showing notifications on http://%v/", *addr)
+	log.Fatalln(http.ListenAndServe(*addr, r.Handler(srv)))
+}
@@ -0,0 +1,73 @@
+//go:build unix
+
+// Command notify-wlr is a notification daemon showing notifications as
+// popups on wlroots based Wayland compositors, like sway or river, using
+// wlr-layer-shell:
+//
+//	notify-wlr [-anchor top-right] [-width 360] [-scale 2] [-margin 10]
+//
+// Clicking a popup invokes its default action, clicking one of its buttons
+// that action, and a right click dismisses it.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/esiqveland/notify/server"
+	"github.com/esiqveland/notify/server/layershell"
+	"github.com/godbus/dbus/v5"
+)
+
+var anchors = map[string]layershell.Anchor{
+	"top-right":    layershell.TopRight,
+	"top-left":     layershell.TopLeft,
+	"bottom-right": layershell.BottomRight,
+	"bottom-left":  layershell.BottomLeft,
+}
+
+func main() {
+	anchor := flag.String("anchor", "top-right", "corner of the screen: top-right, top-left, bottom-right or bottom-left")
+	width := flag.Int("width", 360, "width of the popups in pixels")
+	scale := flag.Int("scale", 2, "size of the pixels of the font")
+	margin := flag.Int("margin", 10, "distance from the edges of the screen in pixels")
+	flag.Parse()
+	a, ok := anchors[*anchor]
+	if !ok {
+		log.Fatalf("unknown anchor: %v", *anchor)
+	}
+
+	r, err := layershell.New(
+		layershell.WithAnchor(a),
+		layershell.WithWidth(*width),
+		layershell.WithScale(*scale),
+		layershell.WithMargin(*margin),
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer r.Close()
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	srv, err := server.New(conn, r)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer srv.Close()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		r.Close()
+	}()
+	if err := r.Run(srv); err != nil {
+		log.Printf("error: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Command notifyctl sends and closes notifications through a running
+// notify-relay, like dunstctl and notify-send rolled into one:
+//
+//	notifyctl send [-icon name] [-urgency low|normal|critical] [-timeout ms] summary [body]
+//	notifyctl close ID
+//	notifyctl capabilities
+//	notifyctl info
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/relay"
+)
+
+func main() {
+	log.SetFlags(0)
+	socket := flag.String("socket", relay.SocketPath(os.Getuid()), "socket of the relay")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	c, err := relay.Dial(*socket)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer c.Close()
+
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "send":
+		err = send(c, args)
+	case "close":
+		if len(args) != 1 {
+			usage()
+			os.Exit(2)
+		}
+		var id uint64
+		id, err = strconv.ParseUint(args[0], 10, 32)
+		if err == nil {
+			err = c.CloseNotification(uint32(id))
+		}
+	case "capabilities":
+		var caps []string
+		caps, err = c.GetCapabilities()
+		if err == nil {
+			fmt.Println(strings.Join(caps, "\n"))
+		}
+	case "info":
+		var info notify.ServerInformation
+		info, err = c.GetServerInformation()
+		if err == nil {
+			fmt.Printf("%v %v (%v), spec %v\n", info.Name, info.Version, info.Vendor, info.SpecVersion)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func send(c *relay.Client, args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	appName := fs.String("app-name", "notifyctl", "application name")
+	icon := fs.String("icon", "", "icon name or path")
+	urgency := fs.String("urgency", "normal", "low, normal or critical")
+	timeout := fs.Int("timeout", -1, "expire timeout in milliseconds, -1 for the server default")
+	replaces := fs.Uint("replaces", 0, "ID of the notification to replace")
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	note := notify.Notification{
+		AppName:       *appName,
+		ReplacesID:    uint32(*replaces),
+		AppIcon:       *icon,
+		Summary:       fs.Arg(0),
+		Body:          fs.Arg(1),
+		ExpireTimeout: int32(*timeout),
+	}
+	switch *urgency {
+	case "low":
+		note.SetUrgency(notify.UrgencyLow)
+	case "normal":
+		note.SetUrgency(notify.UrgencyNormal)
+	case "critical":
+		note.SetUrgency(notify.UrgencyCritical)
+	default:
+		return fmt.Errorf("unknown urgency: %v", *urgency)
+	}
+	id, err := c.SendNotification(note)
+	if err != nil {
+		return err
+	}
+	fmt.Println(id)
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: notifyctl [-socket path] command [arguments]
+
+commands:
+  send [-app-name name] [-icon name] [-urgency low|normal|critical] [-timeout ms] [-replaces id] summary [body]
+  close id
+  capabilities
+  info`)
+}
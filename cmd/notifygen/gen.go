@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog is the YAML catalog of the notification kinds of an application.
+type Catalog struct {
+	App   string `yaml:"app"`
+	Icon  string `yaml:"icon"`
+	Kinds []Kind `yaml:"kinds"`
+}
+
+// Kind is one kind of notification.
+type Kind struct {
+	ID       string   `yaml:"id"`
+	Category string   `yaml:"category"`
+	Urgency  string   `yaml:"urgency"`
+	Icon     string   `yaml:"icon"`
+	Params   []string `yaml:"params"` // "name type"
+	Summary  string   `yaml:"summary"`
+	Body     string   `yaml:"body"`
+	Timeout  *int32   `yaml:"timeout"`
+	Actions  []Action `yaml:"actions"`
+}
+
+// Action is an action of a kind.
+type Action struct {
+	Key   string `yaml:"key"`
+	Label string `yaml:"label"`
+}
+
+var urgencies = map[string]string{
+	"low":      "notify.UrgencyLow",
+	"normal":   "notify.UrgencyNormal",
+	"critical": "notify.UrgencyCritical",
+}
+
+func loadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Catalog
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return &c, nil
+}
+
+// The data the code template is executed with.
+type (
+	file struct {
+		Source  string
+		Package string
+		App     string
+		Kinds   []kind
+		UsesFmt bool
+	}
+	kind struct {
+		ID       string
+		Name     string // Go name
+		Icon     string
+		Category string
+		Urgency  string // Go expression, empty for none
+		Timeout  int32
+		Params   []param
+		Summary  text
+		Body     text
+		Actions  []action
+	}
+	param struct {
+		Name  string // as in the catalog
+		Field string
+		Type  string
+	}
+	// text is a summary or body as a format and its arguments.
+	text struct {
+		Format string
+		Args   []string
+	}
+	action struct {
+		Key    string
+		Label  string
+		Method string
+	}
+)
+
+func generate(c *Catalog, pkg, source string) ([]byte, error) {
+	f := file{Source: source, Package: pkg, App: c.App}
+	ids := make(map[string]bool)
+	for _, k := range c.Kinds {
+		if ids[k.ID] {
+			return nil, fmt.Errorf("kind %q given twice", k.ID)
+		}
+		ids[k.ID] = true
+		gk, err := convert(c, k)
+		if err != nil {
+			return nil, fmt.Errorf("kind %q: %w", k.ID, err)
+		}
+		f.UsesFmt = f.UsesFmt || len(gk.Summary.Args) > 0 || len(gk.Body.Args) > 0
+		f.Kinds = append(f.Kinds, gk)
+	}
+	var buf bytes.Buffer
+	if err := code.Execute(&buf, f); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %w", err)
+	}
+	return src, nil
+}
+
+func convert(c *Catalog, k Kind) (kind, error) {
+	gk := kind{
+		ID:       k.ID,
+		Name:     goName(k.ID),
+		Icon:     k.Icon,
+		Category: k.Category,
+		Timeout:  -1,
+	}
+	if !token.IsIdentifier(gk.Name) {
+		return gk, fmt.Errorf("id doesn't make a Go name")
+	}
+	if gk.Icon == "" {
+		gk.Icon = c.Icon
+	}
+	if k.Timeout != nil {
+		gk.Timeout = *k.Timeout
+	}
+	if k.Urgency != "" {
+		u, ok := urgencies[k.Urgency]
+		if !ok {
+			return gk, fmt.Errorf("unknown urgency %q", k.Urgency)
+		}
+		gk.Urgency = u
+	}
+	params := make(map[string]param)
+	for _, p := range k.Params {
+		fields := strings.Fields(p)
+		if len(fields) < 2 {
+			return gk, fmt.Errorf("param %q is not \"name type\"", p)
+		}
+		gp := param{Name: fields[0], Field: goName(fields[0]), Type: strings.Join(fields[1:], " ")}
+		if !token.IsIdentifier(gp.Field) || params[gp.Name].Name != "" {
+			return gk, fmt.Errorf("invalid or repeated param %q", gp.Name)
+		}
+		params[gp.Name] = gp
+		gk.Params = append(gk.Params, gp)
+	}
+	var err error
+	if gk.Summary, err = parseText(k.Summary, params); err != nil {
+		return gk, fmt.Errorf("summary: %w", err)
+	}
+	if gk.Body, err = parseText(k.Body, params); err != nil {
+		return gk, fmt.Errorf("body: %w", err)
+	}
+	methods := make(map[string]bool)
+	for _, a := range k.Actions {
+		ga := action{Key: a.Key, Label: a.Label, Method: "On" + goName(a.Key)}
+		if a.Key == "" || !token.IsIdentifier(ga.Method) || methods[ga.Method] {
+			return gk, fmt.Errorf("invalid or repeated action %q", a.Key)
+		}
+		methods[ga.Method] = true
+		gk.Actions = append(gk.Actions, ga)
+	}
+	return gk, nil
+}
+
+// parseText turns s with {param} placeholders into a format for
+// fmt.Sprintf and the fields it takes.
+func parseText(s string, params map[string]param) (text, error) {
+	var t text
+	var format strings.Builder
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "{{"):
+			format.WriteByte('{')
+			s = s[2:]
+		case strings.HasPrefix(s, "}}"):
+			format.WriteByte('}')
+			s = s[2:]
+		case s[0] == '{':
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				return t, fmt.Errorf("unclosed {")
+			}
+			p, ok := params[s[1:end]]
+			if !ok {
+				return t, fmt.Errorf("unknown param {%s}", s[1:end])
+			}
+			format.WriteString("%v")
+			t.Args = append(t.Args, "p."+p.Field)
+			s = s[end+1:]
+		case s[0] == '%':
+			format.WriteString("%%")
+			s = s[1:]
+		default:
+			format.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	t.Format = format.String()
+	return t, nil
+}
+
+// goName returns the exported Go name for an id like new_mail or new-mail.
+func goName(id string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(id, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r == ' '
+	}) {
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// Expr returns the Go expression for t.
+func (t text) Expr() string {
+	if len(t.Args) == 0 {
+		return strconv.Quote(strings.ReplaceAll(t.Format, "%%", "%"))
+	}
+	return fmt.Sprintf("fmt.Sprintf(%s, %s)", strconv.Quote(t.Format), strings.Join(t.Args, ", "))
+}
+
+var code = template.Must(template.New("code").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by notifygen from {{.Source}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .UsesFmt}}
+	"fmt"
+{{end}}
+	"github.com/esiqveland/notify"
+)
+{{range .Kinds}}
+// {{.Name}}Params are the params of {{.ID}} notifications.
+type {{.Name}}Params struct {
+{{- range .Params}}
+	{{.Field}} {{.Type}}
+{{- end}}
+}
+
+// {{.Name}}Template renders {{.ID}} notifications.
+var {{.Name}}Template = notify.Template[{{.Name}}Params]{
+	Base: notify.Notification{
+		AppName: {{quote $.App}},
+		AppIcon: {{quote .Icon}},
+{{- if .Actions}}
+		Actions: []string{ {{- range .Actions}}{{quote .Key}}, {{quote .Label}}, {{end -}} },
+{{- end}}
+		ExpireTimeout: {{.Timeout}},
+	},
+	Summary: func(p {{.Name}}Params) string { return {{.Summary.Expr}} },
+	Body:    func(p {{.Name}}Params) string { return {{.Body.Expr}} },
+{{- if or .Category .Urgency}}
+	Hints: func({{.Name}}Params) map[string]interface{} {
+		return map[string]interface{}{
+{{- if .Category}}
+			notify.HintCategory: {{quote .Category}},
+{{- end}}
+{{- if .Urgency}}
+			notify.HintUrgency: {{.Urgency}},
+{{- end}}
+		}
+	},
+{{- end}}
+}
+
+// {{.Name}} returns a {{.ID}} notification.
+func {{.Name}}(p {{.Name}}Params) (notify.Notification, error) {
+	return {{.Name}}Template.Render(p)
+}
+
+// Send{{.Name}} sends a {{.ID}} notification with n.
+func Send{{.Name}}(n notify.Notifier, p {{.Name}}Params) (uint32, error) {
+	return notify.Send(n, {{.Name}}Template, p)
+}
+{{- if .Actions}}
+
+// {{.Name}}Actions handles the actions of {{.ID}} notifications.
+type {{.Name}}Actions interface {
+{{- range .Actions}}
+	{{.Method}}(signal *notify.ActionInvokedSignal)
+{{- end}}
+}
+
+// Dispatch{{.Name}} calls the method of h for the action of signal, which
+// must be for a {{.ID}} notification, and reports whether it is one of its
+// actions.
+func Dispatch{{.Name}}(h {{.Name}}Actions, signal *notify.ActionInvokedSignal) bool {
+	switch signal.ActionKey {
+{{- range .Actions}}
+	case {{quote .Key}}:
+		h.{{.Method}}(signal)
+{{- end}}
+	default:
+		return false
+	}
+	return true
+}
+{{- end}}
+{{end}}`))
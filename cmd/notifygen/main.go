@@ -0,0 +1,67 @@
+// Command notifygen generates typed Go code for the notifications of an
+// application from a YAML catalog of their kinds, so that a large
+// application sends each kind the same way everywhere:
+//
+//	app: Mail
+//	icon: mail-unread
+//	kinds:
+//	  - id: new_mail
+//	    category: email.arrived
+//	    urgency: normal             # low, normal or critical
+//	    params: [from string, count int]
+//	    summary: "{from}"
+//	    body: "{count} new messages"
+//	    timeout: 5000               # ExpireTimeout in ms, -1 if left out
+//	    actions:
+//	      - {key: default, label: Open}
+//	      - {key: archive, label: Archive}
+//
+// For each kind it generates a struct of the params (NewMailParams), a
+// notify.Template for it (NewMailTemplate), a constructor returning the
+// notification (NewMail) and a function sending it (SendNewMail). {name} in
+// summary and body is replaced by the param; {{ and }} stand for literal
+// braces. Kinds with actions also get an interface with a method per action
+// (NewMailActions with OnDefault and OnArchive) and a function calling the
+// method for an ActionInvoked signal (DispatchNewMail).
+//
+// Use it with go generate:
+//
+//	//go:generate go run github.com/esiqveland/notify/cmd/notifygen -o notifications.go catalog.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	out := flag.String("o", "", "file to write, standard output if empty")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated code")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: notifygen [-o file] [-package name] catalog.yaml")
+		os.Exit(2)
+	}
+	if *pkg == "" {
+		*pkg = "main"
+	}
+	path := flag.Arg(0)
+	catalog, err := loadCatalog(path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	src, err := generate(catalog, *pkg, filepath.Base(path))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalln(err)
+	}
+}
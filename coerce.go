@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// hintTypes are the D-Bus types of the hints this package knows about.
+// Servers commonly ignore, or reject the whole notification for, a hint of
+// the wrong type, e.g. an urgency sent as an int32 instead of a byte.
+var hintTypes = map[string]string{
+	HintUrgency:       "y",
+	HintCategory:      "s",
+	HintSenderPID:     "x",
+	HintDesktopEntry:  "s",
+	HintResident:      "b",
+	HintTransient:     "b",
+	HintSoundName:     "s",
+	HintWindowID:      "u",
+	HintWaylandHandle: "s",
+	HintParentWindow:  "s",
+	HintStackTag:      "s",
+	"action-icons":    "b",
+	"image-path":      "s",
+	"sound-file":      "s",
+	"suppress-sound":  "b",
+	"x":               "i",
+	"y":               "i",
+	HintValue:         "i",
+
+	HintNemoPreviewSummary: "s",
+	HintNemoPreviewBody:    "s",
+	HintNemoItemCount:      "i",
+	HintNemoTimestamp:      "s",
+
+	HintDunstForeground: "s",
+	HintDunstBackground: "s",
+	HintDunstFrame:      "s",
+	HintDunstHighlight:  "s",
+}
+
+// MakeHints converts plain go values to hints. Values of known hints are
+// coerced to the type the spec gives them, so e.g. "urgency": 2,
+// "transient": "true" and "value": 42.0 (as decoded from JSON) all end up
+// with the right wire type. Other values are sent with their natural D-Bus
+// type, with int and uint narrowed to 32 bits when they fit.
+// Values that are already a dbus.Variant are used as is, as are the variants
+// returned by a HintMarshaler.
+//
+// An error is returned only for values that can't be represented: a known
+// hint with a value that doesn't convert to its type (e.g. an urgency of 300),
+// a go type D-Bus has no encoding for, or a HintMarshaler that fails.
+func MakeHints(values map[string]interface{}) (map[string]dbus.Variant, error) {
+	hints := make(map[string]dbus.Variant, len(values))
+	for k, value := range values {
+		v, err := makeHint(k, value)
+		if err != nil {
+			return nil, err
+		}
+		hints[k] = v
+	}
+	return hints, nil
+}
+
+// SetHints sets the hints in values, as converted by MakeHints.
+// On error the notification is left unchanged.
+func (n *Notification) SetHints(values map[string]interface{}) error {
+	hints, err := MakeHints(values)
+	if err != nil {
+		return err
+	}
+	if n.Hints == nil {
+		n.Hints = make(map[string]dbus.Variant, len(hints))
+	}
+	for k, v := range hints {
+		n.Hints[k] = v
+	}
+	return nil
+}
+
+func makeHint(key string, value interface{}) (dbus.Variant, error) {
+	switch x := value.(type) {
+	case dbus.Variant:
+		return x, nil
+	case HintMarshaler:
+		v, err := x.MarshalHint()
+		if err != nil {
+			return dbus.Variant{}, fmt.Errorf("notify: hint %v: %w", key, err)
+		}
+		return v, nil
+	}
+	if value == nil {
+		return dbus.Variant{}, fmt.Errorf("notify: hint %v: nil value", key)
+	}
+	sig, known := hintTypes[key]
+	if !known {
+		return naturalVariant(key, value)
+	}
+	coerced, ok := coerce(reflect.ValueOf(value), sig)
+	if !ok {
+		return dbus.Variant{}, fmt.Errorf("notify: hint %v: cannot use %T %v as D-Bus type %v", key, value, value, sig)
+	}
+	return dbus.MakeVariant(coerced), nil
+}
+
+// naturalVariant makes a variant of value with the type godbus gives it.
+func naturalVariant(key string, value interface{}) (v dbus.Variant, err error) {
+	switch x := value.(type) {
+	case int:
+		if x >= math.MinInt32 && x <= math.MaxInt32 {
+			return dbus.MakeVariant(int32(x)), nil
+		}
+		return dbus.MakeVariant(int64(x)), nil
+	case uint:
+		if x <= math.MaxUint32 {
+			return dbus.MakeVariant(uint32(x)), nil
+		}
+		return dbus.MakeVariant(uint64(x)), nil
+	}
+	// godbus panics on types without a D-Bus signature.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("notify: hint %v: %T can't be sent over D-Bus: %v", key, value, r)
+		}
+	}()
+	return dbus.MakeVariant(value), nil
+}
+
+// coerce converts v to the basic D-Bus type sig, if that loses nothing.
+func coerce(v reflect.Value, sig string) (interface{}, bool) {
+	switch sig {
+	case "s":
+		if v.Kind() == reflect.String {
+			return v.String(), true
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String(), true
+		}
+	case "b":
+		switch v.Kind() {
+		case reflect.Bool:
+			return v.Bool(), true
+		case reflect.String:
+			b, err := strconv.ParseBool(v.String())
+			return b, err == nil
+		}
+		if i, ok := coerceInt(v); ok && (i == 0 || i == 1) {
+			return i == 1, true
+		}
+	case "y":
+		if i, ok := coerceInt(v); ok && i >= 0 && i <= math.MaxUint8 {
+			return byte(i), true
+		}
+	case "i":
+		if i, ok := coerceInt(v); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+			return int32(i), true
+		}
+	case "u":
+		if i, ok := coerceInt(v); ok && i >= 0 && i <= math.MaxUint32 {
+			return uint32(i), true
+		}
+	case "x":
+		if i, ok := coerceInt(v); ok {
+			return i, true
+		}
+	}
+	return nil, false
+}
+
+// coerceInt returns v as an int64 if it holds a whole number: an integer in
+// range, a float without a fraction, or a string of decimal digits.
+func coerceInt(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	case reflect.String:
+		i, err := strconv.ParseInt(v.String(), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
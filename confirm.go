@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+const (
+	actionYes = "yes"
+	actionNo  = "no"
+)
+
+// ErrNotificationClosed is returned when a notification being waited on was
+// closed (expired, dismissed or closed by a call) without any action invoked.
+var ErrNotificationClosed = errors.New("notification closed without action")
+
+// ErrNotifierClosed is returned when the Notifier's signal channels were
+// closed while waiting.
+var ErrNotifierClosed = errors.New("notifier closed")
+
+// ErrNotShown is returned when waiting on ID 0, which SendNotification
+// returns for notifications it didn't show, e.g. those held back in quiet
+// hours or muted by a policy.
+var ErrNotShown = errors.New("notification not shown")
+
+// WaitForAction blocks until an action is invoked on the notification with
+// the given id, the notification is closed, or ctx is done.
+// It returns the invoked action key, ErrNotificationClosed if the
+// notification was closed, or ErrServerRestarted if it was lost with its
+// server, see WithRestartDetection. For id 0 it returns ErrNotShown right
+// away.
+//
+// A notification that never expires may wait for the user forever, and
+// servers may drop it without a signal, so give ctx a deadline.
+//
+// WaitForAction consumes the channels returned by ActionInvoked() and
+// NotificationClosed(), so it must not be used while another goroutine
+// is draining them. Signals for other notifications received while waiting
+// are discarded.
+func WaitForAction(ctx context.Context, n Notifier, id uint32) (string, error) {
+	if id == 0 {
+		return "", ErrNotShown
+	}
+	actions := n.ActionInvoked()
+	closed := n.NotificationClosed()
+	for {
+		select {
+		case action, ok := <-actions:
+			if !ok {
+				return "", ErrNotifierClosed
+			}
+			if action.Id == id {
+				return action.ActionKey, nil
+			}
+		case closer, ok := <-closed:
+			if !ok {
+				return "", ErrNotifierClosed
+			}
+			if closer.Id == id && closer.Reason == ReasonServerRestarted {
+				return "", ErrServerRestarted
+			}
+			if closer.Id == id {
+				return "", ErrNotificationClosed
+			}
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// Confirm shows a notification with Yes and No actions and waits for the
+// user to pick one. Dismissing the notification counts as No.
+//
+// The notification never expires, so ctx should have a deadline. If ctx is
+// done before the user answers, the notification is closed and ctx.Err() is
+// returned. ErrNotShown is returned if the notification was held back.
+// See WaitForAction for restrictions on concurrent use of the Notifier.
+func Confirm(ctx context.Context, n Notifier, summary, body string) (bool, error) {
+	key, err := ask(ctx, n, Notification{
+		Summary:       summary,
+		Body:          body,
+		Actions:       []string{actionYes, "Yes", actionNo, "No"},
+		ExpireTimeout: 0, // never expire
+	})
+	if err == ErrNotificationClosed {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return key == actionYes, nil
+}
+
+// Ask shows a notification with one action per choice and waits for the user
+// to pick one. It returns the chosen string.
+//
+// If the notification is dismissed, ErrNotificationClosed is returned, and
+// ErrNotShown if it was held back. The notification never expires, so ctx
+// should have a deadline. If ctx is done before the user answers, the
+// notification is closed and ctx.Err() is returned.
+// See WaitForAction for restrictions on concurrent use of the Notifier.
+func Ask(ctx context.Context, n Notifier, question string, choices ...string) (string, error) {
+	if len(choices) == 0 {
+		return "", errors.New("ask: no choices given")
+	}
+	// choices are keyed by index, so a choice can never collide with
+	// the special "default" action key.
+	actions := make([]string, 0, 2*len(choices))
+	for i, choice := range choices {
+		actions = append(actions, "choice-"+strconv.Itoa(i), choice)
+	}
+	key, err := ask(ctx, n, Notification{
+		Summary:       question,
+		Actions:       actions,
+		ExpireTimeout: 0, // never expire
+	})
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < len(actions); i += 2 {
+		if actions[i] == key {
+			return actions[i+1], nil
+		}
+	}
+	return "", errors.New("ask: unknown action key: " + key)
+}
+
+func ask(ctx context.Context, n Notifier, note Notification) (string, error) {
+	id, err := n.SendNotification(note)
+	if err != nil {
+		return "", err
+	}
+	key, err := WaitForAction(ctx, n, id)
+	if ctx.Err() != nil {
+		n.CloseNotification(id)
+	}
+	return key, err
+}
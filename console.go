@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ConsoleEscape is a terminal escape sequence that raises a desktop
+// notification from inside the terminal emulator.
+type ConsoleEscape int
+
+const (
+	EscapeNone   ConsoleEscape = iota
+	EscapeOSC777               // ESC ] 777 ; notify ; summary ; body, foot, urxvt, VTE with the Fedora patch
+	EscapeOSC9                 // ESC ] 9 ; message, iTerm2, kitty, WezTerm, Windows Terminal
+)
+
+// ConsoleStyle is how a ConsoleNotifier writes notifications.
+type ConsoleStyle struct {
+	Color  bool // color lines by urgency with ANSI escapes
+	Escape ConsoleEscape
+}
+
+// DetectConsoleStyle returns the style for writing to f: color if it is a
+// terminal and NO_COLOR is not set, and the notification escape the
+// terminal emulator is known to support.
+func DetectConsoleStyle(f *os.File) ConsoleStyle {
+	var style ConsoleStyle
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 || os.Getenv("TERM") == "dumb" {
+		return style
+	}
+	_, noColor := os.LookupEnv("NO_COLOR")
+	style.Color = !noColor
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app", os.Getenv("TERM_PROGRAM") == "WezTerm",
+		os.Getenv("WT_SESSION") != "", strings.HasPrefix(term, "xterm-kitty"):
+		style.Escape = EscapeOSC9
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "rxvt"), os.Getenv("VTE_VERSION") != "":
+		style.Escape = EscapeOSC777
+	}
+	return style
+}
+
+// ANSI colors of the urgencies.
+var urgencyColors = map[Urgency]string{
+	UrgencyLow:      "\x1b[2m",    // dim
+	UrgencyNormal:   "\x1b[1m",    // bold
+	UrgencyCritical: "\x1b[1;31m", // bold red
+}
+
+// markupTags are the tags of the body markup of the spec.
+var markupTags = regexp.MustCompile(`</?(b|i|u|a|img)(\s[^>]*)?/?>`)
+
+// ConsoleNotifier is a Notifier writing notifications as lines of text, e.g.
+// to stderr when there is no notification server. Actions and signals are
+// not supported.
+type ConsoleNotifier struct {
+	*NullNotifier
+	style ConsoleStyle
+	clock Clock
+
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewConsoleNotifier creates a ConsoleNotifier writing to w in style.
+func NewConsoleNotifier(w io.Writer, style ConsoleStyle) *ConsoleNotifier {
+	return &ConsoleNotifier{NullNotifier: NewNullNotifier(), style: style, clock: SystemClock, w: w}
+}
+
+// SetClock makes c stamp its lines with the time of clock instead of
+// SystemClock. Call it before using c.
+func (c *ConsoleNotifier) SetClock(clock Clock) {
+	c.clock = clock
+}
+
+// SendNotification writes note as one line:
+//
+//	[15:04:05] AppName: Summary - Body
+//
+// Markup is removed from the body and its lines are joined. Control
+// characters are removed from all of it, see StripControl.
+func (c *ConsoleNotifier) SendNotification(note Notification) (uint32, error) {
+	id, _ := c.NullNotifier.SendNotification(note)
+	body := markupTags.ReplaceAllString(note.Body, "")
+	body = StripControl(strings.Join(strings.Fields(body), " "))
+	appName, summary := StripControl(note.AppName), StripControl(note.Summary)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%v] ", c.clock.Now().Format("15:04:05"))
+	if c.style.Color {
+		b.WriteString(urgencyColors[urgency(note)])
+	}
+	if appName != "" {
+		b.WriteString(appName + ": ")
+	}
+	b.WriteString(summary)
+	if c.style.Color {
+		b.WriteString("\x1b[0m")
+	}
+	if body != "" {
+		b.WriteString(" - " + body)
+	}
+	b.WriteString("\n")
+	switch c.style.Escape {
+	case EscapeOSC777:
+		fmt.Fprintf(&b, "\x1b]777;notify;%s;%s\x1b\\", escapeText(summary), escapeText(body))
+	case EscapeOSC9:
+		message := summary
+		if body != "" {
+			message += ": " + body
+		}
+		fmt.Fprintf(&b, "\x1b]9;%s\x1b\\", escapeText(message))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, err := io.WriteString(c.w, b.String()); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// escapeText makes s, already without control characters, safe inside an
+// OSC sequence, where OSC 777 separates fields with ;.
+func escapeText(s string) string {
+	return strings.ReplaceAll(s, ";", " ")
+}
+
+// GetServerInformation returns a server named "console".
+func (c *ConsoleNotifier) GetServerInformation() (ServerInformation, error) {
+	return ServerInformation{Name: "console", Vendor: "esiqveland"}, nil
+}
+
+// GetCapabilities returns "body".
+func (c *ConsoleNotifier) GetCapabilities() ([]string, error) {
+	return []string{"body"}, nil
+}
+
+// WithApp returns a child of c, see NewChild.
+func (c *ConsoleNotifier) WithApp(appName, appIcon string) Notifier {
+	return NewChild(c, appName, appIcon)
+}
+
+// NewOrConsole returns a Notifier for the notification server of the
+// session bus, configured by opts, falling back to a ConsoleNotifier on
+// stderr while the circuit breaker is open (see WithFallback). When there
+// is no session bus, or no server runs and the bus can't start one, it
+// returns the ConsoleNotifier itself.
+func NewOrConsole(opts ...Option) Notifier {
+	console := NewConsoleNotifier(os.Stderr, DetectConsoleStyle(os.Stderr))
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return console
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	bus := conn.Object(dbusDestination, dbusPath)
+	owned, _ := hasOwner(ctx, bus, dbusNotificationsInterface)
+	if !owned {
+		if activatable, _ := isActivatable(ctx, bus, dbusNotificationsInterface); !activatable {
+			conn.Close()
+			return console
+		}
+	}
+	n, err := New(conn, append([]Option{WithFallback(console)}, opts...)...)
+	if err != nil {
+		conn.Close()
+		return console
+	}
+	return n
+}
@@ -0,0 +1,20 @@
+package notify
+
+import "strings"
+
+// StripControl returns s without control characters: C0 controls, ESC
+// among them, DEL and C1 controls. Notifications come from any application
+// on the bus, so their text must not reach a terminal as is, where an
+// escape sequence could move the cursor, set the title or write to the
+// clipboard. Tabs become spaces.
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r < 0x20, r == 0x7f, r >= 0x80 && r <= 0x9f:
+			return -1
+		}
+		return r
+	}, s)
+}
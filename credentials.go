@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Credentials identify the process owning a bus connection. The bus reports
+// them, so unlike the app_name of a notification the application can't
+// choose them.
+type Credentials struct {
+	PID uint32 `json:"pid"`
+	UID uint32 `json:"uid"`
+	// Executable is the base name of the executable of the process. For
+	// processes of other users it is their name, possibly cut to 15
+	// characters.
+	Executable string `json:"executable,omitempty"`
+	// Cgroup is the path of the process in the unified cgroup hierarchy,
+	// e.g. /user.slice/user-1000.slice/user@1000.service/app.slice/app-firefox-1234.scope,
+	// which on systemd desktops names the application unit.
+	Cgroup string `json:"cgroup,omitempty"`
+}
+
+// SenderCredentials asks the bus of conn for the credentials of the
+// connection with the unique name sender, with GetConnectionCredentials.
+// Executable and Cgroup come from /proc, and are left empty off Linux or if
+// the process has already exited.
+func SenderCredentials(conn *dbus.Conn, sender string) (*Credentials, error) {
+	var creds map[string]dbus.Variant
+	err := conn.BusObject().Call("org.freedesktop.DBus.GetConnectionCredentials", 0, sender).Store(&creds)
+	if err != nil {
+		return nil, err
+	}
+	pid, ok := creds["ProcessID"].Value().(uint32)
+	if !ok {
+		return nil, errors.New("bus did not report the process of " + sender)
+	}
+	c := &Credentials{PID: pid}
+	c.UID, _ = creds["UnixUserID"].Value().(uint32)
+
+	proc := filepath.Join("/proc", strconv.FormatUint(uint64(pid), 10))
+	if path, err := os.Readlink(filepath.Join(proc, "exe")); err == nil {
+		c.Executable = filepath.Base(strings.TrimSuffix(path, " (deleted)"))
+	} else if comm, err := os.ReadFile(filepath.Join(proc, "comm")); err == nil {
+		c.Executable = strings.TrimSpace(string(comm))
+	}
+	if cgroup, err := os.ReadFile(filepath.Join(proc, "cgroup")); err == nil {
+		for _, line := range strings.Split(string(cgroup), "\n") {
+			// the unified hierarchy is the one with ID 0 and no controllers.
+			if path, ok := strings.CutPrefix(line, "0::"); ok {
+				c.Cgroup = path
+				break
+			}
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,54 @@
+package notify
+
+import "github.com/godbus/dbus/v5"
+
+// WithDefaults sets values used for every notification sent, so e.g. the
+// AppName and AppIcon of an application are given once:
+//
+//   - AppName, AppIcon, Summary and Body are taken from defaults when the
+//     notification leaves them empty.
+//   - Actions are taken from defaults when the notification has none.
+//   - Hints are merged: hints of the notification win over default hints
+//     with the same key.
+//   - ExpireTimeout is taken from defaults when the notification leaves it
+//     0. A notification can't ask to never expire if defaults set a timeout.
+//   - ReplacesID of defaults is ignored.
+func WithDefaults(defaults Notification) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.defaults = &defaults
+	}
+}
+
+// withDefaults returns note with the empty fields filled in from defaults.
+func withDefaults(note Notification, defaults Notification) Notification {
+	if note.AppName == "" {
+		note.AppName = defaults.AppName
+	}
+	if note.AppIcon == "" {
+		note.AppIcon = defaults.AppIcon
+	}
+	if note.Summary == "" {
+		note.Summary = defaults.Summary
+	}
+	if note.Body == "" {
+		note.Body = defaults.Body
+	}
+	if len(note.Actions) == 0 {
+		note.Actions = defaults.Actions
+	}
+	if note.ExpireTimeout == 0 {
+		note.ExpireTimeout = defaults.ExpireTimeout
+	}
+	if len(defaults.Hints) > 0 {
+		hints := make(map[string]dbus.Variant, len(defaults.Hints)+len(note.Hints))
+		for k, v := range defaults.Hints {
+			hints[k] = v
+		}
+		for k, v := range note.Hints {
+			hints[k] = v
+		}
+		note.Hints = hints
+	}
+	return note
+}
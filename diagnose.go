@@ -0,0 +1,276 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusDestination     = "org.freedesktop.DBus"
+	dbusPath            = "/org/freedesktop/DBus"
+	dunstInterface      = "org.dunstproject.cmd0"
+	propertiesInterface = "org.freedesktop.DBus.Properties"
+)
+
+// Status is the outcome of a check of Diagnose.
+type Status int
+
+const (
+	StatusOK      Status = iota
+	StatusWarning        // notifications may not show
+	StatusFailed         // notifications can't show
+	StatusUnknown        // the check couldn't tell
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusWarning:
+		return "warning"
+	case StatusFailed:
+		return "failed"
+	case StatusUnknown:
+		return "unknown"
+	default:
+		return "other"
+	}
+}
+
+// Check is the outcome of one check of Diagnose, with what was found in
+// Detail.
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+}
+
+// Report is what Diagnose found.
+type Report struct {
+	SessionBus   Check // a session bus can be connected to
+	Server       Check // a notification server owns org.freedesktop.Notifications
+	Activation   Check // the bus can start a notification server on demand
+	DoNotDisturb Check // the server isn't holding notifications back
+	Portal       Check // the notification portal, needed inside a sandbox
+}
+
+// Checks returns the checks of r in the order they were made.
+func (r Report) Checks() []Check {
+	return []Check{r.SessionBus, r.Server, r.Activation, r.DoNotDisturb, r.Portal}
+}
+
+// Problems returns the checks that didn't pass.
+func (r Report) Problems() []Check {
+	var problems []Check
+	for _, c := range r.Checks() {
+		if c.Status == StatusWarning || c.Status == StatusFailed {
+			problems = append(problems, c)
+		}
+	}
+	return problems
+}
+
+// String returns r as one line per check.
+func (r Report) String() string {
+	var b strings.Builder
+	for _, c := range r.Checks() {
+		fmt.Fprintf(&b, "%-8v %v: %v\n", c.Status, c.Name, c.Detail)
+	}
+	return b.String()
+}
+
+// Diagnose checks why notifications might not appear: whether there is a
+// session bus, whether a notification server runs or can be started by the
+// bus, whether the server is in do not disturb mode, and whether the
+// notification portal is there for sandboxed applications. It connects to
+// the session bus on its own and reports what it found; ctx bounds the
+// calls it makes.
+//
+// Do not disturb is detected for dunst, KDE Plasma and GNOME; elsewhere it is
+// reported as unknown.
+func Diagnose(ctx context.Context) Report {
+	r := Report{
+		SessionBus:   Check{Name: "session bus"},
+		Server:       Check{Name: "notification server"},
+		Activation:   Check{Name: "activation"},
+		DoNotDisturb: Check{Name: "do not disturb"},
+		Portal:       Check{Name: "portal"},
+	}
+	conn, err := dbus.ConnectSessionBus(dbus.WithContext(ctx))
+	if err != nil {
+		r.SessionBus.Status = StatusFailed
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			r.SessionBus.Detail = fmt.Sprintf("DBUS_SESSION_BUS_ADDRESS is not set: %v", err)
+		} else {
+			r.SessionBus.Detail = err.Error()
+		}
+		for _, c := range []*Check{&r.Server, &r.Activation, &r.DoNotDisturb, &r.Portal} {
+			c.Status = StatusUnknown
+			c.Detail = "no session bus"
+		}
+		return r
+	}
+	defer conn.Close()
+	r.SessionBus.Detail = "connected"
+
+	bus := conn.Object(dbusDestination, dbusPath)
+	owned, ownErr := hasOwner(ctx, bus, dbusNotificationsInterface)
+	activatable, actErr := isActivatable(ctx, bus, dbusNotificationsInterface)
+
+	switch {
+	case ownErr != nil:
+		r.Server.Status = StatusUnknown
+		r.Server.Detail = ownErr.Error()
+	case owned:
+		r.Server.Detail = "running"
+		info, err := getServerInformation(ctx, conn.Object(dbusNotificationsInterface, dbusObjectPath))
+		if err != nil {
+			r.Server.Status = StatusWarning
+			r.Server.Detail = fmt.Sprintf("running, but GetServerInformation failed: %v", err)
+		} else {
+			r.Server.Detail = fmt.Sprintf("%v %v (%v), spec %v", info.Name, info.Version, info.Vendor, info.SpecVersion)
+		}
+	case activatable:
+		r.Server.Status = StatusWarning
+		r.Server.Detail = "not running, the bus starts one on the first notification"
+	default:
+		r.Server.Status = StatusFailed
+		r.Server.Detail = "no server owns " + dbusNotificationsInterface + " and none can be started"
+	}
+
+	switch {
+	case actErr != nil:
+		r.Activation.Status = StatusUnknown
+		r.Activation.Detail = actErr.Error()
+	case activatable:
+		r.Activation.Detail = "a notification server can be started on demand"
+	case owned:
+		r.Activation.Detail = "no service file, the running server must be started with the session"
+	default:
+		r.Activation.Status = StatusFailed
+		r.Activation.Detail = "no service file for " + dbusNotificationsInterface
+	}
+
+	if owned {
+		r.DoNotDisturb = doNotDisturb(ctx, conn)
+	} else {
+		r.DoNotDisturb.Status = StatusUnknown
+		r.DoNotDisturb.Detail = "no server running"
+	}
+
+	r.Portal = portalCheck(ctx, bus, conn)
+	return r
+}
+
+func hasOwner(ctx context.Context, bus dbus.BusObject, name string) (bool, error) {
+	var owned bool
+	err := bus.CallWithContext(ctx, dbusDestination+".NameHasOwner", 0, name).Store(&owned)
+	return owned, err
+}
+
+func isActivatable(ctx context.Context, bus dbus.BusObject, name string) (bool, error) {
+	var names []string
+	if err := bus.CallWithContext(ctx, dbusDestination+".ListActivatableNames", 0).Store(&names); err != nil {
+		return false, err
+	}
+	for _, n := range names {
+		if n == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// doNotDisturb checks whether the running server is holding notifications
+// back.
+func doNotDisturb(ctx context.Context, conn *dbus.Conn) Check {
+	c := Check{Name: "do not disturb"}
+	on, detail, known := doNotDisturbOn(ctx, conn.Object(dbusNotificationsInterface, dbusObjectPath))
+	if !known {
+		c.Status = StatusUnknown
+		c.Detail = "the server doesn't report it"
+		return c
+	}
+	return dndCheck(c, on, detail)
+}
+
+// doNotDisturbOn asks the server obj, in the ways of the servers known to
+// have the mode, whether its do not disturb mode is on. known is false if
+// the server doesn't tell. The GNOME setting is only read, running
+// gsettings, if the server is gnome-shell.
+func doNotDisturbOn(ctx context.Context, obj dbus.BusObject) (on bool, detail string, known bool) {
+	get := func(iface, prop string) (bool, bool) {
+		var on bool
+		err := obj.CallWithContext(ctx, propertiesInterface+".Get", 0, iface, prop).Store(&on)
+		return on, err == nil
+	}
+	if paused, ok := get(dunstInterface, "paused"); ok {
+		return paused, "dunst is paused", true
+	}
+	if inhibited, ok := get(dbusNotificationsInterface, "Inhibited"); ok {
+		return inhibited, "notifications are inhibited", true
+	}
+	if info, err := getServerInformation(ctx, obj); err != nil || info.Name != "gnome-shell" {
+		return false, "", false
+	}
+	if out, err := exec.CommandContext(ctx, "gsettings", "get", "org.gnome.desktop.notifications", "show-banners").Output(); err == nil {
+		return strings.TrimSpace(string(out)) == "false", "GNOME shows no banners", true
+	}
+	return false, "", false
+}
+
+func dndCheck(c Check, on bool, detail string) Check {
+	if on {
+		c.Status = StatusWarning
+		c.Detail = detail + ", notifications are held back or not shown"
+		return c
+	}
+	c.Detail = "off"
+	return c
+}
+
+// portalCheck checks for the notification portal, which sandboxed
+// applications send through.
+func portalCheck(ctx context.Context, bus dbus.BusObject, conn *dbus.Conn) Check {
+	c := Check{Name: "portal"}
+	sandbox := DetectSandbox()
+	missing := StatusOK
+	if sandbox != SandboxNone {
+		missing = StatusFailed
+	}
+	owned, err := hasOwner(ctx, bus, portalDestination)
+	if err == nil && !owned {
+		owned, err = isActivatable(ctx, bus, portalDestination)
+	}
+	if err != nil {
+		c.Status = StatusUnknown
+		c.Detail = err.Error()
+		return c
+	}
+	if !owned {
+		c.Status = missing
+		c.Detail = "xdg-desktop-portal is not available"
+		if sandbox == SandboxNone {
+			c.Detail += ", only needed inside a sandbox"
+		}
+		return c
+	}
+	var version uint32
+	err = conn.Object(portalDestination, portalObjectPath).
+		CallWithContext(ctx, propertiesInterface+".Get", 0, portalInterface, "version").Store(&version)
+	if err != nil {
+		c.Status = missing
+		c.Detail = fmt.Sprintf("xdg-desktop-portal has no notification portal: %v", err)
+		return c
+	}
+	c.Detail = fmt.Sprintf("notification portal version %v", version)
+	if sandbox != SandboxNone {
+		c.Detail += fmt.Sprintf(", used inside %v", sandbox)
+	}
+	return c
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Equal reports whether n and o would be sent as the same Notify call: all
+// fields are equal, and so are the hints, in value and D-Bus type.
+func (n Notification) Equal(o Notification) bool {
+	return len(n.Diff(o)) == 0
+}
+
+// Diff returns the fields in which n and o differ, e.g.
+//
+//	[]string{"Body", "Hints[value]"}
+//
+// with a hint set in only one of them, or set to a different value or type,
+// as Hints[<key>] in the order of the keys.
+func (n Notification) Diff(o Notification) []string {
+	var diff []string
+	if n.AppName != o.AppName {
+		diff = append(diff, "AppName")
+	}
+	if n.ReplacesID != o.ReplacesID {
+		diff = append(diff, "ReplacesID")
+	}
+	if n.AppIcon != o.AppIcon {
+		diff = append(diff, "AppIcon")
+	}
+	if n.Summary != o.Summary {
+		diff = append(diff, "Summary")
+	}
+	if n.Body != o.Body {
+		diff = append(diff, "Body")
+	}
+	if !equalActions(n.Actions, o.Actions) {
+		diff = append(diff, "Actions")
+	}
+	var hints []string
+	for key, v := range n.Hints {
+		w, ok := o.Hints[key]
+		if !ok || v.Signature() != w.Signature() || !reflect.DeepEqual(v.Value(), w.Value()) {
+			hints = append(hints, key)
+		}
+	}
+	for key := range o.Hints {
+		if _, ok := n.Hints[key]; !ok {
+			hints = append(hints, key)
+		}
+	}
+	sort.Strings(hints)
+	for _, key := range hints {
+		diff = append(diff, "Hints["+key+"]")
+	}
+	if n.ExpireTimeout != o.ExpireTimeout {
+		diff = append(diff, "ExpireTimeout")
+	}
+	return diff
+}
+
+// equalActions compares actions, treating nil and empty alike as both are
+// sent as an empty array.
+func equalActions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
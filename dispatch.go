@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify/internal/configfile"
+)
+
+// Handlers are called by a Dispatcher for the notifications of a tag.
+// Either may be nil.
+type Handlers struct {
+	OnAction func(id uint32, key string)
+	OnClosed func(id uint32, reason Reason)
+}
+
+// outstanding is a notification sent by a Dispatcher and not closed yet.
+type outstanding struct {
+	Tag     string    `json:"tag"`
+	Actions []string  `json:"actions,omitempty"`
+	Sent    time.Time `json:"sent"`
+}
+
+// Dispatcher routes the signals of the notifications it sends to the
+// Handlers of their tag, e.g. "download" or "chat:42". With a state file it
+// survives restarts of the process: the outstanding notifications are kept
+// in the file, so once the restarted process registers its handlers again,
+// actions invoked on notifications still on screen reach them. This matters
+// most for resident notifications, which stay until the user acts.
+//
+//	d, err := notify.NewDispatcher(n, filepath.Join(stateDir, "notifications.json"))
+//	...
+//	d.Handle("download", notify.Handlers{OnAction: openDownload})
+//	go func() {
+//		for signal := range n.ActionInvoked() {
+//			d.HandleAction(signal)
+//		}
+//	}()
+//	d.Send("download", note)
+//
+// Servers that send signals only to the connection that sent the
+// notification can't reach a restarted process.
+type Dispatcher struct {
+	n    Notifier
+	path string
+
+	lock     sync.Mutex
+	handlers map[string]Handlers
+	open     map[uint32]outstanding
+}
+
+// NewDispatcher creates a Dispatcher sending with n, keeping its state in
+// the file at path, read if it exists. An empty path keeps it in memory.
+func NewDispatcher(n Notifier, path string) (*Dispatcher, error) {
+	d := &Dispatcher{
+		n:        n,
+		path:     path,
+		handlers: make(map[string]Handlers),
+		open:     make(map[uint32]outstanding),
+	}
+	if path == "" {
+		return d, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &d.open); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Handle registers h for the notifications of tag, also those sent before
+// the process restarted.
+func (d *Dispatcher) Handle(tag string, h Handlers) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.handlers[tag] = h
+}
+
+// Send sends note with the Notifier and tracks it under tag.
+func (d *Dispatcher) Send(tag string, note Notification) (uint32, error) {
+	id, err := d.n.SendNotification(note)
+	if err != nil {
+		return 0, err
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if note.ReplacesID != 0 && note.ReplacesID != id {
+		delete(d.open, note.ReplacesID)
+	}
+	d.open[id] = outstanding{Tag: tag, Actions: append([]string(nil), note.Actions...), Sent: ClockOf(d.n).Now()}
+	return id, d.save()
+}
+
+// Outstanding returns the tags of the notifications sent and not closed
+// yet, by ID.
+func (d *Dispatcher) Outstanding() map[uint32]string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	tags := make(map[uint32]string, len(d.open))
+	for id, o := range d.open {
+		tags[id] = o.Tag
+	}
+	return tags
+}
+
+// Forget stops tracking the notification with id, e.g. one known to be
+// gone while the process was not running.
+func (d *Dispatcher) Forget(id uint32) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.open, id)
+	return d.save()
+}
+
+// HandleAction calls OnAction of the tag of the notification signal is for,
+// if the action is one it was sent with. Call it from the loop reading
+// ActionInvoked.
+func (d *Dispatcher) HandleAction(signal *ActionInvokedSignal) {
+	d.lock.Lock()
+	o, ok := d.open[signal.Id]
+	h := d.handlers[o.Tag]
+	d.lock.Unlock()
+	if !ok || h.OnAction == nil || !hasActionKey(o.Actions, signal.ActionKey) {
+		return
+	}
+	h.OnAction(signal.Id, signal.ActionKey)
+}
+
+// HandleClosed stops tracking the notification signal is for, and calls
+// OnClosed of its tag. Call it from the loop reading NotificationClosed.
+func (d *Dispatcher) HandleClosed(signal *NotificationClosedSignal) {
+	d.lock.Lock()
+	o, ok := d.open[signal.Id]
+	if ok {
+		delete(d.open, signal.Id)
+		if err := d.save(); err != nil {
+			log.Printf("error saving notification dispatcher state: %v", err)
+		}
+	}
+	h := d.handlers[o.Tag]
+	d.lock.Unlock()
+	if ok && h.OnClosed != nil {
+		h.OnClosed(signal.Id, signal.Reason)
+	}
+}
+
+// hasActionKey reports whether actions, key and label pairs, have key.
+func hasActionKey(actions []string, key string) bool {
+	for i := 0; i < len(actions); i += 2 {
+		if actions[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the outstanding notifications to the state file. d.lock must
+// be held.
+func (d *Dispatcher) save() error {
+	if d.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(d.open)
+	if err != nil {
+		return err
+	}
+	return configfile.Write(d.path, data)
+}
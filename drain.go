@@ -0,0 +1,21 @@
+package notify
+
+// Drain receives and drops the signals of n until n is closed, for programs
+// that don't care about them but must consume them, see New:
+//
+//	go notify.Drain(n)
+func Drain(n Notifier) {
+	closed, actions := n.NotificationClosed(), n.ActionInvoked()
+	for closed != nil || actions != nil {
+		select {
+		case _, ok := <-closed:
+			if !ok {
+				closed = nil
+			}
+		case _, ok := <-actions:
+			if !ok {
+				actions = nil
+			}
+		}
+	}
+}
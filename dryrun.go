@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// WithDryRun makes the Notifier go through all of its usual processing, but
+// never call the notification server. Sent notifications get synthetic,
+// increasing IDs, and CloseNotification emits NotificationClosed with
+// ReasonClosedByCall, as a server would.
+// GetCapabilities and GetServerInformation return empty values.
+//
+// In dry-run mode New accepts a nil conn, so applications can run in CI or
+// with notifications disabled without a session bus.
+func WithDryRun() Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.dryRun = true
+	}
+}
+
+// dryRunID returns the ID the server would have handed out for note.
+func (n *notifier) dryRunID(note Notification) uint32 {
+	if note.ReplacesID != 0 {
+		return note.ReplacesID
+	}
+	return atomic.AddUint32(&n.lastDryRunID, 1)
+}
+
+// emitClosedByCall delivers the NotificationClosed signal a server would emit
+// for a call to CloseNotification, for when there is no server to emit it.
+func (n *notifier) emitClosedByCall(id uint32) {
+	go func() {
+		select {
+		case n.signal <- &dbus.Signal{
+			Path: dbusObjectPath,
+			Name: signalNotificationClosed,
+			Body: []interface{}{id, uint32(ReasonClosedByCall)},
+		}:
+		case <-n.closing:
+		}
+	}()
+}
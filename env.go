@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by WithEnvironment.
+const (
+	EnvDisable        = "NOTIFY_DISABLE"         // "1" or "true": send nothing, as WithDryRun
+	EnvUrgencyMin     = "NOTIFY_URGENCY_MIN"     // "low", "normal" or "critical": drop less urgent notifications
+	EnvDefaultTimeout = "NOTIFY_DEFAULT_TIMEOUT" // e.g. "5s": used for an ExpireTimeout of -1
+)
+
+// WithEnvironment lets the user tune or silence the application's
+// notifications with environment variables, see EnvDisable, EnvUrgencyMin
+// and EnvDefaultTimeout. Invalid values are logged and ignored.
+//
+// Options given after WithEnvironment override what it sets.
+func WithEnvironment() Option {
+	return func(n *notifier) {
+		if v := os.Getenv(EnvDisable); v != "" {
+			disable, err := strconv.ParseBool(v)
+			if err != nil {
+				log.Printf("invalid %v: %v", EnvDisable, err)
+			} else if disable {
+				n.dryRun, n.staged = true, true
+			}
+		}
+		if v := os.Getenv(EnvUrgencyMin); v != "" {
+			u, ok := parseUrgency(v)
+			if !ok {
+				log.Printf("invalid %v: %q", EnvUrgencyMin, v)
+			} else {
+				n.minUrgency, n.staged = u, true
+			}
+		}
+		if v := os.Getenv(EnvDefaultTimeout); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				log.Printf("invalid %v: %q", EnvDefaultTimeout, v)
+			} else {
+				timeout := int32(d / time.Millisecond)
+				n.defaultTimeout = &timeout
+			}
+		}
+	}
+}
+
+func parseUrgency(s string) (Urgency, bool) {
+	switch strings.ToLower(s) {
+	case "low", "0":
+		return UrgencyLow, true
+	case "normal", "1":
+		return UrgencyNormal, true
+	case "critical", "2":
+		return UrgencyCritical, true
+	}
+	return 0, false
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Escalation is what an Alert does when nobody acknowledges it in time.
+// Any combination of Sound, Forward and Func may be set.
+type Escalation struct {
+	After time.Duration // how long to wait for an acknowledgement
+	// Sound is the themed sound to re-send the notification with, see
+	// HintSoundName. If empty, the notification is not re-sent.
+	Sound string
+	// Forward is a Notifier to send the notification to as well, e.g. one
+	// pushing it to a phone.
+	Forward Notifier
+	// Func is called with the notification, from a goroutine of its own.
+	Func func(Notification)
+	// Clock times After, SystemClock if nil.
+	Clock Clock
+}
+
+// Alert is a notification that escalates unless it is acknowledged: an
+// action invoked on it, or the user dismissing it. Expiring doesn't count.
+// Pass the signals to HandleAction and HandleClosed:
+//
+//	a, err := notify.SendAlert(n, note, notify.Escalation{
+//		After:   30 * time.Second,
+//		Sound:   "alarm-clock-elapsed",
+//		Forward: pager,
+//	})
+type Alert struct {
+	n          Notifier
+	note       Notification
+	escalation Escalation
+
+	lock      sync.Mutex
+	id        uint32
+	acked     bool
+	escalated bool
+	alarm     Alarm
+}
+
+// SendAlert sends note with n and escalates it as set by e unless it is
+// acknowledged within e.After.
+func SendAlert(n Notifier, note Notification, e Escalation) (*Alert, error) {
+	if e.Clock == nil {
+		e.Clock = SystemClock
+	}
+	id, err := n.SendNotification(note)
+	if err != nil {
+		return nil, err
+	}
+	a := &Alert{n: n, note: note.Clone(), escalation: e, id: id}
+	a.lock.Lock()
+	a.alarm = e.Clock.AfterFunc(e.After, a.escalate)
+	a.lock.Unlock()
+	return a, nil
+}
+
+// escalate runs the escalation, unless the alert was acknowledged.
+func (a *Alert) escalate() {
+	a.lock.Lock()
+	if a.acked || a.escalated {
+		a.lock.Unlock()
+		return
+	}
+	a.escalated = true
+	id, note, e := a.id, a.note.Clone(), a.escalation
+	a.lock.Unlock()
+
+	if e.Sound != "" {
+		resend := note.Clone()
+		resend.ReplacesID = id
+		resend.SetHint(HintSoundName, e.Sound)
+		resend.SetUrgency(UrgencyCritical)
+		newID, err := a.n.SendNotification(resend)
+		if err != nil {
+			log.Printf("error re-sending alert: %v", err)
+		} else {
+			a.lock.Lock()
+			a.id = newID
+			a.lock.Unlock()
+		}
+	}
+	if e.Forward != nil {
+		if _, err := e.Forward.SendNotification(note); err != nil {
+			log.Printf("error forwarding alert: %v", err)
+		}
+	}
+	if e.Func != nil {
+		go e.Func(note)
+	}
+}
+
+// ID returns the current ID of the notification.
+func (a *Alert) ID() uint32 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.id
+}
+
+// Acknowledged reports whether the alert was acknowledged.
+func (a *Alert) Acknowledged() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.acked
+}
+
+// Escalated reports whether the alert escalated.
+func (a *Alert) Escalated() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.escalated
+}
+
+// Cancel stops the alert from escalating, as if it was acknowledged.
+func (a *Alert) Cancel() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.ack()
+}
+
+// ack marks the alert acknowledged. a.lock must be held.
+func (a *Alert) ack() {
+	a.acked = true
+	if a.alarm != nil {
+		a.alarm.Stop()
+	}
+}
+
+// HandleAction acknowledges the alert if signal is for its notification.
+// Call it from the loop reading ActionInvoked.
+func (a *Alert) HandleAction(signal *ActionInvokedSignal) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if signal.Id == a.id {
+		a.ack()
+	}
+}
+
+// HandleClosed acknowledges the alert if signal is the user dismissing its
+// notification. Call it from the loop reading NotificationClosed.
+func (a *Alert) HandleClosed(signal *NotificationClosedSignal) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if signal.Id == a.id && signal.Reason == ReasonDismissedByUser {
+		a.ack()
+	}
+}
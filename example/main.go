@@ -5,7 +5,7 @@ import (
 	"log"
 
 	"github.com/esiqveland/notify"
-	"github.com/godbus/dbus"
+	"github.com/godbus/dbus/v5"
 )
 
 func main() {
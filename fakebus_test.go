@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// fakeBus is a busConn whose notification server answers every call right
+// away, without encoding anything, so benchmarks measure the notifier
+// rather than godbus.
+type fakeBus struct {
+	server fakeServer
+}
+
+func (b *fakeBus) Object(dest string, path dbus.ObjectPath) dbus.BusObject {
+	return &b.server
+}
+
+func (b *fakeBus) AddMatchSignal(options ...dbus.MatchOption) error    { return nil }
+func (b *fakeBus) RemoveMatchSignal(options ...dbus.MatchOption) error { return nil }
+func (b *fakeBus) Signal(ch chan<- *dbus.Signal)                       {}
+func (b *fakeBus) RemoveSignal(ch chan<- *dbus.Signal)                 {}
+func (b *fakeBus) Close() error                                        { return nil }
+
+// fakeServer is the notification server of a fakeBus. It replies to Notify
+// with ID 1, from a reply made once, so it doesn't allocate itself; calls
+// must not overlap.
+type fakeServer struct {
+	dbus.BusObject // nil, the notifier only calls the methods below
+	reply          dbus.Call
+}
+
+func (s *fakeServer) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	return s.CallWithContext(context.Background(), method, flags, args...)
+}
+
+func (s *fakeServer) CallWithContext(ctx context.Context, method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	if method != callNotify {
+		return &dbus.Call{Err: errors.New("fake server: unexpected call " + method)}
+	}
+	if s.reply.Body == nil {
+		s.reply.Body = []interface{}{uint32(1)}
+	}
+	return &s.reply
+}
+
+// newFakeNotifier returns a notifier from New on a fakeBus, closed when tb
+// ends. The portal is left out, so it doesn't depend on the sandbox the
+// tests run in.
+func newFakeNotifier(tb testing.TB, opts ...Option) *notifier {
+	n, err := newNotifier(&fakeBus{}, append([]Option{WithoutPortal()}, opts...)...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { n.Close() })
+	return n
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// Flusher is implemented by Notifiers that hold notifications back or
+// deliver them later, see Flush.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Flush delivers what n still holds: for Notifiers from New, notifications
+// held back by quiet hours and pending storm summary updates are sent right
+// away, and sends in progress in other goroutines are waited for. It returns
+// once that is done, or with the error of ctx when ctx is done first. Call
+// it from shutdown hooks, so notifications sent right before exit are not
+// lost:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+//	defer cancel()
+//	notify.Flush(ctx, n)
+//
+// Notifiers that are not Flushers have nothing to flush.
+func Flush(ctx context.Context, n Notifier) error {
+	if c, ok := n.(*child); ok {
+		n = c.Notifier
+	}
+	if f, ok := n.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush implements Flusher, see the Flush function.
+func (n *notifier) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n.flushStorms()
+		n.stopQuiet(true)
+		// wait for sends in progress.
+		n.sends.Lock()
+		n.sends.Unlock()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flushStorms sends the pending summary updates of storms now.
+func (n *notifier) flushStorms() {
+	st := n.storms
+	if st == nil {
+		return
+	}
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	for _, s := range st.keys {
+		// a timer that already fired is waiting for the lock to update.
+		if s.update == nil || !s.update.Stop() {
+			continue
+		}
+		s.update = nil
+		id, err := n.deliver(st.summary(s))
+		if err != nil {
+			log.Printf("error updating summary of notification storm: %v", err)
+			continue
+		}
+		s.id = id
+	}
+}
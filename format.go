@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Helpers for the text of notification bodies, so apps don't each have to
+// get "1 new messages" right.
+
+// groupSeparators are the digit group separators of languages that don't
+// use a comma.
+var groupSeparators = map[string]string{
+	"de": ".", "da": ".", "es": ".", "id": ".", "it": ".", "nl": ".", "pt": ".", "tr": ".",
+	"cs": "\u00a0", "fi": "\u00a0", "fr": "\u202f", "nb": "\u00a0", "pl": "\u00a0",
+	"ru": "\u00a0", "sk": "\u00a0", "sv": "\u00a0", "uk": "\u00a0",
+	"de_CH": "\u2019",
+}
+
+// Count formats n followed by singular or plural, e.g. "1 message" or
+// "1,024 messages", with the number formatted by FormatNumber.
+func Count(n int, singular, plural string) string {
+	word := plural
+	if n == 1 || n == -1 {
+		word = singular
+	}
+	return FormatNumber(int64(n)) + " " + word
+}
+
+// FormatNumber formats n with digit grouping for the locale of the process,
+// taken from LC_ALL, LC_NUMERIC or LANG.
+func FormatNumber(n int64) string {
+	return FormatNumberLocale(n, numericLocale())
+}
+
+// FormatNumberLocale formats n with the digit grouping of locale, given as
+// e.g. "de_DE.UTF-8" or "fr". Languages grouping with a space use a
+// non-breaking one, and leave four digit numbers ungrouped as is customary.
+// Unknown locales group with a comma; "C" and "POSIX" don't group.
+func FormatNumberLocale(n int64, locale string) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	sep := groupSeparator(locale)
+	if sep == "" || len(digits) <= 3 || len(digits) == 4 && strings.TrimSpace(sep) == "" {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		b.WriteString(sep)
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+func groupSeparator(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	switch locale {
+	case "", "C", "POSIX":
+		return ""
+	}
+	if sep, ok := groupSeparators[locale]; ok {
+		return sep
+	}
+	lang := locale
+	if i := strings.IndexAny(lang, "_-"); i >= 0 {
+		lang = lang[:i]
+	}
+	if sep, ok := groupSeparators[lang]; ok {
+		return sep
+	}
+	return ","
+}
+
+func numericLocale() string {
+	for _, key := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
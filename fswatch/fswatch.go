@@ -0,0 +1,269 @@
+// Package fswatch shows notifications for changes in watched directories,
+// e.g. downloads completing in ~/Downloads:
+//
+//	w, err := fswatch.New(notifier, []fswatch.Rule{{
+//		Path:    "~/Downloads",
+//		Summary: "Download complete",
+//		Body:    "{{.Name}}",
+//	}})
+//	...
+//	for action := range notifier.ActionInvoked() {
+//		w.HandleAction(action)
+//	}
+//
+// Each notification has an "Open folder" default action, which opens the
+// directory with xdg-open once passed to HandleAction.
+package fswatch
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/esiqveland/notify"
+	"github.com/fsnotify/fsnotify"
+)
+
+// actionOpenFolder is the key of the action opening the directory.
+const actionOpenFolder = "default"
+
+// defaultIgnore are the names of files still being written by browsers and
+// download tools, or hidden; they are renamed or removed when done.
+var defaultIgnore = []string{"*.part", "*.crdownload", "*.download", "*.tmp", ".*"}
+
+// Op is a kind of change, see fsnotify.Op.
+type Op = fsnotify.Op
+
+// Rule maps changes in a directory to notifications.
+type Rule struct {
+	Path    string   // directory to watch, a leading ~ is the home directory
+	Pattern string   // only files whose name matches, see filepath.Match. Optional.
+	Ops     Op       // changes to notify about, fsnotify.Create if 0
+	Ignore  []string // names to skip, defaults to partial downloads and hidden files
+
+	// Summary and Body are templates, executed with an Event.
+	Summary string
+	Body    string
+	AppName string
+	AppIcon string // defaults to "folder-download"
+}
+
+// Event is what the templates of a Rule are executed with.
+type Event struct {
+	Path string // full path of the file
+	Name string // its base name
+	Dir  string // the watched directory
+	Op   string // e.g. "CREATE"
+}
+
+// Watcher watches the directories of its rules.
+type Watcher struct {
+	n       notify.Notifier
+	watcher *fsnotify.Watcher
+	rules   []*rule
+	done    chan struct{}
+
+	lock    sync.Mutex
+	folders map[uint32]string // directory to open by notification ID
+}
+
+type rule struct {
+	Rule
+	summary *template.Template
+	body    *template.Template
+}
+
+// New starts watching the directories of rules, sending notifications
+// through n.
+//
+// Caller is responsible to call Close() to stop watching.
+func New(n notify.Notifier, rules []Rule) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		n:       n,
+		watcher: fw,
+		done:    make(chan struct{}),
+		folders: make(map[uint32]string),
+	}
+	for i, r := range rules {
+		parsed, err := parseRule(r)
+		if err == nil {
+			err = fw.Add(parsed.Path)
+		}
+		if err != nil {
+			fw.Close()
+			return nil, fmt.Errorf("fswatch: rule %d: %w", i, err)
+		}
+		w.rules = append(w.rules, parsed)
+	}
+	go w.run()
+	return w, nil
+}
+
+func parseRule(r Rule) (*rule, error) {
+	path, err := expandHome(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	r.Path = filepath.Clean(path)
+	if r.Ops == 0 {
+		r.Ops = fsnotify.Create
+	}
+	if r.Ignore == nil {
+		r.Ignore = defaultIgnore
+	}
+	if r.AppIcon == "" {
+		r.AppIcon = "folder-download"
+	}
+	if _, err := filepath.Match(r.Pattern, ""); err != nil {
+		return nil, err
+	}
+	summary, err := template.New("summary").Parse(r.Summary)
+	if err != nil {
+		return nil, err
+	}
+	body, err := template.New("body").Parse(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &rule{Rule: r, summary: summary, body: body}, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+func (r *rule) matches(event fsnotify.Event) bool {
+	if filepath.Dir(event.Name) != r.Path || event.Op&r.Ops == 0 {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	for _, pattern := range r.Ignore {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if r.Pattern == "" {
+		return true
+	}
+	ok, _ := filepath.Match(r.Pattern, name)
+	return ok
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			for _, r := range w.rules {
+				if r.matches(event) {
+					if err := w.notify(r, event); err != nil {
+						log.Printf("fswatch: error notifying about %v: %v", event.Name, err)
+					}
+				}
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("fswatch: %v", err)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *Watcher) notify(r *rule, event fsnotify.Event) error {
+	data := Event{
+		Path: event.Name,
+		Name: filepath.Base(event.Name),
+		Dir:  r.Path,
+		Op:   event.Op.String(),
+	}
+	summary, err := render(r.summary, data)
+	if err != nil {
+		return err
+	}
+	body, err := render(r.body, data)
+	if err != nil {
+		return err
+	}
+	id, err := w.n.SendNotification(notify.Notification{
+		AppName:       r.AppName,
+		AppIcon:       r.AppIcon,
+		Summary:       summary,
+		Body:          body,
+		Actions:       []string{actionOpenFolder, "Open folder"},
+		ExpireTimeout: -1,
+	})
+	if err != nil {
+		return err
+	}
+	w.lock.Lock()
+	w.folders[id] = r.Path
+	w.lock.Unlock()
+	return nil
+}
+
+func render(t *template.Template, data Event) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// HandleAction opens the folder of the notification signal is for, if it is
+// one of the Watcher's and the user clicked it. Call it from the loop
+// reading ActionInvoked.
+func (w *Watcher) HandleAction(signal *notify.ActionInvokedSignal) {
+	w.lock.Lock()
+	dir, ok := w.folders[signal.Id]
+	delete(w.folders, signal.Id)
+	w.lock.Unlock()
+	if !ok || signal.ActionKey != actionOpenFolder {
+		return
+	}
+	cmd := exec.Command("xdg-open", dir)
+	if signal.ActivationToken != "" {
+		cmd.Env = append(os.Environ(), "XDG_ACTIVATION_TOKEN="+signal.ActivationToken)
+	}
+	if err := cmd.Start(); err != nil {
+		log.Printf("fswatch: error opening %v: %v", dir, err)
+		return
+	}
+	go cmd.Wait()
+}
+
+// HandleClosed forgets the notification signal is for. Call it from the
+// loop reading NotificationClosed, so the Watcher doesn't keep the folders
+// of all notifications it ever sent.
+func (w *Watcher) HandleClosed(signal *notify.NotificationClosedSignal) {
+	w.lock.Lock()
+	delete(w.folders, signal.Id)
+	w.lock.Unlock()
+}
+
+// Close stops watching. The Notifier is left open.
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
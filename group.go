@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Group collapses related notifications, e.g. the messages of one chat, into
+// one on screen.
+//
+// On servers advertising the x-dunst-stack-tag capability each notification
+// is sent with the group's stack tag and the server stacks them. Elsewhere
+// the Group keeps a single rolled-up notification that it replaces with each
+// new one: its summary carries the title and the count, its body the latest
+// notification.
+type Group struct {
+	n     Notifier
+	tag   string
+	title string
+	stack bool // the server stacks by tag itself
+
+	lock  sync.Mutex
+	id    uint32
+	count int
+}
+
+// NewGroup creates a Group sending through n, with title as the summary of
+// the rolled-up notification. The capabilities of the server are queried
+// once, here.
+func NewGroup(n Notifier, tag, title string) *Group {
+	g := &Group{n: n, tag: tag, title: title}
+	if caps, err := n.GetCapabilities(); err == nil {
+		for _, c := range caps {
+			if c == HintStackTag {
+				g.stack = true
+			}
+		}
+	}
+	return g
+}
+
+// Send adds note to the group. ReplacesID of note is ignored.
+func (g *Group) Send(note Notification) (uint32, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	note = withDefaultHint(note, HintStackTag, dbus.MakeVariant(g.tag))
+	count := g.count + 1
+	if !g.stack {
+		note.ReplacesID = g.id
+		if count > 1 {
+			note = g.rollUp(note, count)
+		}
+	}
+	id, err := g.n.SendNotification(note)
+	if err != nil {
+		return 0, err
+	}
+	g.id, g.count = id, count
+	return id, nil
+}
+
+// rollUp turns latest into the rolled-up notification for count.
+func (g *Group) rollUp(latest Notification, count int) Notification {
+	body := latest.Summary
+	if latest.Body != "" {
+		body += "\n" + latest.Body
+	}
+	latest.Summary = fmt.Sprintf("%v (%d)", g.title, count)
+	latest.Body = strings.TrimSpace(body)
+	return latest
+}
+
+// Count returns the number of notifications sent since the group was last
+// empty.
+func (g *Group) Count() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.count
+}
+
+// HandleClosed empties the group if signal is for its notification, so the
+// count starts over after the user dismissed it. Call it from the loop
+// reading NotificationClosed.
+func (g *Group) HandleClosed(signal *NotificationClosedSignal) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if signal.Id == g.id {
+		g.id, g.count = 0, 0
+	}
+}
+
+// Close closes the group's notification and empties the group.
+func (g *Group) Close() error {
+	g.lock.Lock()
+	id := g.id
+	g.id, g.count = 0, 0
+	g.lock.Unlock()
+	if id == 0 {
+		return nil
+	}
+	_, err := g.n.CloseNotification(id)
+	return err
+}
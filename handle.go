@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrDismissed is returned by updates of a Handle whose notification has
+// been closed.
+var ErrDismissed = errors.New("notification dismissed")
+
+// Handle is a notification that is updated in place, e.g. the progress of a
+// download, from any number of goroutines.
+//
+// Update and Close run one at a time, in the order they are called, so an
+// Update racing a Close either shows before the Close or fails with
+// ErrDismissed; it never brings the notification back. Pass the
+// NotificationClosed signals to HandleClosed so updates also stop once the
+// user dismissed it, and the ActionInvoked signals to HandleAction to learn
+// the Outcome.
+type Handle struct {
+	n Notifier
+
+	lock    sync.Mutex
+	id      uint32
+	note    Notification // as last shown
+	closed  error        // why updates fail, nil while shown
+	queue   []func()
+	running bool // a goroutine is running the queue
+	outcome Outcome
+	action  string        // the key invoked, for OutcomeActionInvoked
+	decided chan struct{} // closed once outcome is known
+
+	skipUnchanged   bool          // see SkipUnchanged
+	responseTimeout time.Duration // see ResponseTimeout
+	timeout         Alarm
+}
+
+// HandleOption configures a Handle, see Show.
+type HandleOption func(*Handle)
+
+// SkipUnchanged makes Update skip the D-Bus call when the notification would
+// be sent as shown already, see Notification.Equal, e.g. for status apps
+// that update on every poll of a value that rarely changes.
+func SkipUnchanged() HandleOption {
+	return func(h *Handle) {
+		h.skipUnchanged = true
+	}
+}
+
+// Show sends note with n and returns the Handle to update it with,
+// configured by opts.
+func Show(n Notifier, note Notification, opts ...HandleOption) (*Handle, error) {
+	id, err := n.SendNotification(note)
+	if err != nil {
+		return nil, err
+	}
+	h := &Handle{n: n, id: id, note: note.Clone(), decided: make(chan struct{})}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.startTimeout()
+	return h, nil
+}
+
+// ID returns the current ID of the notification.
+func (h *Handle) ID() uint32 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.id
+}
+
+// Update replaces the notification with note. ReplacesID of note is
+// ignored.
+func (h *Handle) Update(note Notification) error {
+	return h.do(func() error {
+		h.lock.Lock()
+		id, closed, shown := h.id, h.closed, h.note
+		h.lock.Unlock()
+		if closed != nil {
+			return closed
+		}
+		note.ReplacesID = id
+		shown.ReplacesID = id
+		if h.skipUnchanged && note.Equal(shown) {
+			return nil
+		}
+		newID, err := h.n.SendNotification(note)
+		if err != nil {
+			return err
+		}
+		h.lock.Lock()
+		h.id, h.note = newID, note.Clone()
+		h.lock.Unlock()
+		return nil
+	})
+}
+
+// Close closes the notification. Updates called after it fail with
+// ErrDismissed.
+func (h *Handle) Close() error {
+	return h.do(func() error {
+		h.lock.Lock()
+		id, closed := h.id, h.closed
+		if closed == nil {
+			h.closed = ErrDismissed
+		}
+		h.lock.Unlock()
+		if closed != nil {
+			return nil
+		}
+		if _, err := h.n.CloseNotification(id); err != nil {
+			return err
+		}
+		h.lock.Lock()
+		h.decide(OutcomeClosedByCall)
+		h.lock.Unlock()
+		return nil
+	})
+}
+
+// HandleClosed marks the handle dismissed if signal is for its
+// notification, failing the updates still queued. Call it from the loop
+// reading NotificationClosed. Updates fail with ErrServerRestarted instead
+// if the notification was lost with its server, see WithRestartDetection.
+func (h *Handle) HandleClosed(signal *NotificationClosedSignal) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if signal.Id != h.id {
+		return
+	}
+	h.decide(signal.Reason.Outcome())
+	if h.closed != nil {
+		return
+	}
+	h.closed = ErrDismissed
+	if signal.Reason == ReasonServerRestarted {
+		h.closed = ErrServerRestarted
+	}
+}
+
+// do queues op behind the operations called before it and waits for its
+// result.
+func (h *Handle) do(op func() error) error {
+	result := make(chan error, 1)
+	h.lock.Lock()
+	h.queue = append(h.queue, func() { result <- op() })
+	if !h.running {
+		h.running = true
+		go h.run()
+	}
+	h.lock.Unlock()
+	return <-result
+}
+
+// run runs the queued operations until there are none left.
+func (h *Handle) run() {
+	for {
+		h.lock.Lock()
+		if len(h.queue) == 0 {
+			h.running = false
+			h.lock.Unlock()
+			return
+		}
+		op := h.queue[0]
+		h.queue = h.queue[1:]
+		h.lock.Unlock()
+		op()
+	}
+}
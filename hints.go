@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Standard hints, see the Hints section of the spec.
+const (
+	HintUrgency      = "urgency"       // urgency level, BYTE. See Urgency.
+	HintCategory     = "category"      // type of notification, e.g. "email.arrived", STRING.
+	HintSenderPID    = "sender-pid"    // process ID of the sender, INT64. Since spec 1.3.
+	HintDesktopEntry = "desktop-entry" // desktop entry name of the sender, without .desktop, STRING.
+	HintResident     = "resident"      // keep the notification after an action is invoked, BOOLEAN.
+	HintTransient    = "transient"     // bypass the server's persistence, BOOLEAN.
+	HintSoundName    = "sound-name"    // themed sound to play, e.g. "message-new-instant", STRING.
+)
+
+// Urgency is the value of the urgency hint.
+type Urgency byte
+
+const (
+	UrgencyLow      Urgency = 0
+	UrgencyNormal   Urgency = 1
+	UrgencyCritical Urgency = 2
+)
+
+// Window association hints.
+// These are not part of the spec; servers that understand them can use them
+// to position the notification next to, or group it with, the sending
+// application window. Servers that don't simply ignore them.
+const (
+	HintWindowID      = "x-window-id"      // X11 window ID, UINT32
+	HintWaylandHandle = "x-wayland-handle" // exported xdg-foreign surface handle, STRING
+	HintParentWindow  = "x-parent-window"  // portal style identifier: "x11:<hex xid>" or "wayland:<handle>", STRING
+)
+
+// HintStackTag tags related notifications, STRING. Not part of the spec,
+// dunst and others replace a shown notification with a new one carrying the
+// same tag. This package also uses it to identify notifications by tag,
+// e.g. for muting.
+const HintStackTag = "x-dunst-stack-tag"
+
+// HintValue is the progress of a task in percent, INT32. Not part of the
+// spec, most servers show it as a progress bar.
+const HintValue = "value"
+
+// HintMarshaler is implemented by types that encode themselves as a hint
+// value, e.g. a color or an image type, analogous to json.Marshaler.
+// SetHint, SetHints and MakeHints use the variant returned by MarshalHint
+// instead of encoding the value itself.
+type HintMarshaler interface {
+	MarshalHint() (dbus.Variant, error)
+}
+
+// SetHint sets hint key to value, allocating the Hints map if needed.
+// It panics if value can't be sent over D-Bus. If value is a HintMarshaler
+// that fails, the hint is left out and sending the notification fails with
+// the error; use SetHints to get it right away instead.
+func (n *Notification) SetHint(key string, value interface{}) {
+	if n.Hints == nil {
+		n.Hints = map[string]dbus.Variant{}
+	}
+	if m, ok := value.(HintMarshaler); ok {
+		v, err := m.MarshalHint()
+		if err != nil {
+			if n.hintErr == nil {
+				n.hintErr = fmt.Errorf("notify: hint %v: %w", key, err)
+			}
+			return
+		}
+		n.Hints[key] = v
+		return
+	}
+	n.Hints[key] = dbus.MakeVariant(value)
+}
+
+// SetUrgency sets the urgency hint.
+func (n *Notification) SetUrgency(u Urgency) {
+	n.SetHint(HintUrgency, byte(u))
+}
+
+// urgency returns the urgency of note. Notifications without a (valid)
+// urgency hint are treated as UrgencyNormal, as the spec suggests.
+func urgency(note Notification) Urgency {
+	v, ok := note.Hints[HintUrgency]
+	if !ok {
+		return UrgencyNormal
+	}
+	switch u := v.Value().(type) {
+	case byte:
+		return Urgency(u)
+	case Urgency:
+		return u
+	default:
+		return UrgencyNormal
+	}
+}
+
+// hintString returns the string value of hint key of note, or "".
+func hintString(note Notification, key string) string {
+	v, ok := note.Hints[key]
+	if !ok {
+		return ""
+	}
+	s, _ := v.Value().(string)
+	return s
+}
+
+// SetProgress sets the value hint to percent, clamped to 0..100.
+func (n *Notification) SetProgress(percent int) {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	n.SetHint(HintValue, int32(percent))
+}
+
+// SetX11Window associates the notification with the X11 window xid.
+func (n *Notification) SetX11Window(xid uint32) {
+	n.SetHint(HintWindowID, xid)
+	n.SetHint(HintParentWindow, "x11:"+strconv.FormatUint(uint64(xid), 16))
+}
+
+// SetWaylandSurface associates the notification with a Wayland surface,
+// given as a handle exported with the xdg-foreign protocol.
+func (n *Notification) SetWaylandSurface(handle string) {
+	n.SetHint(HintWaylandHandle, handle)
+	n.SetHint(HintParentWindow, "wayland:"+handle)
+}
+
+// withDefaultHint returns note with hint key set to value, unless the caller
+// already set it. The caller's Hints map is never modified.
+func withDefaultHint(note Notification, key string, value dbus.Variant) Notification {
+	if _, ok := note.Hints[key]; ok {
+		return note
+	}
+	hints := make(map[string]dbus.Variant, len(note.Hints)+1)
+	for k, v := range note.Hints {
+		hints[k] = v
+	}
+	hints[key] = value
+	note.Hints = hints
+	return note
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type failingHint struct{ err error }
+
+func (h failingHint) MarshalHint() (dbus.Variant, error) {
+	return dbus.Variant{}, h.err
+}
+
+func TestSetHintMarshalerError(t *testing.T) {
+	errColor := errors.New("no such color")
+	var note Notification
+	note.SetHint("x-color", failingHint{errColor})
+	if _, ok := note.Hints["x-color"]; ok {
+		t.Error("failed hint was set")
+	}
+	n := newFakeNotifier(t)
+	if _, err := n.SendNotification(note.Clone()); !errors.Is(err, errColor) {
+		t.Errorf("SendNotification() error = %v, want %v", err, errColor)
+	}
+}
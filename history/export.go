@@ -0,0 +1,150 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// csvHeader are the columns written by WriteCSV.
+var csvHeader = []string{"time", "id", "app", "category", "urgency", "summary", "body", "outcome", "ended", "action"}
+
+// WriteCSV writes entries as CSV, a header line first. Times are RFC 3339,
+// and empty for an outcome not known yet.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, e := range entries {
+		note := e.Notification
+		category, _ := note.Hints[notify.HintCategory].Value().(string)
+		ended := ""
+		if !e.Ended.IsZero() {
+			ended = e.Ended.Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			e.Time.Format(time.RFC3339),
+			strconv.FormatUint(uint64(e.ID), 10),
+			note.AppName,
+			category,
+			urgencyName(note),
+			note.Summary,
+			note.Body,
+			e.Outcome.String(),
+			ended,
+			e.Action,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes entries as an indented JSON array.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return enc.Encode(entries)
+}
+
+// WriteReport writes entries as a report for people, a section per day in
+// loc with the number of notifications per application and outcome, then
+// the notifications:
+//
+//	2024-05-01: 3 notifications
+//	  mail 2, chat 1
+//	  Dismissed 2, ActionInvoked 1
+//
+//	  09:12  mail: New message - from Ann           Dismissed
+//	  ...
+func WriteReport(w io.Writer, entries []Entry, loc *time.Location) error {
+	entries = append([]Entry{}, entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	var b strings.Builder
+	for len(entries) > 0 {
+		day := entries[0].Time.In(loc).Format("2006-01-02")
+		n := 1
+		for n < len(entries) && entries[n].Time.In(loc).Format("2006-01-02") == day {
+			n++
+		}
+		reportDay(&b, day, entries[:n], loc)
+		entries = entries[n:]
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func reportDay(b *strings.Builder, day string, entries []Entry, loc *time.Location) {
+	apps := map[string]int{}
+	outcomes := map[string]int{}
+	for _, e := range entries {
+		app := e.Notification.AppName
+		if app == "" {
+			app = "(unnamed)"
+		}
+		apps[app]++
+		outcomes[e.Outcome.String()]++
+	}
+	fmt.Fprintf(b, "%v: %v\n", day, notify.Count(len(entries), "notification", "notifications"))
+	fmt.Fprintf(b, "  %v\n", counts(apps))
+	fmt.Fprintf(b, "  %v\n\n", counts(outcomes))
+	for _, e := range entries {
+		note := e.Notification
+		line := note.Summary
+		if note.AppName != "" {
+			line = note.AppName + ": " + line
+		}
+		if body := strings.Join(strings.Fields(note.Body), " "); body != "" {
+			line += " - " + body
+		}
+		if len([]rune(line)) > 60 {
+			line = string([]rune(line)[:59]) + "…"
+		}
+		fmt.Fprintf(b, "  %v  %-60v  %v\n", e.Time.In(loc).Format("15:04"), line, e.Outcome)
+	}
+	b.WriteString("\n")
+}
+
+// counts formats counts by name, largest first.
+func counts(m map[string]int) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if m[names[i]] != m[names[j]] {
+			return m[names[i]] > m[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%v %v", name, m[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func urgencyName(note notify.Notification) string {
+	u, ok := note.Hints[notify.HintUrgency].Value().(byte)
+	if !ok {
+		return ""
+	}
+	switch notify.Urgency(u) {
+	case notify.UrgencyLow:
+		return "low"
+	case notify.UrgencyNormal:
+		return "normal"
+	case notify.UrgencyCritical:
+		return "critical"
+	}
+	return strconv.Itoa(int(u))
+}
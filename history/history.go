@@ -0,0 +1,298 @@
+// Package history keeps a persistent history of notifications, e.g. those
+// observed by package monitor, in a file of JSON lines:
+//
+//	s, err := history.Open(history.DefaultPath())
+//	...
+//	defer s.Close()
+//	s.Add(history.Entry{Time: time.Now(), ID: id, Notification: note})
+//	...
+//	s.MarkClosed(id, notify.ReasonDismissedByUser, time.Now())
+//
+// The file is only appended to, so a crash loses at most the line being
+// written, except when a Retention prunes it. A Store is safe for
+// concurrent use. Only one process may have a history open with Open: a
+// prune replaces the file, and another writer would go on appending to the
+// old one. Others read it with OpenReadOnly.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// Entry is a notification in the history.
+type Entry struct {
+	Seq    uint64    `json:"seq"` // assigned by the Store, counting up from 1
+	Time   time.Time `json:"time"`
+	ID     uint32    `json:"id"`               // handed out by the server
+	Sender string    `json:"sender,omitempty"` // unique bus name of the application
+	// Credentials are those of the process behind Sender, if known. Unlike
+	// the AppName of the notification the application can't fake them.
+	Credentials *notify.Credentials `json:"credentials,omitempty"`
+	// Notification is the notification as last shown: the replacements of
+	// a notification update its entry.
+	Notification notify.Notification `json:"notification"`
+
+	Outcome notify.Outcome `json:"outcome"`
+	Ended   time.Time      `json:"ended"`            // when the outcome became known
+	Action  string         `json:"action,omitempty"` // the key invoked, for OutcomeActionInvoked
+}
+
+// record is a line of the file.
+type record struct {
+	Event string    `json:"event"` // "notify", "close" or "action"
+	Seq   uint64    `json:"seq"`
+	Time  time.Time `json:"time"`
+	// For "notify", the entry.
+	Entry *Entry `json:"entry,omitempty"`
+	// For "close" and "action".
+	Reason notify.Reason `json:"reason,omitempty"`
+	Action string        `json:"action,omitempty"`
+}
+
+// DefaultPath returns the default location of the history,
+// notify/history.jsonl in the XDG data directory.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "notify", "history.jsonl")
+}
+
+// Store is a history of notifications.
+type Store struct {
+	path string
+
+	lock    sync.Mutex
+	f       *os.File
+	enc     *json.Encoder
+	entries []Entry
+	bySeq   map[uint64]int    // index in entries
+	open    map[uint32]uint64 // Seq of the entry shown with an ID
+	lastSeq uint64
+
+	retention *Retention // see SetRetention
+	pruned    time.Time  // when prune last ran
+	readOnly  bool       // see OpenReadOnly
+	corrupt   int        // lines skipped by load, see Corrupt
+}
+
+// ErrReadOnly is returned for changes to a Store from OpenReadOnly.
+var ErrReadOnly = errors.New("history: store opened read-only")
+
+// Open reads the history at path, which is created if needed with its
+// directory.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path, f: f, bySeq: make(map[uint64]int), open: make(map[uint32]uint64)}
+	if err := s.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.enc = json.NewEncoder(f)
+	s.enc.SetEscapeHTML(false)
+	return s, nil
+}
+
+// OpenReadOnly reads the history at path, for searching it while another
+// process records to it. A missing file is an empty history. The Store
+// doesn't see what is added to the file afterwards, returns ErrReadOnly
+// for changes, and prunes by its retention in memory only.
+func OpenReadOnly(path string) (*Store, error) {
+	s := &Store{path: path, bySeq: make(map[uint64]int), open: make(map[uint32]uint64), readOnly: true}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s.f = f
+	err = s.load()
+	s.f = nil
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load folds the records of the file into the entries. Lines that aren't
+// records are skipped and counted in s.corrupt, except a last one, which a
+// crash or a write in progress cut short.
+func (s *Store) load() error {
+	scanner := bufio.NewScanner(s.f)
+	scanner.Buffer(nil, 16<<20) // image-data hints make long lines
+	// whether the line before was not a record
+	cut := false
+	for scanner.Scan() {
+		if cut {
+			s.corrupt++
+		}
+		var r record
+		cut = json.Unmarshal(scanner.Bytes(), &r) != nil
+		if !cut {
+			s.apply(r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if cut && !s.readOnly {
+		// end the cut line so records are appended on lines of their own.
+		_, err := s.f.Write([]byte("\n"))
+		return err
+	}
+	return nil
+}
+
+// Corrupt returns how many lines of the file, not counting the last, were
+// skipped when it was read for not being records.
+func (s *Store) Corrupt() int {
+	return s.corrupt
+}
+
+// apply applies r to the entries. s.lock must be held, except from load.
+func (s *Store) apply(r record) {
+	if r.Seq > s.lastSeq {
+		s.lastSeq = r.Seq
+	}
+	switch r.Event {
+	case "notify":
+		if r.Entry == nil {
+			return
+		}
+		e := *r.Entry
+		if i, ok := s.bySeq[r.Seq]; ok {
+			s.entries[i] = e
+		} else {
+			s.bySeq[r.Seq] = len(s.entries)
+			s.entries = append(s.entries, e)
+		}
+		if e.Outcome == notify.OutcomePending {
+			s.open[e.ID] = e.Seq
+		}
+	case "close", "action":
+		i, ok := s.bySeq[r.Seq]
+		if !ok {
+			return
+		}
+		e := &s.entries[i]
+		delete(s.open, e.ID)
+		if e.Outcome != notify.OutcomePending {
+			// an action comes with a close, the action counts.
+			return
+		}
+		e.Ended = r.Time
+		if r.Event == "action" {
+			e.Outcome, e.Action = notify.OutcomeActionInvoked, r.Action
+		} else {
+			e.Outcome = r.Reason.Outcome()
+		}
+	}
+}
+
+// write appends r to the file and applies it. s.lock must be held.
+func (s *Store) write(r record) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if s.enc == nil {
+		return errors.New("history: store closed")
+	}
+	if err := s.enc.Encode(r); err != nil {
+		return err
+	}
+	s.apply(r)
+	return nil
+}
+
+// Add adds e to the history and returns it with its Seq. A notification
+// replacing one still shown, see Notification.ReplacesID, updates its
+// entry instead, which keeps its Time. A notification the retention
+// excludes, see SetRetention, is not added and returned with a Seq of 0.
+func (s *Store) Add(e Entry) (Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.retention != nil && s.retention.excludes(e) {
+		return e, nil
+	}
+	e.Outcome, e.Ended, e.Action = notify.OutcomePending, time.Time{}, ""
+	if seq, ok := s.open[e.ID]; ok && e.Notification.ReplacesID == e.ID {
+		e.Seq, e.Time = seq, s.entries[s.bySeq[seq]].Time
+	} else {
+		if ok {
+			// the server handed out the ID again, it restarted.
+			if err := s.write(record{Event: "close", Seq: seq, Time: e.Time, Reason: notify.ReasonServerRestarted}); err != nil {
+				return Entry{}, err
+			}
+		}
+		e.Seq = s.lastSeq + 1
+	}
+	if err := s.write(record{Event: "notify", Seq: e.Seq, Time: e.Time, Entry: &e}); err != nil {
+		return Entry{}, err
+	}
+	if now := time.Now(); s.pruneDue(now) {
+		return e, s.prune(now)
+	}
+	return e, nil
+}
+
+// MarkClosed records that the notification shown with id was closed.
+func (s *Store) MarkClosed(id uint32, reason notify.Reason, at time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	seq, ok := s.open[id]
+	if !ok {
+		return nil
+	}
+	return s.write(record{Event: "close", Seq: seq, Time: at, Reason: reason})
+}
+
+// MarkInvoked records that the action key was invoked on the notification
+// shown with id.
+func (s *Store) MarkInvoked(id uint32, key string, at time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	seq, ok := s.open[id]
+	if !ok {
+		return nil
+	}
+	return s.write(record{Event: "action", Seq: seq, Time: at, Action: key})
+}
+
+// Entries returns the entries of the history, oldest first.
+func (s *Store) Entries() []Entry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Entry{}, s.entries...)
+}
+
+// Close closes the file.
+func (s *Store) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.enc == nil {
+		return nil
+	}
+	s.enc = nil
+	return s.f.Close()
+}
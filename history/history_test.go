@@ -0,0 +1,29 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	lines := `{"event":"notify","seq":1,"entry":{"seq":1,"id":1}}
+not a record
+{"event":"notify","seq":2,"entry":{"seq":2,"id":2}}
+{"event":"notify","seq":3,"entry":{"se`
+	if err := os.WriteFile(path, []byte(lines), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got := s.Corrupt(); got != 1 {
+		t.Errorf("Corrupt() = %v, want 1", got)
+	}
+	if got := s.Query(Query{}).Total; got != 2 {
+		t.Errorf("loaded %v entries, want 2", got)
+	}
+}
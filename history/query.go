@@ -0,0 +1,112 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// Order is the order of the entries a Query returns.
+type Order int
+
+const (
+	NewestFirst Order = iota
+	OldestFirst
+)
+
+// Query selects entries of the history. Zero fields don't filter.
+type Query struct {
+	App      string           // AppName, exactly
+	Category string           // the category hint, also matching subcategories: "im" matches "im.received"
+	Since    time.Time        // shown at or after
+	Until    time.Time        // shown before
+	Outcomes []notify.Outcome // any of them
+	// Text is searched for in the summary and body, ignoring case. All its
+	// words must appear, in any order.
+	Text string
+
+	Order  Order
+	Offset int // entries to skip, for pagination
+	Limit  int // at most this many, all if 0
+}
+
+// Page is a page of the result of a Query.
+type Page struct {
+	Entries []Entry
+	Total   int // entries matching, on all pages
+}
+
+// Next returns q for the page after p, and whether there is one.
+func (q Query) Next(p Page) (Query, bool) {
+	q.Offset += len(p.Entries)
+	return q, len(p.Entries) > 0 && q.Offset < p.Total
+}
+
+// Match reports whether e is selected by q, not counting pagination.
+func (q Query) Match(e Entry) bool {
+	note := e.Notification
+	if q.App != "" && note.AppName != q.App {
+		return false
+	}
+	if q.Category != "" {
+		category, _ := note.Hints[notify.HintCategory].Value().(string)
+		if category != q.Category && !strings.HasPrefix(category, q.Category+".") {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	if len(q.Outcomes) > 0 {
+		found := false
+		for _, o := range q.Outcomes {
+			found = found || o == e.Outcome
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Text != "" {
+		text := strings.ToLower(note.Summary + "\n" + note.Body)
+		for _, word := range strings.Fields(strings.ToLower(q.Text)) {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Query returns the page of entries selected by q.
+func (s *Store) Query(q Query) Page {
+	var matching []Entry
+	for _, e := range s.Entries() {
+		if q.Match(e) {
+			matching = append(matching, e)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		if q.Order == OldestFirst {
+			return matching[i].Time.Before(matching[j].Time)
+		}
+		return matching[j].Time.Before(matching[i].Time)
+	})
+	page := Page{Total: len(matching)}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	if q.Offset >= len(matching) {
+		return page
+	}
+	matching = matching[q.Offset:]
+	if q.Limit > 0 && len(matching) > q.Limit {
+		matching = matching[:q.Limit]
+	}
+	page.Entries = matching
+	return page
+}
@@ -0,0 +1,177 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+// pruneInterval is how often Add prunes entries that got too old.
+const pruneInterval = time.Hour
+
+// Retention limits what a Store keeps, as logging every notification
+// forever keeps a lot of private messages. Zero fields don't limit.
+type Retention struct {
+	MaxAge   time.Duration // entries shown longer ago are removed
+	MaxCount int           // the oldest entries beyond it are removed
+	// ExcludeTransient skips notifications with the transient hint, which
+	// asks servers not to persist them.
+	ExcludeTransient bool
+	// ExcludeCategories skips notifications of these categories and their
+	// subcategories, e.g. "im" for chat messages.
+	ExcludeCategories []string
+}
+
+// excludes reports whether r keeps e out of the history altogether.
+func (r Retention) excludes(e Entry) bool {
+	hints := e.Notification.Hints
+	if r.ExcludeTransient && hintBool(hints[notify.HintTransient]) {
+		return true
+	}
+	category, _ := hints[notify.HintCategory].Value().(string)
+	for _, c := range r.ExcludeCategories {
+		if category == c || strings.HasPrefix(category, c+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// hintBool returns the value of a boolean hint. Like server.HintBool it
+// takes integers too, which some senders use for booleans.
+func hintBool(v dbus.Variant) bool {
+	switch b := v.Value().(type) {
+	case bool:
+		return b
+	case byte:
+		return b != 0
+	case int16:
+		return b != 0
+	case uint16:
+		return b != 0
+	case int32:
+		return b != 0
+	case uint32:
+		return b != 0
+	case int64:
+		return b != 0
+	case uint64:
+		return b != 0
+	}
+	return false
+}
+
+// SetRetention makes s keep entries as limited by r from now on, and prunes
+// by it right away: entries excluded by it are removed too. A Store from
+// OpenReadOnly leaves the file alone and only drops them from memory.
+func (s *Store) SetRetention(r Retention) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.retention = &r
+	return s.prune(time.Now())
+}
+
+// Prune removes the entries the retention set with SetRetention doesn't
+// keep at now, rewriting the file without them. Add does so as needed.
+func (s *Store) Prune(now time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.prune(now)
+}
+
+// pruneDue reports whether Add should prune. s.lock must be held.
+func (s *Store) pruneDue(now time.Time) bool {
+	r := s.retention
+	if r == nil {
+		return false
+	}
+	if r.MaxCount > 0 && len(s.entries) > r.MaxCount+r.MaxCount/10 {
+		return true
+	}
+	return r.MaxAge > 0 && now.Sub(s.pruned) >= pruneInterval
+}
+
+// prune removes the entries the retention doesn't keep. s.lock must be held.
+func (s *Store) prune(now time.Time) error {
+	r := s.retention
+	s.pruned = now
+	if r == nil || (s.enc == nil && !s.readOnly) {
+		return nil
+	}
+	var kept []Entry
+	for _, e := range s.entries {
+		if r.excludes(e) || (r.MaxAge > 0 && now.Sub(e.Time) > r.MaxAge) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if r.MaxCount > 0 && len(kept) > r.MaxCount {
+		kept = kept[len(kept)-r.MaxCount:]
+	}
+	if len(kept) == len(s.entries) {
+		return nil
+	}
+	if s.readOnly {
+		s.reset(kept)
+		return nil
+	}
+	return s.rewrite(kept)
+}
+
+// rewrite replaces the file with one holding entries. s.lock must be held.
+func (s *Store) rewrite(entries []Entry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".history-*")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	enc.SetEscapeHTML(false)
+	for i := range entries {
+		e := entries[i]
+		if err = enc.Encode(record{Event: "notify", Seq: e.Seq, Time: e.Time, Entry: &e}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		// the entries are on disk, but can't be added to any more.
+		s.f.Close()
+		s.enc = nil
+		return err
+	}
+	s.f.Close()
+	s.f = f
+	s.enc = json.NewEncoder(f)
+	s.enc.SetEscapeHTML(false)
+	s.reset(entries)
+	return nil
+}
+
+// reset replaces the entries in memory with entries. s.lock must be held.
+func (s *Store) reset(entries []Entry) {
+	s.entries = nil
+	s.bySeq = make(map[uint64]int)
+	s.open = make(map[uint32]uint64)
+	for i := range entries {
+		e := entries[i]
+		s.apply(record{Event: "notify", Seq: e.Seq, Time: e.Time, Entry: &e})
+	}
+}
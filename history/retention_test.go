@@ -0,0 +1,35 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+func TestRetentionExcludes(t *testing.T) {
+	r := Retention{ExcludeTransient: true, ExcludeCategories: []string{"im"}}
+	tests := []struct {
+		name  string
+		hints map[string]dbus.Variant
+		want  bool
+	}{
+		{"no hints", nil, false},
+		{"transient", map[string]dbus.Variant{notify.HintTransient: dbus.MakeVariant(true)}, true},
+		{"not transient", map[string]dbus.Variant{notify.HintTransient: dbus.MakeVariant(false)}, false},
+		{"transient int32", map[string]dbus.Variant{notify.HintTransient: dbus.MakeVariant(int32(1))}, true},
+		{"transient byte 0", map[string]dbus.Variant{notify.HintTransient: dbus.MakeVariant(byte(0))}, false},
+		{"transient string", map[string]dbus.Variant{notify.HintTransient: dbus.MakeVariant("true")}, false},
+		{"category", map[string]dbus.Variant{notify.HintCategory: dbus.MakeVariant("im")}, true},
+		{"subcategory", map[string]dbus.Variant{notify.HintCategory: dbus.MakeVariant("im.received")}, true},
+		{"other category", map[string]dbus.Variant{notify.HintCategory: dbus.MakeVariant("image")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Entry{Notification: notify.Notification{Hints: tt.hints}}
+			if got := r.excludes(e); got != tt.want {
+				t.Errorf("excludes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// AppIdentity is the name and icon an application is known by on the
+// desktop.
+type AppIdentity struct {
+	Name string
+	Icon string
+}
+
+var (
+	detectOnce sync.Once
+	detected   AppIdentity
+)
+
+// WithoutAppDetection stops the Notifier from filling in an empty AppName
+// and AppIcon from DetectApp.
+func WithoutAppDetection() Option {
+	return func(n *notifier) {
+		n.detectApp = false
+	}
+}
+
+// DetectApp makes a best-effort guess at the identity of the running
+// application, which the Notifier uses for notifications that leave AppName
+// or AppIcon empty, so they don't show up unnamed:
+//
+//   - Inside Flatpak, the desktop entry of the application ID.
+//   - Otherwise a desktop entry in the XDG data dirs named after the
+//     executable, or whose Exec runs it.
+//   - Failing that, the executable name and no icon.
+//
+// The result is computed once and cached.
+func DetectApp() AppIdentity {
+	detectOnce.Do(func() {
+		detected = detectApp()
+	})
+	return detected
+}
+
+func detectApp() AppIdentity {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	exe = filepath.Base(exe)
+
+	if DetectSandbox() == SandboxFlatpak {
+		if id := flatpakAppID(); id != "" {
+			if entry := findDesktopEntry(id, ""); entry != nil {
+				return desktopIdentity(entry, id)
+			}
+			return AppIdentity{Name: id, Icon: id}
+		}
+	}
+	if entry := findDesktopEntry(exe, exe); entry != nil {
+		return desktopIdentity(entry, exe)
+	}
+	return AppIdentity{Name: exe}
+}
+
+func desktopIdentity(entry map[string]string, fallback string) AppIdentity {
+	id := AppIdentity{Name: entry["Name"], Icon: entry["Icon"]}
+	if id.Name == "" {
+		id.Name = fallback
+	}
+	return id
+}
+
+// findDesktopEntry returns the [Desktop Entry] group of the desktop file
+// named id.desktop, or if exe is set, of the first one running exe.
+func findDesktopEntry(id, exe string) map[string]string {
+	dirs := applicationDirs()
+	for _, dir := range dirs {
+		if entry := readKeyFile(filepath.Join(dir, id+".desktop"), "Desktop Entry"); entry != nil {
+			return entry
+		}
+	}
+	if exe == "" {
+		return nil
+	}
+	for _, dir := range dirs {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.desktop"))
+		for _, file := range files {
+			entry := readKeyFile(file, "Desktop Entry")
+			if fields := strings.Fields(entry["Exec"]); len(fields) > 0 && filepath.Base(fields[0]) == exe {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
+// applicationDirs returns the applications directories of the XDG data
+// dirs, most important first.
+func applicationDirs() []string {
+	home := os.Getenv("XDG_DATA_HOME")
+	if home == "" {
+		if h, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(h, ".local", "share")
+		}
+	}
+	data := os.Getenv("XDG_DATA_DIRS")
+	if data == "" {
+		data = "/usr/local/share:/usr/share"
+	}
+	var dirs []string
+	for _, dir := range append([]string{home}, filepath.SplitList(data)...) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "applications"))
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"image"
+	"image/draw"
+)
+
+// ImageData is the (iiibiiay) struct of the image-data hint, raw pixels the
+// server shows instead of the icon:
+//
+//	note.SetHint("image-data", notify.NewImageData(img))
+type ImageData struct {
+	Width, Height, Rowstride int32
+	HasAlpha                 bool
+	BitsPerSample, Channels  int32
+	Data                     []byte
+}
+
+// NewImageData returns the pixels of img as 8 bit RGBA, not premultiplied,
+// as the spec has them.
+func NewImageData(img image.Image) ImageData {
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return ImageData{
+		Width:         int32(b.Dx()),
+		Height:        int32(b.Dy()),
+		Rowstride:     int32(rgba.Stride),
+		HasAlpha:      true,
+		BitsPerSample: 8,
+		Channels:      4,
+		Data:          rgba.Pix,
+	}
+}
@@ -0,0 +1,63 @@
+// Package configfile reads the configuration files of the packages again
+// when they change, so edits apply without a restart, and writes the files
+// the packages keep their state in.
+package configfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Reload calls parse with the contents of the file at path if it was
+// modified since *modified, then sets *modified to its modification time.
+// If the file doesn't exist parse is called with nil, every time. If parse
+// fails *modified is left alone, so a half written file is read again once
+// it is complete.
+func Reload(path string, modified *time.Time, parse func(data []byte) error) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		*modified = time.Time{}
+		return parse(nil)
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(*modified) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := parse(data); err != nil {
+		return err
+	}
+	*modified = info.ModTime()
+	return nil
+}
+
+// Write replaces the file at path with data, readable by the user only. It
+// writes a temporary file, syncs it and renames it over path, so after a
+// crash path holds either the old or the new data, never a part of it.
+func Write(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package configfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	var modified time.Time
+	var got []string
+	parse := func(data []byte) error {
+		if string(data) == "bad" {
+			return errors.New("bad")
+		}
+		got = append(got, string(data))
+		return nil
+	}
+	write := func(s string, at time.Time) {
+		if err := os.WriteFile(path, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Truncate(time.Second)
+
+	if err := Reload(path, &modified, parse); err != nil || len(got) != 1 || got[0] != "" {
+		t.Fatalf("missing file: got %q, %v", got, err)
+	}
+	write("a", start)
+	Reload(path, &modified, parse)
+	Reload(path, &modified, parse)
+	if len(got) != 2 || got[1] != "a" {
+		t.Fatalf("unchanged file read again: %q", got)
+	}
+	write("bad", start.Add(time.Second))
+	if err := Reload(path, &modified, parse); err == nil {
+		t.Fatal("no error for a bad file")
+	}
+	if !modified.Equal(start) {
+		t.Errorf("modified = %v after a bad file, want %v", modified, start)
+	}
+	write("b", start.Add(2*time.Second))
+	if err := Reload(path, &modified, parse); err != nil || got[len(got)-1] != "b" {
+		t.Fatalf("fixed file: got %q, %v", got, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	for _, s := range []string{"first", "second"} {
+		if err := Write(path, []byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != s {
+			t.Errorf("read %q, wrote %q", data, s)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("mode %v, want 0600", mode)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%v files left in the directory, want 1", len(entries))
+	}
+}
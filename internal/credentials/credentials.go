@@ -0,0 +1,50 @@
+// Package credentials caches the notify.Credentials of the senders on a
+// bus, for the packages receiving notifications from any application.
+package credentials
+
+import (
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+// maxSenders caps the cache of the credentials of bus names.
+const maxSenders = 1024
+
+// Cache looks up the credentials of bus names once per name, as unique
+// names are never reused.
+type Cache struct {
+	conn *dbus.Conn
+
+	lock    sync.Mutex
+	senders map[string]*notify.Credentials // by bus name, nil if unknown
+}
+
+// New returns a Cache asking the bus of conn.
+func New(conn *dbus.Conn) *Cache {
+	return &Cache{conn: conn, senders: make(map[string]*notify.Credentials)}
+}
+
+// Lookup returns the credentials of the process owning the bus name
+// sender, or nil if the bus can't tell. Each call returns a copy.
+func (c *Cache) Lookup(sender string) *notify.Credentials {
+	c.lock.Lock()
+	creds, ok := c.senders[sender]
+	c.lock.Unlock()
+	if !ok {
+		creds, _ = notify.SenderCredentials(c.conn, sender)
+		c.lock.Lock()
+		// senders come and go, so the cache is dropped once it is full.
+		if len(c.senders) >= maxSenders {
+			c.senders = make(map[string]*notify.Credentials)
+		}
+		c.senders[sender] = creds
+		c.lock.Unlock()
+	}
+	if creds == nil {
+		return nil
+	}
+	dup := *creds
+	return &dup
+}
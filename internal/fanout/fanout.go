@@ -0,0 +1,100 @@
+// Package fanout hands the signals of one notify.Notifier to any number of
+// subscribers, for the packages exposing a Notifier to other processes.
+package fanout
+
+import (
+	"log"
+	"sync"
+
+	"github.com/esiqveland/notify"
+)
+
+// Event is a signal received by the Notifier; exactly one field is set.
+type Event struct {
+	Closed *notify.NotificationClosedSignal
+	Action *notify.ActionInvokedSignal
+}
+
+// Fanout consumes the signal channels of a Notifier and copies each signal
+// to every subscriber.
+type Fanout struct {
+	buffer int
+
+	lock        sync.Mutex
+	done        bool
+	subscribers map[chan Event]struct{}
+}
+
+// New starts consuming the signals of n. A subscriber falling more than
+// buffer events behind misses events, so a stalled client can't stall the
+// Notifier.
+func New(n notify.Notifier, buffer int) *Fanout {
+	f := &Fanout{
+		buffer:      buffer,
+		subscribers: make(map[chan Event]struct{}),
+	}
+	go f.run(n)
+	return f
+}
+
+// Subscribe returns a channel receiving all events from now on, and a
+// function to call when done with it. The channel is closed when the
+// Notifier is.
+func (f *Fanout) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, f.buffer)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.done {
+		close(events)
+		return events, func() {}
+	}
+	f.subscribers[events] = struct{}{}
+	return events, func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if _, ok := f.subscribers[events]; ok {
+			delete(f.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+func (f *Fanout) run(n notify.Notifier) {
+	closed, actions := n.NotificationClosed(), n.ActionInvoked()
+	for closed != nil || actions != nil {
+		select {
+		case c, ok := <-closed:
+			if !ok {
+				closed = nil
+				continue
+			}
+			f.publish(Event{Closed: c})
+		case a, ok := <-actions:
+			if !ok {
+				actions = nil
+				continue
+			}
+			f.publish(Event{Action: a})
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.done = true
+	for events := range f.subscribers {
+		delete(f.subscribers, events)
+		close(events)
+	}
+}
+
+func (f *Fanout) publish(event Event) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for events := range f.subscribers {
+		select {
+		case events <- event:
+		default:
+			log.Printf("subscriber is falling behind, dropping event: %+v", event)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// Package toml decodes the TOML used by the configuration files of the
+// packages: tables, arrays of tables and key/value pairs of strings,
+// integers, floats, booleans and arrays of them. Inline tables, dates and
+// multi-line strings are not supported.
+//
+// See: https://toml.io/en/v1.0.0
+package toml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Unmarshal decodes data into v the way encoding/json decodes the JSON
+// form of the document, so v is tagged for encoding/json; a TOML table is
+// a JSON object.
+func Unmarshal(data []byte, v interface{}) error {
+	doc, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, v)
+}
+
+// Parse decodes data to tables of map[string]interface{}, arrays of
+// []interface{}, and string, int64, float64 and bool values.
+func Parse(data []byte) (map[string]interface{}, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("toml: invalid UTF-8")
+	}
+	p := &parser{s: string(data), line: 1, root: make(map[string]interface{})}
+	p.table = p.root
+	if err := p.document(); err != nil {
+		return nil, fmt.Errorf("toml: line %v: %w", p.line, err)
+	}
+	return p.root, nil
+}
+
+type parser struct {
+	s    string // left to parse
+	line int
+
+	root  map[string]interface{}
+	table map[string]interface{} // of the last table header
+}
+
+func (p *parser) document() error {
+	for {
+		p.space(true)
+		if p.s == "" {
+			return nil
+		}
+		var err error
+		if p.s[0] == '[' {
+			err = p.header()
+		} else {
+			err = p.keyValue()
+		}
+		if err != nil {
+			return err
+		}
+		// nothing but a comment may follow on the line.
+		p.space(false)
+		if p.s != "" && p.s[0] != '\n' {
+			return fmt.Errorf("unexpected %q", p.s[0])
+		}
+	}
+}
+
+// space skips white space and comments, and newlines too if lines is set.
+func (p *parser) space(lines bool) {
+	for p.s != "" {
+		switch c := p.s[0]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.s = p.s[1:]
+		case c == '\n' && lines:
+			p.s = p.s[1:]
+			p.line++
+		case c == '#':
+			i := strings.IndexByte(p.s, '\n')
+			if i < 0 {
+				i = len(p.s)
+			}
+			p.s = p.s[i:]
+		default:
+			return
+		}
+	}
+}
+
+// header parses [table] and [[array.of.tables]].
+func (p *parser) header() error {
+	array := strings.HasPrefix(p.s, "[[")
+	if array {
+		p.s = p.s[2:]
+	} else {
+		p.s = p.s[1:]
+	}
+	keys, err := p.keys()
+	if err != nil {
+		return err
+	}
+	end := "]"
+	if array {
+		end = "]]"
+	}
+	if !strings.HasPrefix(p.s, end) {
+		return fmt.Errorf("missing %v", end)
+	}
+	p.s = p.s[len(end):]
+
+	t := p.root
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch v := t[key].(type) {
+		case nil:
+			if last && array {
+				next := make(map[string]interface{})
+				t[key] = []interface{}{next}
+				t = next
+				continue
+			}
+			next := make(map[string]interface{})
+			t[key] = next
+			t = next
+		case map[string]interface{}:
+			if last && array {
+				return fmt.Errorf("%v is a table, not an array of tables", key)
+			}
+			t = v
+		case []interface{}:
+			tables, ok := v[len(v)-1].(map[string]interface{})
+			if !ok || (last && !array) {
+				return fmt.Errorf("%v is already defined", key)
+			}
+			if last {
+				tables = make(map[string]interface{})
+				t[key] = append(v, tables)
+			}
+			t = tables
+		default:
+			return fmt.Errorf("%v is already defined", key)
+		}
+	}
+	p.table = t
+	return nil
+}
+
+// keyValue parses key = value into the current table.
+func (p *parser) keyValue() error {
+	keys, err := p.keys()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(p.s, "=") {
+		return fmt.Errorf("missing = after %v", strings.Join(keys, "."))
+	}
+	p.s = p.s[1:]
+	p.space(false)
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+	t := p.table
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := t[key].(map[string]interface{})
+		if !ok {
+			if t[key] != nil {
+				return fmt.Errorf("%v is already defined", key)
+			}
+			next = make(map[string]interface{})
+			t[key] = next
+		}
+		t = next
+	}
+	key := keys[len(keys)-1]
+	if _, ok := t[key]; ok {
+		return fmt.Errorf("%v is already defined", key)
+	}
+	t[key] = value
+	return nil
+}
+
+// keys parses a dotted key, and the space after it.
+func (p *parser) keys() ([]string, error) {
+	var keys []string
+	for {
+		p.space(false)
+		var key string
+		switch {
+		case strings.HasPrefix(p.s, `"`):
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case strings.HasPrefix(p.s, "'"):
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			i := 0
+			for i < len(p.s) && isBareKey(p.s[i]) {
+				i++
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("missing key")
+			}
+			key, p.s = p.s[:i], p.s[i:]
+		}
+		keys = append(keys, key)
+		p.space(false)
+		if !strings.HasPrefix(p.s, ".") {
+			return keys, nil
+		}
+		p.s = p.s[1:]
+	}
+}
+
+func isBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *parser) value() (interface{}, error) {
+	switch {
+	case strings.HasPrefix(p.s, `"""`) || strings.HasPrefix(p.s, "'''"):
+		return nil, fmt.Errorf("multi-line strings are not supported")
+	case strings.HasPrefix(p.s, `"`):
+		return p.basicString()
+	case strings.HasPrefix(p.s, "'"):
+		return p.literalString()
+	case strings.HasPrefix(p.s, "["):
+		return p.array()
+	case strings.HasPrefix(p.s, "{"):
+		return nil, fmt.Errorf("inline tables are not supported")
+	}
+	i := 0
+	for i < len(p.s) && strings.IndexByte(" \t\r\n#,]", p.s[i]) < 0 {
+		i++
+	}
+	word := p.s[:i]
+	p.s = p.s[i:]
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return nil, fmt.Errorf("%v can't be decoded", word)
+	case "":
+		return nil, fmt.Errorf("missing value")
+	}
+	number := strings.ReplaceAll(word, "_", "")
+	if isInteger(number) {
+		i, err := strconv.ParseInt(number, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %v", word)
+		}
+		return i, nil
+	}
+	// floats have a decimal integer part, without leading zeros too.
+	whole := number
+	if i := strings.IndexAny(number, ".eE"); i >= 0 {
+		whole = number[:i]
+	}
+	if strings.Trim(number, "0123456789+-.eE") == "" && isInteger(whole) {
+		if f, err := strconv.ParseFloat(number, 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid value %v", word)
+}
+
+// isInteger reports whether s, without underscores, is a TOML integer:
+// decimal without leading zeros, or hexadecimal, octal or binary with a
+// 0x, 0o or 0b prefix.
+func isInteger(s string) bool {
+	for _, prefix := range []string{"0x", "0o", "0b"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "+"), "-")
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return false
+	}
+	return digits == "0" || digits[0] != '0'
+}
+
+func (p *parser) array() ([]interface{}, error) {
+	p.s = p.s[1:]
+	values := []interface{}{}
+	for {
+		p.space(true)
+		if strings.HasPrefix(p.s, "]") {
+			p.s = p.s[1:]
+			return values, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		p.space(true)
+		switch {
+		case strings.HasPrefix(p.s, ","):
+			p.s = p.s[1:]
+		case strings.HasPrefix(p.s, "]"):
+		default:
+			return nil, fmt.Errorf("missing , or ] in array")
+		}
+	}
+}
+
+func (p *parser) literalString() (string, error) {
+	end := strings.IndexAny(p.s[1:], "'\n")
+	if end < 0 || p.s[1+end] != '\'' {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := p.s[1 : 1+end]
+	p.s = p.s[2+end:]
+	return s, nil
+}
+
+func (p *parser) basicString() (string, error) {
+	var b strings.Builder
+	i := 1
+	for {
+		if i >= len(p.s) || p.s[i] == '\n' {
+			return "", fmt.Errorf("unterminated string")
+		}
+		c := p.s[i]
+		if c == '"' {
+			p.s = p.s[i+1:]
+			return b.String(), nil
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if i+1 >= len(p.s) {
+			return "", fmt.Errorf("unterminated string")
+		}
+		i += 2
+		switch e := p.s[i-1]; e {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(e)
+		case 'u', 'U':
+			n := 4
+			if e == 'U' {
+				n = 8
+			}
+			if i+n > len(p.s) {
+				return "", fmt.Errorf("invalid escape \\%c", e)
+			}
+			r, err := strconv.ParseUint(p.s[i:i+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", fmt.Errorf("invalid escape \\%c%v", e, p.s[i:i+n])
+			}
+			b.WriteRune(rune(r))
+			i += n
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", e)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package toml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type table = map[string]interface{}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want table
+	}{
+		{"empty", "", table{}},
+		{"comments", "# a comment\n\na = 1 # after a value\n# last", table{"a": int64(1)}},
+		{"types", "s = 'x'\ni = -12\nf = 1.5\nb = true\nn = 1_000", table{
+			"s": "x", "i": int64(-12), "f": 1.5, "b": true, "n": int64(1000),
+		}},
+		{"integer bases", "h = 0xff\no = 0o17\nb = 0b101", table{"h": int64(255), "o": int64(15), "b": int64(5)}},
+		{"exponent", "e = 1e3", table{"e": 1000.0}},
+		{"escapes", `s = "tab\there \"quoted\" \\ \u00e9 \U0001F600"`, table{"s": "tab\there \"quoted\" \\ é 😀"}},
+		{"literal string", `s = 'C:\path\n'`, table{"s": `C:\path\n`}},
+		{"quoted keys", `"a b" = 1` + "\n'c.d' = 2", table{"a b": int64(1), "c.d": int64(2)}},
+		{"dotted keys", "a.b = 1\na.c = 2", table{"a": table{"b": int64(1), "c": int64(2)}}},
+		{"arrays", "a = [1, 2,\n  3, # comment\n]\nb = [['x'], []]", table{
+			"a": []interface{}{int64(1), int64(2), int64(3)},
+			"b": []interface{}{[]interface{}{"x"}, []interface{}{}},
+		}},
+		{"tables", "[server]\nport = 80\n[server.tls]\ncert = 'c'", table{
+			"server": table{"port": int64(80), "tls": table{"cert": "c"}},
+		}},
+		{"arrays of tables", "[[rule]]\napp = 'a'\n[[rule]]\napp = 'b'", table{
+			"rule": []interface{}{table{"app": "a"}, table{"app": "b"}},
+		}},
+		{"subtable of array", "[[rule]]\n[rule.match]\napp = 'a'", table{
+			"rule": []interface{}{table{"match": table{"app": "a"}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{"missing value", "a =", "missing value"},
+		{"missing equals", "a 1", "missing ="},
+		{"missing key", "= 1", "missing key"},
+		{"redefined key", "a = 1\na = 2", "already defined"},
+		{"redefined table", "[a]\n[[a]]", "not an array of tables"},
+		{"unterminated string", `a = "x`, "unterminated string"},
+		{"string across lines", "a = 'x\n'", "unterminated string"},
+		{"invalid escape", `a = "\q"`, `invalid escape \q`},
+		{"invalid unicode escape", `a = "\uD800"`, "invalid escape"},
+		{"unclosed header", "[a", "missing ]"},
+		{"unclosed array", "a = [1 2]", "missing , or ]"},
+		{"leading zero", "a = 012", "invalid value"},
+		{"trailing text", "a = 1 b", "unexpected"},
+		{"inline table", "a = {b = 1}", "not supported"},
+		{"multi-line string", `a = """x"""`, "not supported"},
+		{"nan", "a = nan", "can't be decoded"},
+		{"invalid UTF-8", "a = '\xff'", "invalid UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse() error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseErrorLine(t *testing.T) {
+	_, err := Parse([]byte("a = 1\n\nb = \n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "toml: line 3:") {
+		t.Errorf("Parse() error = %v, want it on line 3", err)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var v struct {
+		Rule []struct {
+			App   string   `json:"app"`
+			Tags  []string `json:"tags"`
+			Limit int      `json:"limit"`
+		} `json:"rule"`
+	}
+	err := Unmarshal([]byte("[[rule]]\napp = 'mail'\ntags = ['a', 'b']\nlimit = 3"), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Rule) != 1 || v.Rule[0].App != "mail" || len(v.Rule[0].Tags) != 2 || v.Rule[0].Limit != 3 {
+		t.Errorf("Unmarshal() = %+v", v)
+	}
+}
@@ -0,0 +1,375 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// jsonNotification is the JSON form of a Notification.
+type jsonNotification struct {
+	AppName       string                 `json:"app_name"`
+	ReplacesID    uint32                 `json:"replaces_id"`
+	AppIcon       string                 `json:"app_icon"`
+	Summary       string                 `json:"summary"`
+	Body          string                 `json:"body"`
+	Actions       []string               `json:"actions"`
+	Hints         map[string]jsonVariant `json:"hints"`
+	ExpireTimeout int32                  `json:"expire_timeout"`
+}
+
+// jsonVariant is the JSON form of a dbus.Variant. The signature is kept, so
+// the variant decodes back to the exact same wire type.
+type jsonVariant struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// Hints are encoded together with their D-Bus signature, so a Notification
+// survives a round trip through JSON unchanged. Byte arrays (e.g. the pixels
+// of image-data) are encoded as base64 and structs as arrays of their fields.
+func (n Notification) MarshalJSON() ([]byte, error) {
+	j := jsonNotification{
+		AppName:       n.AppName,
+		ReplacesID:    n.ReplacesID,
+		AppIcon:       n.AppIcon,
+		Summary:       n.Summary,
+		Body:          n.Body,
+		Actions:       n.Actions,
+		ExpireTimeout: n.ExpireTimeout,
+	}
+	if n.Hints != nil {
+		j.Hints = make(map[string]jsonVariant, len(n.Hints))
+		for k, v := range n.Hints {
+			jv, err := marshalVariant(v)
+			if err != nil {
+				return nil, fmt.Errorf("hint %v: %v", k, err)
+			}
+			j.Hints[k] = jv
+		}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Notification) UnmarshalJSON(data []byte) error {
+	var j jsonNotification
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*n = Notification{
+		AppName:       j.AppName,
+		ReplacesID:    j.ReplacesID,
+		AppIcon:       j.AppIcon,
+		Summary:       j.Summary,
+		Body:          j.Body,
+		Actions:       j.Actions,
+		ExpireTimeout: j.ExpireTimeout,
+	}
+	if j.Hints != nil {
+		n.Hints = make(map[string]dbus.Variant, len(j.Hints))
+		for k, jv := range j.Hints {
+			v, err := unmarshalVariant(jv)
+			if err != nil {
+				return fmt.Errorf("hint %v: %v", k, err)
+			}
+			n.Hints[k] = v
+		}
+	}
+	return nil
+}
+
+func marshalVariant(v dbus.Variant) (jsonVariant, error) {
+	value, err := json.Marshal(jsonValue(reflect.ValueOf(v.Value())))
+	if err != nil {
+		return jsonVariant{}, err
+	}
+	return jsonVariant{Type: v.Signature().String(), Value: value}, nil
+}
+
+func unmarshalVariant(jv jsonVariant) (dbus.Variant, error) {
+	sig, err := dbus.ParseSignature(jv.Type)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	value, err := decodeValue(jv.Type, jv.Value)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	return dbus.MakeVariantWithSignature(value.Interface(), sig), nil
+}
+
+// jsonValue converts a value stored in a variant to something encoding/json
+// handles the way we want.
+func jsonValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch x := v.Interface().(type) {
+	case dbus.Variant:
+		jv, err := marshalVariant(x)
+		if err != nil {
+			return nil
+		}
+		return jv
+	case []byte:
+		return x
+	case dbus.Signature:
+		return x.String()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		ret := make([]interface{}, v.Len())
+		for i := range ret {
+			ret[i] = jsonValue(v.Index(i))
+		}
+		return ret
+	case reflect.Struct:
+		ret := make([]interface{}, 0, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				ret = append(ret, jsonValue(v.Field(i)))
+			}
+		}
+		return ret
+	case reflect.Map:
+		ret := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			ret[fmt.Sprint(k.Interface())] = jsonValue(v.MapIndex(k))
+		}
+		return ret
+	case reflect.Ptr, reflect.Interface:
+		return jsonValue(v.Elem())
+	}
+	return v.Interface()
+}
+
+// decodeValue decodes data as a value of D-Bus type sig.
+func decodeValue(sig string, data json.RawMessage) (reflect.Value, error) {
+	t, err := typeFor(sig)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	switch sig[0] {
+	case 'v':
+		var jv jsonVariant
+		if err := json.Unmarshal(data, &jv); err != nil {
+			return reflect.Value{}, err
+		}
+		v, err := unmarshalVariant(jv)
+		return reflect.ValueOf(v), err
+	case 'g':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return reflect.Value{}, err
+		}
+		v, err := dbus.ParseSignature(s)
+		return reflect.ValueOf(v), err
+	case '(':
+		var fields []json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return reflect.Value{}, err
+		}
+		sigs, err := splitSignature(sig[1 : len(sig)-1])
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(fields) != len(sigs) {
+			return reflect.Value{}, fmt.Errorf("struct %v has %v fields, got %v", sig, len(sigs), len(fields))
+		}
+		ret := reflect.New(t).Elem()
+		for i, s := range sigs {
+			f, err := decodeValue(s, fields[i])
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			ret.Field(i).Set(f)
+		}
+		return ret, nil
+	case 'a':
+		if sig == "ay" {
+			break
+		}
+		if sig[1] == '{' {
+			kv, err := splitSignature(sig[2 : len(sig)-1])
+			if err != nil || len(kv) != 2 {
+				return reflect.Value{}, fmt.Errorf("invalid dict signature %v", sig)
+			}
+			var entries map[string]json.RawMessage
+			if err := json.Unmarshal(data, &entries); err != nil {
+				return reflect.Value{}, err
+			}
+			ret := reflect.MakeMapWithSize(t, len(entries))
+			for k, raw := range entries {
+				key, err := decodeKey(kv[0], k)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				value, err := decodeValue(kv[1], raw)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				ret.SetMapIndex(key.Convert(t.Key()), value)
+			}
+			return ret, nil
+		}
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return reflect.Value{}, err
+		}
+		ret := reflect.MakeSlice(t, len(elems), len(elems))
+		for i, raw := range elems {
+			e, err := decodeValue(sig[1:], raw)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			ret.Index(i).Set(e)
+		}
+		return ret, nil
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return ptr.Elem(), nil
+}
+
+// decodeKey decodes a dict key, which JSON always stores as a string.
+func decodeKey(sig string, key string) (reflect.Value, error) {
+	switch sig {
+	case "s", "o", "g":
+		quoted, err := json.Marshal(key)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return decodeValue(sig, quoted)
+	case "b":
+		b, err := strconv.ParseBool(key)
+		return reflect.ValueOf(b), err
+	}
+	return decodeValue(sig, json.RawMessage(key))
+}
+
+// typeFor returns the go type used to hold values of D-Bus type sig.
+// Structs are represented by struct types with one exported field per
+// member, which godbus encodes back to the same signature.
+func typeFor(sig string) (reflect.Type, error) {
+	if sig == "" {
+		return nil, errors.New("empty signature")
+	}
+	switch sig[0] {
+	case 'y':
+		return reflect.TypeOf(byte(0)), nil
+	case 'b':
+		return reflect.TypeOf(false), nil
+	case 'n':
+		return reflect.TypeOf(int16(0)), nil
+	case 'q':
+		return reflect.TypeOf(uint16(0)), nil
+	case 'i':
+		return reflect.TypeOf(int32(0)), nil
+	case 'u':
+		return reflect.TypeOf(uint32(0)), nil
+	case 'x':
+		return reflect.TypeOf(int64(0)), nil
+	case 't':
+		return reflect.TypeOf(uint64(0)), nil
+	case 'd':
+		return reflect.TypeOf(float64(0)), nil
+	case 's':
+		return reflect.TypeOf(""), nil
+	case 'o':
+		return reflect.TypeOf(dbus.ObjectPath("")), nil
+	case 'g':
+		return reflect.TypeOf(dbus.Signature{}), nil
+	case 'v':
+		return reflect.TypeOf(dbus.Variant{}), nil
+	case 'h':
+		return reflect.TypeOf(dbus.UnixFDIndex(0)), nil
+	case 'a':
+		if len(sig) > 1 && sig[1] == '{' {
+			kv, err := splitSignature(sig[2 : len(sig)-1])
+			if err != nil || len(kv) != 2 {
+				return nil, fmt.Errorf("invalid dict signature %v", sig)
+			}
+			k, err := typeFor(kv[0])
+			if err != nil {
+				return nil, err
+			}
+			v, err := typeFor(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			return reflect.MapOf(k, v), nil
+		}
+		elem, err := typeFor(sig[1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	case '(':
+		sigs, err := splitSignature(sig[1 : len(sig)-1])
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]reflect.StructField, len(sigs))
+		for i, s := range sigs {
+			t, err := typeFor(s)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = reflect.StructField{Name: "F" + strconv.Itoa(i), Type: t}
+		}
+		return reflect.StructOf(fields), nil
+	}
+	return nil, fmt.Errorf("unsupported signature %v", sig)
+}
+
+// splitSignature splits sig into its single complete types.
+func splitSignature(sig string) ([]string, error) {
+	var ret []string
+	for sig != "" {
+		n, err := completeTypeLen(sig)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, sig[:n])
+		sig = sig[n:]
+	}
+	return ret, nil
+}
+
+// completeTypeLen returns the length of the first complete type in sig.
+func completeTypeLen(sig string) (int, error) {
+	if sig == "" {
+		return 0, errors.New("incomplete signature")
+	}
+	switch sig[0] {
+	case 'a':
+		n, err := completeTypeLen(sig[1:])
+		return n + 1, err
+	case '(', '{':
+		end := byte(')')
+		if sig[0] == '{' {
+			end = '}'
+		}
+		i := 1
+		for i < len(sig) && sig[i] != end {
+			n, err := completeTypeLen(sig[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+		if i >= len(sig) {
+			return 0, errors.New("unterminated signature " + sig)
+		}
+		return i + 1, nil
+	}
+	return 1, nil
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONRecord is one line written by a JSONLinesNotifier.
+type JSONRecord struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"` // "notify" or "close"
+	ID    uint32    `json:"id"`
+	// Notification is what was sent, for "notify".
+	Notification *Notification `json:"notification,omitempty"`
+}
+
+// JSONLinesNotifier is a Notifier writing every notification sent and
+// closed as a JSONRecord on a line of its own, so headless CI runs and
+// containers keep the notification stream for later inspection, e.g. with
+// jq or ReadJSONLines. Hints keep their D-Bus types, see
+// Notification.MarshalJSON. Signals are never emitted.
+type JSONLinesNotifier struct {
+	*NullNotifier
+
+	lock  sync.Mutex
+	enc   *json.Encoder
+	close func() error // of a file opened by OpenJSONLines
+}
+
+// NewJSONLinesNotifier creates a JSONLinesNotifier writing to w, e.g.
+// os.Stdout.
+func NewJSONLinesNotifier(w io.Writer) *JSONLinesNotifier {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLinesNotifier{NullNotifier: NewNullNotifier(), enc: enc}
+}
+
+// OpenJSONLines creates a JSONLinesNotifier appending to the file at path,
+// which is created if needed and closed by Close.
+func OpenJSONLines(path string) (*JSONLinesNotifier, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := NewJSONLinesNotifier(f)
+	j.close = f.Close
+	return j, nil
+}
+
+func (j *JSONLinesNotifier) write(r JSONRecord) error {
+	r.Time = time.Now()
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.enc.Encode(r)
+}
+
+// SendNotification writes note with the ID it is given.
+func (j *JSONLinesNotifier) SendNotification(note Notification) (uint32, error) {
+	id, _ := j.NullNotifier.SendNotification(note)
+	if err := j.write(JSONRecord{Event: "notify", ID: id, Notification: &note}); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// CloseNotification writes that id was closed.
+func (j *JSONLinesNotifier) CloseNotification(id uint32) (bool, error) {
+	return true, j.write(JSONRecord{Event: "close", ID: id})
+}
+
+// CloseNotifications writes that ids were closed.
+func (j *JSONLinesNotifier) CloseNotifications(ids ...uint32) error {
+	for _, id := range ids {
+		if err := j.write(JSONRecord{Event: "close", ID: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithApp returns a child of j, see NewChild.
+func (j *JSONLinesNotifier) WithApp(appName, appIcon string) Notifier {
+	return NewChild(j, appName, appIcon)
+}
+
+// Close closes the signal channels, and the file of OpenJSONLines.
+func (j *JSONLinesNotifier) Close() error {
+	j.NullNotifier.Close()
+	if j.close != nil {
+		return j.close()
+	}
+	return nil
+}
+
+// ReadJSONLines reads the records written by a JSONLinesNotifier.
+func ReadJSONLines(r io.Reader) ([]JSONRecord, error) {
+	var records []JSONRecord
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var record JSONRecord
+		err := dec.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
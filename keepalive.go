@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// KeepAlive keeps a status notification, e.g. "Recording…", on screen by
+// re-sending it every interval, replacing itself. This brings it back after
+// the notification daemon restarts, and lets the content change over time,
+// e.g. to show for how long something has been running.
+//
+// Set an ExpireTimeout of 0 and the resident hint so it doesn't go away by
+// itself. Once the user dismisses it, it stays away: pass the signals of
+// NotificationClosed to HandleClosed.
+type KeepAlive struct {
+	n        Notifier
+	content  func() Notification
+	clock    Clock
+	interval time.Duration
+
+	lock      sync.Mutex
+	id        uint32
+	alarm     Alarm
+	stopped   bool // closed or dismissed
+	dismissed bool
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewKeepAlive sends the notification returned by content, and re-sends
+// it every interval, on the clock of n (see WithClock), with content
+// called again each time. The ReplacesID returned by content is ignored.
+// Call Close to stop and remove the notification.
+func NewKeepAlive(n Notifier, interval time.Duration, content func() Notification) (*KeepAlive, error) {
+	k := &KeepAlive{
+		n:        n,
+		content:  content,
+		clock:    ClockOf(n),
+		interval: interval,
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err := k.send(content()); err != nil {
+		return nil, err
+	}
+	k.alarm = k.clock.AfterFunc(interval, k.refresh)
+	return k, nil
+}
+
+// ID returns the current ID of the notification. It changes if the daemon
+// restarted and didn't know the previous one.
+func (k *KeepAlive) ID() uint32 {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return k.id
+}
+
+// refresh re-sends the notification and schedules the next time.
+func (k *KeepAlive) refresh() {
+	note := k.content()
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.stopped {
+		return
+	}
+	// on error the daemon is most likely restarting, the next time tries
+	// again.
+	k.send(note)
+	k.alarm = k.clock.AfterFunc(k.interval, k.refresh)
+}
+
+// send sends note in place of the notification. k.lock must be held.
+func (k *KeepAlive) send(note Notification) error {
+	note.ReplacesID = k.id
+	id, err := k.n.SendNotification(note)
+	if err != nil {
+		return err
+	}
+	k.id = id
+	return nil
+}
+
+// HandleClosed stops refreshing if signal is the user dismissing the
+// notification. Call it from the loop reading NotificationClosed.
+func (k *KeepAlive) HandleClosed(signal *NotificationClosedSignal) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if signal.Id == k.id && signal.Reason == ReasonDismissedByUser {
+		k.dismissed = true
+		k.stop()
+	}
+}
+
+// stop stops refreshing. k.lock must be held.
+func (k *KeepAlive) stop() {
+	k.stopped = true
+	if k.alarm != nil {
+		k.alarm.Stop()
+	}
+}
+
+// Close stops refreshing and closes the notification, unless the user
+// dismissed it. Calling it again does nothing and returns the same error.
+func (k *KeepAlive) Close() error {
+	k.closeOnce.Do(func() {
+		k.lock.Lock()
+		k.stop()
+		id, dismissed := k.id, k.dismissed
+		k.lock.Unlock()
+		if !dismissed {
+			_, k.closeErr = k.n.CloseNotification(id)
+		}
+	})
+	return k.closeErr
+}
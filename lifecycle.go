@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const signalNameOwnerChanged = "org.freedesktop.DBus.NameOwnerChanged"
+
+// reorderWindow is how far below the highest ID handed out an ID may be
+// without the server having restarted: replies to concurrent sends arrive
+// in any order.
+const reorderWindow = 1024
+
+// ReasonServerRestarted is the Reason of the NotificationClosed signals a
+// Notifier with WithRestartDetection emits for the notifications lost with
+// a notification server that restarted or went away. Servers never send it.
+const ReasonServerRestarted Reason = 256
+
+// ErrServerRestarted is returned for a notification lost with its
+// notification server, see WithRestartDetection.
+var ErrServerRestarted = errors.New("notification server restarted")
+
+// WithRestartDetection makes the Notifier notice when the notification
+// server restarts or goes away, from the bus reporting a new owner of
+// org.freedesktop.Notifications. In case that signal is missed, the server
+// handing out the ID of a notification still shown, or one far below those
+// it handed out before, counts as a restart too. The notifications it
+// showed are gone then, and their
+// IDs may be reused for others: the Notifier emits NotificationClosed with
+// ReasonServerRestarted for each of them, so WaitForAction returns
+// ErrServerRestarted, Handles stop updating, and helpers like Group and Timer
+// start over, instead of waiting for signals that never come.
+//
+// It has no effect with the notification portal, which keeps notifications
+// across restarts of the server.
+func WithRestartDetection() Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.lifecycle = &lifecycle{open: make(map[uint32]bool)}
+	}
+}
+
+// lifecycle tracks the notifications shown by the server.
+type lifecycle struct {
+	lock   sync.Mutex
+	open   map[uint32]bool // shown and not closed yet
+	lastID uint32          // the highest ID handed out
+}
+
+// ownerMatch is the match rule for the bus reporting a new owner of the
+// notification server name.
+func ownerMatch() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchSender(dbusDestination),
+		dbus.WithMatchObjectPath(dbusPath),
+		dbus.WithMatchInterface(dbusDestination),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, dbusNotificationsInterface),
+	}
+}
+
+// restartDetection reports whether n tracks restarts of the server.
+func (n *notifier) restartDetection() bool {
+	return n.lifecycle != nil && !n.portal && !n.dryRun
+}
+
+// observeID records that the server handed out id for a notification
+// replacing replaces. A new ID that is still shown, or far below the
+// highest one handed out, means the server started counting over; a
+// smaller ID alone doesn't, replies to concurrent sends come in any order.
+func (n *notifier) observeID(id, replaces uint32) {
+	if !n.restartDetection() {
+		return
+	}
+	l := n.lifecycle
+	l.lock.Lock()
+	if id != replaces && (l.open[id] || uint64(id)+reorderWindow < uint64(l.lastID)) {
+		lost := l.reset()
+		l.open[id] = true
+		l.lastID = id
+		l.lock.Unlock()
+		n.tracef("server handed out id %v again, it restarted", id)
+		n.emitLost(lost, id)
+		return
+	}
+	if id > l.lastID {
+		l.lastID = id
+	}
+	l.open[id] = true
+	l.lock.Unlock()
+}
+
+// observeClosed records that the notification with id was closed.
+func (n *notifier) observeClosed(id uint32) {
+	if !n.restartDetection() {
+		return
+	}
+	n.lifecycle.lock.Lock()
+	delete(n.lifecycle.open, id)
+	n.lifecycle.lock.Unlock()
+}
+
+// ownerChanged handles a NameOwnerChanged signal of the bus: the server
+// object is made again for the new server, and once the server that showed
+// the notifications lost its name, they are gone.
+func (n *notifier) ownerChanged(signal *dbus.Signal) {
+	var name, oldOwner, newOwner string
+	if dbus.Store(signal.Body, &name, &oldOwner, &newOwner) != nil || name != dbusNotificationsInterface {
+		return
+	}
+	n.setOwner(newOwner)
+	n.resetObject()
+	if !n.restartDetection() || oldOwner == "" {
+		return
+	}
+	n.lifecycle.lock.Lock()
+	lost := n.lifecycle.reset()
+	n.lifecycle.lock.Unlock()
+	n.tracef("notification server %v left, new owner %q", oldOwner, newOwner)
+	n.emitLost(lost, 0)
+}
+
+// reset forgets all notifications and returns their IDs. l.lock must be
+// held.
+func (l *lifecycle) reset() []uint32 {
+	lost := make([]uint32, 0, len(l.open))
+	for id := range l.open {
+		lost = append(lost, id)
+	}
+	l.open = make(map[uint32]bool)
+	l.lastID = 0
+	return lost
+}
+
+// emitLost emits NotificationClosed with ReasonServerRestarted for the ids,
+// except for the one shown by the new server.
+func (n *notifier) emitLost(ids []uint32, except uint32) {
+	if !n.addHandler() {
+		return
+	}
+	go func() {
+		defer n.handlers.Done()
+		for _, id := range ids {
+			if id == except {
+				continue
+			}
+			n.takeActivationToken(id)
+			n.closer.put(&NotificationClosedSignal{Id: id, Reason: ReasonServerRestarted}, n.closing)
+		}
+	}()
+}
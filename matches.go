@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Notifiers sharing a connection, see NewShared, need the same match rules.
+// The bus keeps one rule per AddMatch call, so the rules are counted per
+// connection instead: installed by the first Notifier and removed with the
+// last.
+var matches struct {
+	lock sync.Mutex
+	refs map[matchKey]int
+}
+
+type matchKey struct {
+	conn busConn
+	rule string
+}
+
+// ownerTimeout bounds asking the bus for the owner of the server name.
+const ownerTimeout = 5 * time.Second
+
+// Names of the match rules.
+const (
+	ruleSignals = "signals" // see signalMatch
+	rulePortal  = "portal"  // see portalMatch
+	ruleOwner   = "owner"   // see ownerMatch
+)
+
+// addMatch installs the match rules of n on its connection, unless another
+// Notifier on the connection did.
+func (n *notifier) addMatch() error {
+	rule := ruleSignals
+	if n.portal {
+		rule = rulePortal
+	}
+	if err := addRule(n.conn, rule, n.match()); err != nil {
+		return err
+	}
+	if !n.portal {
+		if err := addRule(n.conn, ruleOwner, ownerMatch()); err != nil {
+			removeRule(n.conn, rule, n.match())
+			return err
+		}
+	}
+	return nil
+}
+
+// removeMatch removes the match rules of n from its connection, unless
+// another Notifier on the connection still needs them.
+func (n *notifier) removeMatch() error {
+	rule := ruleSignals
+	if n.portal {
+		rule = rulePortal
+	}
+	err := removeRule(n.conn, rule, n.match())
+	if !n.portal {
+		if ownerErr := removeRule(n.conn, ruleOwner, ownerMatch()); err == nil {
+			err = ownerErr
+		}
+	}
+	return err
+}
+
+func addRule(conn busConn, rule string, options []dbus.MatchOption) error {
+	key := matchKey{conn, rule}
+	matches.lock.Lock()
+	defer matches.lock.Unlock()
+	if matches.refs[key] == 0 {
+		if err := conn.AddMatchSignal(options...); err != nil {
+			return err
+		}
+	}
+	if matches.refs == nil {
+		matches.refs = make(map[matchKey]int)
+	}
+	matches.refs[key]++
+	return nil
+}
+
+func removeRule(conn busConn, rule string, options []dbus.MatchOption) error {
+	key := matchKey{conn, rule}
+	matches.lock.Lock()
+	defer matches.lock.Unlock()
+	matches.refs[key]--
+	if matches.refs[key] > 0 {
+		return nil
+	}
+	delete(matches.refs, key)
+	return conn.RemoveMatchSignal(options...)
+}
+
+// signalMatch is the match rule for signals of the Notifications interface.
+// It is scoped to the notification server, as any connection may emit
+// signals on the interface. The ids the signals start with can't be
+// filtered on: the bus matches only string arguments.
+func signalMatch() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchSender(dbusNotificationsInterface),
+		dbus.WithMatchObjectPath(dbusObjectPath),
+		dbus.WithMatchInterface(dbusNotificationsInterface),
+	}
+}
+
+// match is the match rule for the signals the notifier listens to.
+func (n *notifier) match() []dbus.MatchOption {
+	if n.portal {
+		return portalMatch()
+	}
+	return signalMatch()
+}
+
+// wanted reports whether signal is one the notifier handles. The
+// connection delivers all the signals it receives to every channel, those
+// of other match rules of the application too, so signals of the
+// Notifications interface are only taken from the server, the connection
+// owning its name, and NameOwnerChanged only from the bus.
+func (n *notifier) wanted(signal *dbus.Signal) bool {
+	switch signal.Path {
+	case dbusObjectPath:
+		switch signal.Name {
+		case signalNotificationClosed, signalActionInvoked, signalActivationToken:
+			return n.fromServer(signal.Sender)
+		}
+	case portalObjectPath:
+		return signal.Name == portalSignalActionInvoked
+	case dbusPath:
+		return signal.Name == signalNameOwnerChanged && signal.Sender == dbusDestination
+	}
+	return false
+}
+
+// fromServer reports whether sender, a unique bus name, owns the name of
+// the notification server.
+func (n *notifier) fromServer(sender string) bool {
+	n.objLock.Lock()
+	defer n.objLock.Unlock()
+	return n.owner != "" && sender == n.owner
+}
+
+// setOwner records the unique bus name of the notification server, "" if
+// there is none.
+func (n *notifier) setOwner(owner string) {
+	n.objLock.Lock()
+	defer n.objLock.Unlock()
+	n.owner = owner
+}
+
+// resolveOwner asks the bus which connection owns the name of the
+// notification server. Signals arriving meanwhile are checked against the
+// owner the NameOwnerChanged signals report, which agrees with the answer.
+func (n *notifier) resolveOwner() {
+	ctx, cancel := context.WithTimeout(context.Background(), ownerTimeout)
+	defer cancel()
+	var owner string
+	err := n.conn.Object(dbusDestination, dbusPath).CallWithContext(ctx, dbusDestination+".GetNameOwner", 0, dbusNotificationsInterface).Store(&owner)
+	if err != nil {
+		// no server yet, NameOwnerChanged reports the one started.
+		return
+	}
+	n.setOwner(owner)
+}
@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestWantedSender(t *testing.T) {
+	n := newFakeNotifier(t)
+	n.setOwner(":1.10")
+	tests := []struct {
+		name   string
+		signal dbus.Signal
+		want   bool
+	}{
+		{"from server", dbus.Signal{Sender: ":1.10", Path: dbusObjectPath, Name: signalActionInvoked}, true},
+		{"from other connection", dbus.Signal{Sender: ":1.99", Path: dbusObjectPath, Name: signalActionInvoked}, false},
+		{"closed from other connection", dbus.Signal{Sender: ":1.99", Path: dbusObjectPath, Name: signalNotificationClosed}, false},
+		{"owner change from bus", dbus.Signal{Sender: dbusDestination, Path: dbusPath, Name: signalNameOwnerChanged}, true},
+		{"owner change from other connection", dbus.Signal{Sender: ":1.99", Path: dbusPath, Name: signalNameOwnerChanged}, false},
+	}
+	for _, tt := range tests {
+		if got := n.wanted(&tt.signal); got != tt.want {
+			t.Errorf("%v: wanted() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// a new server takes over the name.
+	n.ownerChanged(&dbus.Signal{Body: []interface{}{dbusNotificationsInterface, ":1.10", ":1.99"}})
+	if !n.wanted(&dbus.Signal{Sender: ":1.99", Path: dbusObjectPath, Name: signalActionInvoked}) {
+		t.Error("signal of the new server not wanted")
+	}
+	if n.wanted(&dbus.Signal{Sender: ":1.10", Path: dbusObjectPath, Name: signalActionInvoked}) {
+		t.Error("signal of the old server wanted")
+	}
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"log"
+	"sync"
+)
+
+// ActionMore is the key of the action showing the next actions of a Menu.
+const ActionMore = "notify-more"
+
+// DefaultMaxActions is how many actions a Menu shows at a time unless told
+// otherwise: most servers show three buttons and hide or squeeze the rest.
+const DefaultMaxActions = 3
+
+// Menu is a notification with more actions than the server comfortably
+// shows. The first ones are shown with a "More…" action, which re-sends the
+// notification as a follow-up with the next ones:
+//
+//	m, err := notify.ShowMenu(n, note, 0)
+//	...
+//	for signal := range n.ActionInvoked() {
+//		if m.HandleAction(signal) {
+//			continue // the next actions are shown
+//		}
+//		if m.Owns(signal.Id) {
+//			// signal.ActionKey is one of the actions of note
+//		}
+//	}
+//
+// The "default" action, invoked by clicking the notification, is kept on
+// every page and not counted.
+type Menu struct {
+	n       Notifier
+	note    Notification
+	max     int
+	actions []string // key, label pairs, without "default"
+
+	lock sync.Mutex
+	ids  []uint32 // of the pages shown
+	next int      // index in actions of the first pair of the next page
+}
+
+// ShowMenu sends note with n, showing at most max of its actions at a time.
+// If max is 0, it is the MaxActions of the profile of note's category if
+// n applies one, see WithProfiles, or DefaultMaxActions.
+func ShowMenu(n Notifier, note Notification, max int) (*Menu, error) {
+	if max <= 0 {
+		max = maxActions(n, note)
+	}
+	if max < 2 {
+		// room for one action and More.
+		max = 2
+	}
+	m := &Menu{n: n, note: note.Clone(), max: max}
+	m.note.Actions = nil
+	for i := 0; i+1 < len(note.Actions); i += 2 {
+		if note.Actions[i] == "default" {
+			m.note.Actions = append(m.note.Actions, note.Actions[i], note.Actions[i+1])
+			continue
+		}
+		m.actions = append(m.actions, note.Actions[i], note.Actions[i+1])
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err := m.showNext(0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// maxActions returns the MaxActions of the profile n applies to note, or
+// DefaultMaxActions.
+func maxActions(n Notifier, note Notification) int {
+	if c, ok := n.(*child); ok {
+		n = c.Notifier
+	}
+	if nn, ok := n.(*notifier); ok && nn.profiles != nil {
+		if profile, ok := nn.profiles.Lookup(hintString(note, HintCategory)); ok && profile.MaxActions > 0 {
+			return profile.MaxActions
+		}
+	}
+	return DefaultMaxActions
+}
+
+// showNext sends the page of actions starting at m.next, replacing the
+// notification with replaces. m.lock must be held.
+func (m *Menu) showNext(replaces uint32) error {
+	page := m.actions[m.next:]
+	more := len(page)/2 > m.max
+	if more {
+		page = page[:2*(m.max-1)]
+	}
+	note := m.note.Clone()
+	note.ReplacesID = replaces
+	note.Actions = append(note.Actions, page...)
+	if more {
+		note.Actions = append(note.Actions, ActionMore, "More…")
+	}
+	id, err := m.n.SendNotification(note)
+	if err != nil {
+		return err
+	}
+	m.ids = append(m.ids, id)
+	m.next += len(page)
+	return nil
+}
+
+// ID returns the ID of the page shown last.
+func (m *Menu) ID() uint32 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.ids[len(m.ids)-1]
+}
+
+// Owns reports whether id is that of one of the pages of m, so actions
+// invoked on it are actions of the menu.
+func (m *Menu) Owns(id uint32) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, own := range m.ids {
+		if own == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleAction shows the next actions if signal is the More action of the
+// page shown last, and reports whether it was. Call it from the loop
+// reading ActionInvoked.
+func (m *Menu) HandleAction(signal *ActionInvokedSignal) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	last := m.ids[len(m.ids)-1]
+	if signal.Id != last || signal.ActionKey != ActionMore || m.next >= len(m.actions) {
+		return false
+	}
+	if err := m.showNext(last); err != nil {
+		log.Printf("error sending more actions: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,237 @@
+// Package monitor observes the notifications of all applications on a bus,
+// like dbus-monitor, for notification history and logging tools:
+//
+//	conn, err := dbus.ConnectSessionBus() // a connection of its own
+//	...
+//	m, err := monitor.New(conn)
+//	...
+//	for e := range m.Events() {
+//		fmt.Println(e.Kind, e.ID, e.Notification.Summary)
+//	}
+//
+// It uses the BecomeMonitor method of the bus, which the session bus allows
+// to the user owning it. Notify calls are paired with their replies for the
+// IDs the server handed out.
+//
+// The app_name of a notification is whatever the application says. For its
+// identity as the bus knows it, pass another connection to
+// WithCredentials, and Notified events carry the Credentials of the sender.
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/internal/credentials"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsInterface = "org.freedesktop.Notifications"
+
+	// pendingTimeout is how long a Notify call waits for its reply; calls
+	// from applications that gave up are forgotten after it.
+	pendingTimeout = time.Minute
+)
+
+// Kind is a kind of Event.
+type Kind int
+
+const (
+	Notified      Kind = iota // an application sent a notification
+	Closed                    // the server closed one
+	ActionInvoked             // the user invoked an action
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Notified:
+		return "Notified"
+	case Closed:
+		return "Closed"
+	case ActionInvoked:
+		return "ActionInvoked"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a notification observed on the bus.
+type Event struct {
+	Kind Kind
+	Time time.Time
+	ID   uint32 // handed out by the server
+	// Sender is the unique bus name of the application, for Notified.
+	Sender string
+	// Credentials are those of the process behind Sender, for Notified
+	// with WithCredentials; nil if the bus couldn't tell.
+	Credentials *notify.Credentials
+	// Notification is what was sent, for Notified. ReplacesID is that of
+	// the call.
+	Notification notify.Notification
+	Reason       notify.Reason // for Closed
+	ActionKey    string        // for ActionInvoked
+}
+
+type callKey struct {
+	sender string
+	serial uint32
+}
+
+type pendingCall struct {
+	at    time.Time
+	note  notify.Notification
+	creds *notify.Credentials
+}
+
+// Monitor reports the notifications on a bus as Events.
+type Monitor struct {
+	conn     *dbus.Conn
+	messages chan *dbus.Message
+	events   chan Event
+	done     chan struct{}
+
+	closeOnce sync.Once
+	pending   map[callKey]pendingCall // Notify calls waiting for their reply
+	senders   *credentials.Cache      // see WithCredentials
+}
+
+// Option configures a Monitor created with New.
+type Option func(*Monitor)
+
+// WithCredentials looks up the Credentials of the senders of notifications
+// on bus, a connection to the same bus as the monitor that isn't one. They
+// are looked up when the Notify call is seen, so applications exiting right
+// after sending are usually still caught.
+func WithCredentials(bus *dbus.Conn) Option {
+	return func(m *Monitor) {
+		m.senders = credentials.New(bus)
+	}
+}
+
+// New makes conn a monitor connection and starts reporting events. conn
+// can't be used for anything else afterwards, and is closed by Close.
+func New(conn *dbus.Conn, opts ...Option) (*Monitor, error) {
+	rules := []string{
+		"type='method_call',interface='" + notificationsInterface + "',member='Notify'",
+		// replies carry no interface, they are matched to the calls.
+		"type='method_return'",
+		"type='error'",
+		"type='signal',interface='" + notificationsInterface + "'",
+	}
+	call := conn.BusObject().Call("org.freedesktop.DBus.Monitoring.BecomeMonitor", 0, rules, uint32(0))
+	if call.Err != nil {
+		return nil, call.Err
+	}
+	m := &Monitor{
+		conn:     conn,
+		messages: make(chan *dbus.Message, 64),
+		events:   make(chan Event, 64),
+		done:     make(chan struct{}),
+		pending:  make(map[callKey]pendingCall),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	conn.Eavesdrop(m.messages)
+	go m.loop()
+	return m, nil
+}
+
+// Events returns the channel of events, closed by Close.
+func (m *Monitor) Events() <-chan Event {
+	return m.events
+}
+
+// Close stops monitoring and closes the connection.
+func (m *Monitor) Close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		close(m.done)
+		err = m.conn.Close()
+	})
+	return err
+}
+
+func (m *Monitor) loop() {
+	defer close(m.events)
+	for {
+		select {
+		case msg := <-m.messages:
+			if e, ok := m.handle(msg, time.Now()); ok {
+				select {
+				case m.events <- e:
+				case <-m.done:
+					return
+				}
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// handle returns the event of msg, if it completes one.
+func (m *Monitor) handle(msg *dbus.Message, now time.Time) (Event, bool) {
+	member, _ := msg.Headers[dbus.FieldMember].Value().(string)
+	switch msg.Type {
+	case dbus.TypeMethodCall:
+		sender, _ := msg.Headers[dbus.FieldSender].Value().(string)
+		var note notify.Notification
+		err := dbus.Store(msg.Body, &note.AppName, &note.ReplacesID, &note.AppIcon, &note.Summary,
+			&note.Body, &note.Actions, &note.Hints, &note.ExpireTimeout)
+		if member != "Notify" || err != nil {
+			return Event{}, false
+		}
+		m.expire(now)
+		m.pending[callKey{sender, msg.Serial()}] = pendingCall{at: now, note: note, creds: m.credentialsOf(sender)}
+	case dbus.TypeMethodReply, dbus.TypeError:
+		dest, _ := msg.Headers[dbus.FieldDestination].Value().(string)
+		serial, _ := msg.Headers[dbus.FieldReplySerial].Value().(uint32)
+		key := callKey{dest, serial}
+		call, ok := m.pending[key]
+		if !ok {
+			return Event{}, false
+		}
+		delete(m.pending, key)
+		var id uint32
+		if msg.Type == dbus.TypeError || dbus.Store(msg.Body, &id) != nil {
+			return Event{}, false
+		}
+		return Event{Kind: Notified, Time: call.at, ID: id, Sender: dest, Credentials: call.creds, Notification: call.note}, true
+	case dbus.TypeSignal:
+		switch member {
+		case "NotificationClosed":
+			var id, reason uint32
+			if dbus.Store(msg.Body, &id, &reason) == nil {
+				return Event{Kind: Closed, Time: now, ID: id, Reason: notify.Reason(reason)}, true
+			}
+		case "ActionInvoked":
+			var id uint32
+			var key string
+			if dbus.Store(msg.Body, &id, &key) == nil {
+				return Event{Kind: ActionInvoked, Time: now, ID: id, ActionKey: key}, true
+			}
+		}
+	}
+	return Event{}, false
+}
+
+// expire forgets calls that got no reply in time.
+func (m *Monitor) expire(now time.Time) {
+	for key, call := range m.pending {
+		if now.Sub(call.at) > pendingTimeout {
+			delete(m.pending, key)
+		}
+	}
+}
+
+// credentialsOf returns the credentials of the process owning the bus name
+// sender, or nil without WithCredentials.
+func (m *Monitor) credentialsOf(sender string) *notify.Credentials {
+	if m.senders == nil {
+		return nil
+	}
+	return m.senders.Lookup(sender)
+}
@@ -0,0 +1,32 @@
+package notify
+
+import "time"
+
+// Hints of Nemo, the notification server of Sailfish OS. Not part of the
+// spec; other servers ignore them.
+const (
+	HintNemoPreviewSummary = "x-nemo-preview-summary" // summary of the popup banner, STRING
+	HintNemoPreviewBody    = "x-nemo-preview-body"    // body of the popup banner, STRING
+	HintNemoItemCount      = "x-nemo-item-count"      // number of items, e.g. unread messages, INT32
+	HintNemoTimestamp      = "x-nemo-timestamp"       // when the event happened, ISO 8601 STRING
+)
+
+// SetPreview sets the summary and body Nemo shows in the popup banner, which
+// may differ from those kept in the notification area. Without them Nemo
+// shows no banner.
+func (n *Notification) SetPreview(summary, body string) {
+	n.SetHint(HintNemoPreviewSummary, summary)
+	n.SetHint(HintNemoPreviewBody, body)
+}
+
+// SetItemCount sets the number of items, e.g. unread messages, the
+// notification stands for.
+func (n *Notification) SetItemCount(count int) {
+	n.SetHint(HintNemoItemCount, int32(count))
+}
+
+// SetTimestamp sets when the event the notification is about happened,
+// which Nemo shows and sorts by instead of the time it was sent.
+func (n *Notification) SetTimestamp(t time.Time) {
+	n.SetHint(HintNemoTimestamp, t.Format(time.RFC3339))
+}
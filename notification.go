@@ -1,20 +1,24 @@
 package notify
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"os"
+	"regexp"
+	"time"
 
-	"github.com/godbus/dbus"
+	"github.com/godbus/dbus/v5"
 	"sync"
 )
 
 const (
-	dbusRemoveMatch            = "org.freedesktop.DBus.RemoveMatch"
-	dbusAddMatch               = "org.freedesktop.DBus.AddMatch"
 	dbusObjectPath             = "/org/freedesktop/Notifications" // the DBUS object path
 	dbusNotificationsInterface = "org.freedesktop.Notifications"  // DBUS Interface
 	signalNotificationClosed   = "org.freedesktop.Notifications.NotificationClosed"
 	signalActionInvoked        = "org.freedesktop.Notifications.ActionInvoked"
+	signalActivationToken      = "org.freedesktop.Notifications.ActivationToken"
 	callGetCapabilities        = "org.freedesktop.Notifications.GetCapabilities"
 	callCloseNotification      = "org.freedesktop.Notifications.CloseNotification"
 	callNotify                 = "org.freedesktop.Notifications.Notify"
@@ -33,13 +37,23 @@ type Notification struct {
 	Actions       []string // tuples of (action_key, label), e.g.: []string{"cancel", "Cancel", "open", "Open"}
 	Hints         map[string]dbus.Variant
 	ExpireTimeout int32 // milliseconds to show notification
+
+	hintErr error // of the first HintMarshaler that failed in SetHint
 }
 
 // SendNotification is provided for convenience.
 // Use if you only want to deliver a notification and dont care about events.
 func SendNotification(conn *dbus.Conn, note Notification) (uint32, error) {
 	obj := conn.Object(dbusNotificationsInterface, dbusObjectPath)
-	call := obj.Call(callNotify, 0,
+	return sendNotification(context.Background(), obj, note)
+}
+
+// sendNotification calls Notify on obj.
+func sendNotification(ctx context.Context, obj dbus.BusObject, note Notification) (uint32, error) {
+	if note.hintErr != nil {
+		return 0, note.hintErr
+	}
+	call := obj.CallWithContext(ctx, callNotify, 0,
 		note.AppName,
 		note.ReplacesID,
 		note.AppIcon,
@@ -51,6 +65,12 @@ func SendNotification(conn *dbus.Conn, note Notification) (uint32, error) {
 	if call.Err != nil {
 		return 0, call.Err
 	}
+	// the reply is a single uint32, skip the reflection in Store.
+	if len(call.Body) == 1 {
+		if id, ok := call.Body[0].(uint32); ok {
+			return id, nil
+		}
+	}
 	var ret uint32
 	err := call.Store(&ret)
 	if err != nil {
@@ -84,10 +104,14 @@ type ServerInformation struct {
 //
 func GetServerInformation(conn *dbus.Conn) (ServerInformation, error) {
 	obj := conn.Object(dbusNotificationsInterface, dbusObjectPath)
+	return getServerInformation(context.Background(), obj)
+}
+
+func getServerInformation(ctx context.Context, obj dbus.BusObject) (ServerInformation, error) {
 	if obj == nil {
 		return ServerInformation{}, errors.New("error creating dbus call object")
 	}
-	call := obj.Call(callGetServerInformation, 0)
+	call := obj.CallWithContext(ctx, callGetServerInformation, 0)
 	if call.Err != nil {
 		log.Printf("Error calling %v: %v", callGetServerInformation, call.Err)
 		return ServerInformation{}, call.Err
@@ -110,7 +134,11 @@ func GetServerInformation(conn *dbus.Conn) (ServerInformation, error) {
 // GetCapabilities provide an exported method for this operation
 func GetCapabilities(conn *dbus.Conn) ([]string, error) {
 	obj := conn.Object(dbusNotificationsInterface, dbusObjectPath)
-	call := obj.Call(callGetCapabilities, 0)
+	return getCapabilities(context.Background(), obj)
+}
+
+func getCapabilities(ctx context.Context, obj dbus.BusObject) ([]string, error) {
+	call := obj.CallWithContext(ctx, callGetCapabilities, 0)
 	if call.Err != nil {
 		log.Printf("error calling GetCapabilities: %v", call.Err)
 		return []string{}, call.Err
@@ -141,59 +169,222 @@ type Notifier interface {
 	SendNotification(n Notification) (uint32, error)
 	GetCapabilities() ([]string, error)
 	GetServerInformation() (ServerInformation, error)
-	CloseNotification(id int) (bool, error)
+	CloseNotification(id uint32) (bool, error)
+	CloseNotifications(ids ...uint32) error
 	NotificationClosed() <-chan *NotificationClosedSignal
 	ActionInvoked() <-chan *ActionInvokedSignal
+	WithApp(appName, appIcon string) Notifier
+	Close() error
+}
+
+// busConn is the part of *dbus.Conn the notifier uses, so tests can run it
+// against a fake bus.
+type busConn interface {
+	Object(dest string, path dbus.ObjectPath) dbus.BusObject
+	AddMatchSignal(options ...dbus.MatchOption) error
+	RemoveMatchSignal(options ...dbus.MatchOption) error
+	Signal(ch chan<- *dbus.Signal)
+	RemoveSignal(ch chan<- *dbus.Signal)
 	Close() error
 }
 
 // notifier implements Notifier interface
 type notifier struct {
-	conn    *dbus.Conn
+	conn    busConn
+	release func() error // closes conn, see NewShared
+	objLock sync.Mutex
+	obj     dbus.BusObject // the notification server, see object()
+	owner   string         // unique name of the server, see wanted
 	signal  chan *dbus.Signal
-	closer  chan *NotificationClosedSignal
-	action  chan *ActionInvokedSignal
+	closer  *outbox[*NotificationClosedSignal]
+	action  *outbox[*ActionInvokedSignal]
 	done    chan bool
 	running sync.Mutex
+
+	closing      chan struct{}  // closed by Close
+	closingLock  sync.Mutex     // orders closing against handlers.Add, see addHandler
+	handlers     sync.WaitGroup // signals being handled, see Close
+	keepConn     bool           // see WithoutClosingConn
+	flushOnClose bool           // see WithFlushOnClose
+	sends        sync.RWMutex   // read locked by sends in progress, see Flush
+	buffering    Buffering      // see WithBuffering
+	clock        Clock          // see WithClock
+
+	tokensLock sync.Mutex
+	tokens     map[uint32]string // pending activation tokens by notification id
+
+	senderPID bool         // set the sender-pid hint on sent notifications
+	pid       dbus.Variant // value of the sender-pid hint
+	trace     *log.Logger  // logs calls and signals if set
+
+	address string       // bus to connect to, see WithBusAddress
+	retry   *RetryPolicy // retries of SendNotification, see WithRetry
+
+	defaults  *Notification // see WithDefaults
+	detectApp bool          // fill in AppName and AppIcon, see DetectApp
+	app       AppIdentity   // what DetectApp found, when New ran
+
+	minUrgency     Urgency // drop less urgent notifications, see WithEnvironment
+	defaultTimeout *int32  // replaces an ExpireTimeout of -1 if set
+	policy         *Policy // see WithPolicy
+
+	redact []*regexp.Regexp // masked in summary and body, see WithRedaction
+
+	profiles *Profiles           // see WithProfiles
+	backends map[string]Notifier // by name, for profiles
+	sounds   *sounds             // see WithCategorySounds
+
+	receipts  *receipts  // see WithDeliveryReceipts
+	lifecycle *lifecycle // see WithRestartDetection
+	spool     *spool     // see WithPersistentOutbox
+	storms    *storms    // see WithStormGuard
+
+	maxSize    int        // see WithMaxMessageSize
+	sizeAction SizeAction // what to do with larger notifications
+
+	breaker  *breaker // see WithCircuitBreaker
+	fallback Notifier // used while the circuit is open
+
+	staged      bool // an option changes what is sent, see plain
+	simpleOnce  sync.Once
+	simpleHints map[string]dbus.Variant // see sharedHints
+
+	dryRun       bool   // never call the server, see WithDryRun
+	lastDryRunID uint32 // accessed atomically
+
+	portal       bool   // use the notification portal, see DetectSandbox
+	appID        string // desktop-entry hint of a sandboxed application
+	lastPortalID uint32 // accessed atomically
+
+	quiet      *QuietHours
+	quietLock  sync.Mutex
+	quietQueue []Notification
+	quietTimer Alarm
+	dndOn      bool      // do not disturb mode as last asked, see QuietHours
+	dndAt      time.Time // when it was asked
+	dndAsking  bool      // a goroutine is asking, see doNotDisturb
 }
 
-// New creates a new Notifier using conn.
-// See also: Notifier
-func New(conn *dbus.Conn) (Notifier, error) {
-	n := &notifier{
-		conn:    conn,
-		signal:  make(chan *dbus.Signal, channelBufferSize),
-		closer:  make(chan *NotificationClosedSignal, channelBufferSize),
-		action:  make(chan *ActionInvokedSignal, channelBufferSize),
-		done:    make(chan bool),
-		running: sync.Mutex{},
+// New creates a new Notifier using conn, configured by opts.
+//
+// By default the Notifier sets the sender-pid hint to the pid of the current
+// process on every notification that doesn't already carry it.
+// Empty AppName and AppIcon are filled in from DetectApp (see
+// WithoutAppDetection).
+// Inside a Flatpak or Snap sandbox it also sets the desktop-entry hint to the
+// application ID, and sends through the notification portal (see
+// WithoutPortal).
+// conn may be nil if WithBusAddress or WithDryRun is given.
+// See also: Notifier, Option
+func New(conn *dbus.Conn, opts ...Option) (Notifier, error) {
+	var bus busConn
+	// don't wrap a nil pointer in a non-nil busConn.
+	if conn != nil {
+		bus = conn
 	}
+	n, err := newNotifier(bus, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
 
-	// add a listener in dbus for signals to Notification interface.
-	call := n.conn.BusObject().Call(dbusAddMatch, 0,
-		"type='signal',path='"+dbusObjectPath+"',interface='"+dbusNotificationsInterface+"'")
-	if call.Err != nil {
-		return nil, call.Err
+func newNotifier(conn busConn, opts ...Option) (*notifier, error) {
+	n := &notifier{
+		conn:      conn,
+		done:      make(chan bool),
+		closing:   make(chan struct{}),
+		clock:     SystemClock,
+		running:   sync.Mutex{},
+		tokens:    make(map[uint32]string),
+		senderPID: true,
+		detectApp: true,
+		pid:       dbus.MakeVariant(int64(os.Getpid())),
+		portal:    DetectSandbox() != SandboxNone,
+		appID:     SandboxAppID(),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if n.detectApp {
+		n.app = DetectApp()
+	}
+	signals := n.buffering.Signals
+	if signals < 1 {
+		signals = channelBufferSize
+	}
+	n.signal = make(chan *dbus.Signal, signals)
+	n.closer = newOutbox(n.buffering.Events, n.buffering.Overflow, func(s *NotificationClosedSignal) uint32 { return s.Id })
+	n.action = newOutbox(n.buffering.Events, n.buffering.Overflow, func(s *ActionInvokedSignal) uint32 { return s.Id })
+	if n.address != "" && !n.dryRun {
+		if n.conn != nil {
+			return nil, errors.New("notify: both a connection and a bus address given")
+		}
+		conn, err := dbus.Connect(n.address)
+		if err != nil {
+			return nil, err
+		}
+		n.conn = conn
 	}
 
 	// start eventloop
 	go n.eventLoop()
+	if n.dryRun {
+		return n, nil
+	}
+
+	// add a listener in dbus for signals to Notification interface.
+	if err := n.addMatch(); err != nil {
+		n.done <- true
+		if n.address != "" {
+			n.conn.Close()
+		}
+		return nil, err
+	}
 
 	// register in dbus for signal delivery
 	n.conn.Signal(n.signal)
+	if !n.portal {
+		n.resolveOwner()
+	}
 
+	n.replaySpool()
+	if n.quiet != nil {
+		// have the do not disturb mode known by the first send.
+		n.doNotDisturb()
+	}
 	return n, nil
 }
 
-func (n notifier) eventLoop() {
+func (n *notifier) eventLoop() {
 	n.running.Lock()
 	defer n.running.Unlock()
 	received := 0
 	for {
 		select {
 		case signal := <-n.signal:
+			if !n.wanted(signal) {
+				continue
+			}
 			received += 1
 			log.Printf("got signal: %v Signal: %+v", received, signal)
+			n.traceSignal(signal)
+			// ActivationToken is emitted right before ActionInvoked,
+			// store it here so it is in place when the action is handled.
+			if signal.Name == signalActivationToken {
+				n.storeActivationToken(signal)
+				continue
+			}
+			if signal.Name == signalNameOwnerChanged {
+				n.ownerChanged(signal)
+				continue
+			}
+			n.handlers.Add(1)
+			if n.buffering.Overflow != Block {
+				// nothing blocks, keep the order of the signals.
+				n.handleSignal(signal)
+				continue
+			}
 			go n.handleSignal(signal)
 		// its all over, exit and go home
 		case <-n.done:
@@ -204,28 +395,149 @@ func (n notifier) eventLoop() {
 }
 
 // signal handler that translates and sends notifications to channels
-func (n notifier) handleSignal(signal *dbus.Signal) {
+func (n *notifier) handleSignal(signal *dbus.Signal) {
+	defer n.handlers.Done()
+	// any connection can emit signals on the interface, so the body is
+	// checked with Store rather than trusted.
 	switch signal.Name {
 	case signalNotificationClosed:
-		n.closer <- &NotificationClosedSignal{
-			Id:     signal.Body[0].(uint32),
-			Reason: Reason(signal.Body[1].(uint32)),
+		var id, reason uint32
+		if err := dbus.Store(signal.Body, &id, &reason); err != nil {
+			log.Printf("malformed signal: %+v: %v", signal, err)
+			return
+		}
+		n.takeActivationToken(id)
+		n.observeClosed(id)
+		closed := &NotificationClosedSignal{
+			Id:     id,
+			Reason: Reason(reason),
 		}
+		if n.receipts != nil {
+			closed.Dropped = n.receipts.dropped(id, closed.Reason, n.clock.Now())
+		}
+		n.closer.put(closed, n.closing)
 	case signalActionInvoked:
-		n.action <- &ActionInvokedSignal{
-			Id:        signal.Body[0].(uint32),
-			ActionKey: signal.Body[1].(string),
+		var id uint32
+		var key string
+		if err := dbus.Store(signal.Body, &id, &key); err != nil {
+			log.Printf("malformed signal: %+v: %v", signal, err)
+			return
 		}
+		action := &ActionInvokedSignal{
+			Id:              id,
+			ActionKey:       key,
+			ActivationToken: n.takeActivationToken(id),
+		}
+		n.action.put(action, n.closing)
+	case portalSignalActionInvoked:
+		action := portalActionInvoked(signal)
+		if action == nil {
+			log.Printf("malformed signal: %+v", signal)
+			return
+		}
+		n.action.put(action, n.closing)
 	default:
 		log.Printf("unknown signal: %+v", signal)
 	}
 }
 
+// addHandler adds a handler of signals for Close to wait for, unless n is
+// closing. For handlers started outside the event loop, which Close stops
+// before it waits.
+func (n *notifier) addHandler() bool {
+	n.closingLock.Lock()
+	defer n.closingLock.Unlock()
+	select {
+	case <-n.closing:
+		return false
+	default:
+	}
+	n.handlers.Add(1)
+	return true
+}
+
+// storeActivationToken remembers the token from an ActivationToken signal
+// until the matching ActionInvoked signal arrives.
+func (n *notifier) storeActivationToken(signal *dbus.Signal) {
+	var id uint32
+	var token string
+	if err := dbus.Store(signal.Body, &id, &token); err != nil {
+		log.Printf("malformed signal: %+v: %v", signal, err)
+		return
+	}
+	n.tokensLock.Lock()
+	n.tokens[id] = token
+	n.tokensLock.Unlock()
+}
+
+// takeActivationToken returns and forgets the pending token for id, if any.
+func (n *notifier) takeActivationToken(id uint32) string {
+	n.tokensLock.Lock()
+	defer n.tokensLock.Unlock()
+	token := n.tokens[id]
+	delete(n.tokens, id)
+	return token
+}
+
+// object returns the notification server object, creating it on first use
+// and again after resetObject.
+func (n *notifier) object() dbus.BusObject {
+	n.objLock.Lock()
+	defer n.objLock.Unlock()
+	if n.obj == nil {
+		n.obj = n.conn.Object(dbusNotificationsInterface, dbusObjectPath)
+	}
+	return n.obj
+}
+
+// resetObject drops the notification server object, for the next call to
+// create it again: the server changed, or a call found it gone.
+func (n *notifier) resetObject() {
+	n.objLock.Lock()
+	defer n.objLock.Unlock()
+	n.obj = nil
+}
+
+// callFailed resets the server object if err says the server is gone.
+func (n *notifier) callFailed(err error) {
+	var e dbus.Error
+	if errors.Is(err, dbus.ErrClosed) || errors.As(err, &e) && serverGone[e.Name] {
+		n.resetObject()
+	}
+}
+
+// serverGone are the errors of calls to a server that left the bus.
+var serverGone = map[string]bool{
+	"org.freedesktop.DBus.Error.ServiceUnknown": true,
+	"org.freedesktop.DBus.Error.NameHasNoOwner": true,
+	"org.freedesktop.DBus.Error.NoReply":        true,
+	"org.freedesktop.DBus.Error.Disconnected":   true,
+}
+
 func (n *notifier) GetCapabilities() ([]string, error) {
-	return GetCapabilities(n.conn)
+	if n.dryRun {
+		return []string{}, nil
+	}
+	if n.portal {
+		// the portal has no way to ask, these are what it always supports.
+		return []string{"actions", "body"}, nil
+	}
+	n.tracef("call %v", callGetCapabilities)
+	caps, err := getCapabilities(context.Background(), n.object())
+	n.tracef("reply %v capabilities=%q err=%v", callGetCapabilities, caps, err)
+	return caps, err
 }
 func (n *notifier) GetServerInformation() (ServerInformation, error) {
-	return GetServerInformation(n.conn)
+	if n.dryRun {
+		return ServerInformation{}, nil
+	}
+	if n.portal {
+		return ServerInformation{Name: "xdg-desktop-portal", Vendor: "freedesktop.org"}, nil
+	}
+	n.tracef("call %v", callGetServerInformation)
+	info, err := getServerInformation(context.Background(), n.object())
+	n.tracef("reply %v info=%+v err=%v", callGetServerInformation, info, err)
+	return info, err
 }
 
 
@@ -255,7 +567,91 @@ func (n *notifier) GetServerInformation() (ServerInformation, error) {
 // If replaces_id is 0, the return value is a UINT32 that represent the notification. It is unique, and will not be reused unless a MAXINT number of notifications have been generated. An acceptable implementation may just use an incrementing counter for the ID. The returned ID is always greater than zero. Servers must make sure not to return zero as an ID.
 // If replaces_id is not 0, the returned value is the same value as replaces_id.
 func (n *notifier) SendNotification(note Notification) (uint32, error) {
-	return SendNotification(n.conn, note)
+	if note.hintErr != nil {
+		return 0, note.hintErr
+	}
+	n.sends.RLock()
+	defer n.sends.RUnlock()
+	if n.defaults != nil {
+		note = withDefaults(note, *n.defaults)
+	}
+	note = n.redacted(note)
+	if n.policy != nil && !n.policy.Allows(note) {
+		n.tracef("dropping notification muted by policy")
+		return 0, nil
+	}
+	note, backend := n.applyProfile(note)
+	if urgency(note) < n.minUrgency {
+		n.tracef("dropping notification below urgency %v", n.minUrgency)
+		return 0, nil
+	}
+	if backend != nil {
+		return backend.SendNotification(note)
+	}
+	note = n.categorySound(note)
+	if n.defaultTimeout != nil && note.ExpireTimeout == -1 {
+		note.ExpireTimeout = *n.defaultTimeout
+	}
+	if n.detectApp {
+		if note.AppName == "" {
+			note.AppName = n.app.Name
+		}
+		if note.AppIcon == "" {
+			note.AppIcon = n.app.Icon
+		}
+	}
+	if n.senderPID {
+		note = withDefaultHint(note, HintSenderPID, n.pid)
+	}
+	if n.appID != "" {
+		note = withDefaultHint(note, HintDesktopEntry, dbus.MakeVariant(n.appID))
+	}
+	if id, collapsed, err := n.collapse(note); collapsed {
+		return id, err
+	}
+	return n.deliver(note)
+}
+
+// deliver sends note, unless quiet hours hold it back.
+func (n *notifier) deliver(note Notification) (uint32, error) {
+	if n.holdBack(note) {
+		return 0, nil
+	}
+	return n.sendJournaled(note)
+}
+
+// send delivers note to the server, without applying any of the
+// notifier's policies.
+func (n *notifier) send(note Notification) (uint32, error) {
+	note, err := n.fitSize(note)
+	if err != nil {
+		return 0, err
+	}
+	n.traceNotify(note)
+	if n.dryRun {
+		id := n.dryRunID(note)
+		n.tracef("dry-run %v id=%v", callNotify, id)
+		return id, nil
+	}
+	return n.withBreaker(note, func() (uint32, error) {
+		return n.withRetry(func() (uint32, error) {
+			if n.portal {
+				id, err := n.portalSend(context.Background(), note)
+				n.tracef("reply %v id=%v err=%v", portalAddNotification, id, err)
+				return id, err
+			}
+			id, err := sendNotification(context.Background(), n.object(), note)
+			n.tracef("reply %v id=%v err=%v", callNotify, id, err)
+			n.callFailed(err)
+			if err == nil && n.receipts != nil {
+				n.receipts.record(id, note, n.clock.Now())
+			}
+			if err == nil {
+				n.observeID(id, note.ReplacesID)
+			}
+			return id, err
+		})
+	})
 }
 
 // CloseNotification causes a notification to be forcefully closed and removed from the user's view.
@@ -264,18 +660,75 @@ func (n *notifier) SendNotification(note Notification) (uint32, error) {
 //
 // The NotificationClosed (dbus) signal is emitted by this method.
 // If the notification no longer exists, an empty D-BUS Error message is sent back.
-func (n *notifier) CloseNotification(id int) (bool, error) {
-	obj := n.conn.Object(dbusNotificationsInterface, dbusObjectPath)
-	call := obj.Call(callCloseNotification, 0, uint32(id))
+func (n *notifier) CloseNotification(id uint32) (bool, error) {
+	n.tracef("call %v id=%v", callCloseNotification, id)
+	if n.receipts != nil {
+		n.receipts.closing(id)
+	}
+	if n.dryRun {
+		n.emitClosedByCall(id)
+		return true, nil
+	}
+	if n.portal {
+		err := n.portalClose(context.Background(), id)
+		n.tracef("reply %v err=%v", portalRemoveNotification, err)
+		if err != nil {
+			return false, err
+		}
+		// the portal has no NotificationClosed signal.
+		n.emitClosedByCall(id)
+		return true, nil
+	}
+	call := n.object().CallWithContext(context.Background(), callCloseNotification, 0, id)
+	n.tracef("reply %v err=%v", callCloseNotification, call.Err)
 	if call.Err != nil {
+		n.callFailed(call.Err)
 		return false, call.Err
 	}
 	return true, nil
 }
 
+// CloseNotifications closes all notifications in ids. The calls are sent
+// without waiting for each reply in turn, so closing many notifications
+// costs about one round trip to the server.
+// All notifications are attempted; the error is that of the first one that
+// failed to close.
+func (n *notifier) CloseNotifications(ids ...uint32) error {
+	if n.dryRun || n.portal {
+		var first error
+		for _, id := range ids {
+			if _, err := n.CloseNotification(id); err != nil && first == nil {
+				first = fmt.Errorf("closing %v: %w", id, err)
+			}
+		}
+		return first
+	}
+	obj := n.object()
+	calls := make([]*dbus.Call, len(ids))
+	for i, id := range ids {
+		n.tracef("call %v id=%v", callCloseNotification, id)
+		if n.receipts != nil {
+			n.receipts.closing(id)
+		}
+		calls[i] = obj.GoWithContext(context.Background(), callCloseNotification, 0, make(chan *dbus.Call, 1), id)
+	}
+	var first error
+	for i, call := range calls {
+		<-call.Done
+		n.tracef("reply %v id=%v err=%v", callCloseNotification, ids[i], call.Err)
+		if call.Err != nil && first == nil {
+			first = fmt.Errorf("closing %v: %w", ids[i], call.Err)
+		}
+	}
+	return first
+}
+
 type NotificationClosedSignal struct {
 	Id     uint32
 	Reason Reason
+	// Dropped is set if the daemon likely never showed the notification,
+	// see WithDeliveryReceipts.
+	Dropped bool
 }
 // From the Gnome developer spec:
 // 1 - The notification expired.
@@ -297,22 +750,34 @@ func (r Reason) String() string {
 		return "ClosedByCall"
 	case ReasonUnknown:
 		return "Unknown"
+	case ReasonServerRestarted:
+		return "ServerRestarted"
 	default:
 		return "Other"
 	}
 }
 
+// WithApp returns a child Notifier stamping appName and appIcon on what it
+// sends, sharing this Notifier's connection. See NewChild.
+func (n *notifier) WithApp(appName, appIcon string) Notifier {
+	return NewChild(n, appName, appIcon)
+}
+
 // NotificationClosed returns a receive only channel that sends
 // NotificationClosedSignal for signals.
 //
 // Must be consumed because event delivery will stall.
 func (n *notifier) NotificationClosed() <-chan *NotificationClosedSignal {
-	return n.closer
+	return n.closer.ch
 }
 
 type ActionInvokedSignal struct {
 	Id        uint32
 	ActionKey string
+	// ActivationToken is the xdg-activation token sent by the server along
+	// with the action, if any. Pass it on (e.g. in XDG_ACTIVATION_TOKEN) so
+	// a window raised in response to the action gets focus on Wayland.
+	ActivationToken string
 }
 
 // ActionInvoked returns a receive only channel that sends
@@ -320,21 +785,40 @@ type ActionInvokedSignal struct {
 //
 // Must be consumed.
 func (n *notifier) ActionInvoked() <-chan *ActionInvokedSignal {
-	return n.action
+	return n.action.ch
 }
 
-// Close cleans up and shuts down signal delivery loop
+// Close cleans up and shuts down signal delivery loop: it removes the match
+// rule and signal channel from the connection, waits for the signals being
+// handled, closes the signal channels and, unless WithoutClosingConn is
+// given, the connection. Notifications held back by quiet hours are
+// discarded, or sent with WithFlushOnClose.
 func (n *notifier) Close() error {
 	log.Printf("closing!")
+	n.stopQuiet(n.flushOnClose)
+	n.stopStorms()
 	n.done <- true
-	n.conn.BusObject().Call(dbusRemoveMatch, 0,
-		"type='signal',path='"+dbusObjectPath+"',interface='"+dbusNotificationsInterface+"'")
+	if !n.dryRun {
+		n.removeMatch()
 
-	// remove signal reception
-	defer n.conn.Signal(n.signal)
-	close(n.closer)
-	close(n.action)
+		// remove signal reception
+		n.conn.RemoveSignal(n.signal)
+	}
+	// handlers blocked on channels nobody reads any more give up.
+	n.closingLock.Lock()
+	close(n.closing)
+	n.closingLock.Unlock()
+	n.handlers.Wait()
+	close(n.closer.ch)
+	close(n.action.ch)
 	close(n.done)
+	if n.release != nil {
+		return n.release()
+	}
+	if n.conn == nil || n.keepConn {
+		// dry-run without a connection, or the caller's
+		return nil
+	}
 	err := n.conn.Close()
 	return err
 }
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// BenchmarkSendNotification measures the allocations of SendNotification
+// for a notification with hints, and for the progress updates of a
+// long-running task, which replace one notification over and over.
+func BenchmarkSendNotification(b *testing.B) {
+	n := newFakeNotifier(b)
+	b.Run("Hints", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			note := Notification{
+				Summary: "New message",
+				Body:    "See you at eight?",
+				Hints: map[string]dbus.Variant{
+					HintUrgency:  dbus.MakeVariant(byte(UrgencyNormal)),
+					HintCategory: dbus.MakeVariant("im.received"),
+				},
+				ExpireTimeout: -1,
+			}
+			if _, err := n.SendNotification(note); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Progress", func(b *testing.B) {
+		b.ReportAllocs()
+		note := Notification{Summary: "Copying files", ExpireTimeout: -1}
+		id, err := n.SendNotification(note)
+		if err != nil {
+			b.Fatal(err)
+		}
+		note.ReplacesID = id
+		for i := 0; i < b.N; i++ {
+			note.SetProgress(i % 101)
+			if _, err := n.SendNotification(note); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package notifyhttp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// SignatureHeader is the header carrying the HMAC of the body of a request,
+// "sha256=" and the hex HMAC-SHA256 of the body under Auth.HMACKey, as
+// GitHub, Gitea and others sign their webhooks.
+const SignatureHeader = "X-Hub-Signature-256"
+
+// maxSignedBody is the most of a signed body read to check its signature.
+const maxSignedBody = 1 << 20
+
+// Auth says who may use a handler, see Protect.
+type Auth struct {
+	// Tokens are accepted in an "Authorization: Bearer <token>" header.
+	// Empty tokens are never accepted, e.g. from an unset variable.
+	Tokens []string
+	// HMACKey, if set, has requests signed in SignatureHeader accepted.
+	// The signature covers the body only, so a recorded request can be
+	// replayed.
+	HMACKey []byte
+	// Allow are the networks requests may come from; any if empty.
+	Allow []netip.Prefix
+	// Rate is how many requests per second one client address may make,
+	// with bursts of up to Burst; unlimited if 0.
+	Rate  float64
+	Burst int
+	// Clock refills the buckets of the rate limit, notify.SystemClock if
+	// nil.
+	Clock notify.Clock
+}
+
+// Protect returns h guarded by auth: requests from addresses outside
+// auth.Allow are refused with 403, those over the rate limit with 429, and,
+// if auth has tokens or an HMAC key, those without a valid one of them with
+// 401.
+//
+// Clients are told apart by the address of the connection. Behind a reverse
+// proxy they all share its address, so put the limits there instead.
+//
+// Empty tokens and an empty HMAC key are dropped, but still ask for
+// authentication: with nothing else left, all requests are refused.
+func Protect(h http.Handler, auth Auth) http.Handler {
+	if auth.Burst < 1 {
+		auth.Burst = 1
+	}
+	if auth.Clock == nil {
+		auth.Clock = notify.SystemClock
+	}
+	required := len(auth.Tokens) > 0 || auth.HMACKey != nil
+	var tokens []string
+	for _, t := range auth.Tokens {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	auth.Tokens = tokens
+	if len(auth.HMACKey) == 0 {
+		auth.HMACKey = nil
+	}
+	return &guard{
+		next:     h,
+		auth:     auth,
+		required: required,
+		buckets:  make(map[netip.Addr]*bucket),
+	}
+}
+
+type guard struct {
+	next     http.Handler
+	auth     Auth
+	required bool // auth had tokens or an HMAC key, even if empty
+
+	lock    sync.Mutex
+	buckets map[netip.Addr]*bucket
+	swept   time.Time
+}
+
+// bucket is the token bucket of one client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (g *guard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	addr, err := remoteAddr(r)
+	if err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	if !g.allowed(addr) {
+		writeError(w, http.StatusForbidden, errors.New("address not allowed"))
+		return
+	}
+	if wait := g.take(addr, g.auth.Clock.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+		return
+	}
+	if err := g.authenticate(r); err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	g.next.ServeHTTP(w, r)
+}
+
+func remoteAddr(r *http.Request) (netip.Addr, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, errors.New("unknown client address")
+	}
+	return addr.Unmap(), nil
+}
+
+func (g *guard) allowed(addr netip.Addr) bool {
+	if len(g.auth.Allow) == 0 {
+		return true
+	}
+	for _, p := range g.auth.Allow {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// take takes a token from the bucket of addr, or returns how long until
+// there is one.
+func (g *guard) take(addr netip.Addr, now time.Time) time.Duration {
+	if g.auth.Rate <= 0 {
+		return 0
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.sweep(now)
+	b, ok := g.buckets[addr]
+	if !ok {
+		b = &bucket{tokens: float64(g.auth.Burst), last: now}
+		g.buckets[addr] = b
+	}
+	b.tokens = g.refill(b, now)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / g.auth.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+func (g *guard) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*g.auth.Rate
+	return math.Min(tokens, float64(g.auth.Burst))
+}
+
+// sweep forgets clients whose buckets filled up again, at most once a
+// minute. g.lock must be held.
+func (g *guard) sweep(now time.Time) {
+	if now.Sub(g.swept) < time.Minute {
+		return
+	}
+	g.swept = now
+	for addr, b := range g.buckets {
+		if g.refill(b, now) >= float64(g.auth.Burst) {
+			delete(g.buckets, addr)
+		}
+	}
+}
+
+func (g *guard) authenticate(r *http.Request) error {
+	if !g.required {
+		return nil
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		if token == "" {
+			return errors.New("invalid token")
+		}
+		for _, t := range g.auth.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+				return nil
+			}
+		}
+		return errors.New("invalid token")
+	}
+	if signature := r.Header.Get(SignatureHeader); signature != "" && g.auth.HMACKey != nil {
+		return g.verify(r, signature)
+	}
+	return errors.New("authentication required")
+}
+
+// verify checks the signature of the body of r, and leaves the body to be
+// read again.
+func (g *guard) verify(r *http.Request, signature string) error {
+	want, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return errors.New("invalid signature")
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxSignedBody {
+		return errors.New("signed body too large")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	mac := hmac.New(sha256.New, g.auth.HMACKey)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), want) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
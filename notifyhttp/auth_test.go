@@ -0,0 +1,39 @@
+package notifyhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProtectEmptyToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	tests := []struct {
+		name   string
+		auth   Auth
+		header string
+		want   int
+	}{
+		{"empty token configured", Auth{Tokens: []string{""}}, "Bearer ", http.StatusUnauthorized},
+		{"empty token configured, none sent", Auth{Tokens: []string{""}}, "", http.StatusUnauthorized},
+		{"empty token sent", Auth{Tokens: []string{"", "secret"}}, "Bearer ", http.StatusUnauthorized},
+		{"valid token", Auth{Tokens: []string{"", "secret"}}, "Bearer secret", http.StatusNoContent},
+		{"empty HMAC key", Auth{HMACKey: []byte{}}, "", http.StatusUnauthorized},
+		{"no auth", Auth{}, "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/notify", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			Protect(ok, tt.auth).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %v, want %v", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Package notifyhttp exposes a notify.Notifier over HTTP with JSON bodies, for
+shell scripts and web dashboards:
+
+	POST /notify        send the notification in the body, in the JSON form of
+	                    notify.Notification; replies {"id": ID}
+	POST /close/{id}    close a notification
+	GET  /capabilities  replies {"capabilities": [...]}
+	GET  /events        Server-Sent Events: "closed" events with {"id", "reason"}
+	                    and "action" events with {"id", "action_key",
+	                    "activation_token"}
+
+For example:
+
+	curl -d '{"summary": "Build done"}' localhost:8080/notify
+
+Errors reply with a non-2xx status and {"error": "message"}.
+
+With NewSealedHandler notifications, their replies, closes and events travel
+sealed with a pre-shared key, see package seal, for webhooks going through
+services that shouldn't read them.
+
+The handler does no authentication; anyone who can reach it can show
+notifications on the desktop. Listen on localhost, or wrap it with Protect
+to require a bearer token or a signed body, limit the rate of each client
+and the networks they may call from:
+
+	h := notifyhttp.Protect(notifyhttp.NewHandler(n), notifyhttp.Auth{
+		Tokens: []string{os.Getenv("NOTIFY_TOKEN")},
+		Allow:  []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")},
+		Rate:   1,
+		Burst:  10,
+	})
+
+and call it with
+
+	curl -H "Authorization: Bearer $NOTIFY_TOKEN" -d '{"summary": "Build done"}' host:8080/notify
+*/
+package notifyhttp
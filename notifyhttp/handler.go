@@ -0,0 +1,243 @@
+package notifyhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/internal/fanout"
+	"github.com/esiqveland/notify/seal"
+)
+
+// eventBufferSize is how many events a slow /events client may fall behind
+// before events for it are dropped.
+const eventBufferSize = 16
+
+type handler struct {
+	n      notify.Notifier
+	events *fanout.Fanout
+	key    *seal.Key // of sealed notifications, see NewSealedHandler
+}
+
+// sealed is a body sealed with a pre-shared key.
+type sealed struct {
+	Sealed []byte `json:"sealed"`
+}
+
+// NewHandler returns an http.Handler serving the API described in the
+// package documentation using n. The handler consumes the signal channels of
+// n and hands the signals to /events clients.
+func NewHandler(n notify.Notifier) http.Handler {
+	return newHandler(n, nil)
+}
+
+// NewSealedHandler is NewHandler for senders sealing their notifications
+// with key, see package seal: POST /notify takes {"sealed": "<base64 box>"}
+// holding the JSON form of the notification, refuses anything else, and
+// seals its reply the same way. Notifications are closed with POST /close
+// and a sealed {"id": ID}, instead of the ID in the path. /events sends
+// "sealed" events only, with {"sealed": "<base64 box>"} holding
+// {"event": "closed" or "action", "data": {...}}. The content of
+// notifications, their IDs and what the user did with them are then hidden
+// from proxies and webhook services in between.
+//
+// What stays in clear: /capabilities, status codes and error messages,
+// which endpoint is called, and the sizes and times of requests and events.
+func NewSealedHandler(n notify.Notifier, key seal.Key) http.Handler {
+	return newHandler(n, &key)
+}
+
+func newHandler(n notify.Notifier, key *seal.Key) http.Handler {
+	h := &handler{
+		n:      n,
+		events: fanout.New(n, eventBufferSize),
+		key:    key,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /notify", h.notify)
+	mux.HandleFunc("POST /close/{id}", h.close)
+	mux.HandleFunc("POST /close", h.closeSealed)
+	mux.HandleFunc("GET /capabilities", h.capabilities)
+	mux.HandleFunc("GET /events", h.serveEvents)
+	return mux
+}
+
+func (h *handler) notify(w http.ResponseWriter, r *http.Request) {
+	var note notify.Notification
+	if err := h.decode(r, &note); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := h.n.SendNotification(note)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	reply := map[string]uint32{"id": id}
+	if h.key == nil {
+		writeJSON(w, http.StatusOK, reply)
+		return
+	}
+	box, err := h.seal(reply)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, box)
+}
+
+// seal returns the JSON form of v sealed with the key of the handler.
+func (h *handler) seal(v interface{}) (sealed, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sealed{}, err
+	}
+	box, err := h.key.Seal(data)
+	return sealed{Sealed: box}, err
+}
+
+// decode reads the JSON body of r into v, opening it first if the handler
+// takes sealed bodies.
+func (h *handler) decode(r *http.Request, v interface{}) error {
+	if h.key == nil {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+	var s sealed
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		return err
+	}
+	if s.Sealed == nil {
+		return errors.New("body is not sealed")
+	}
+	data, err := h.key.Open(s.Sealed)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (h *handler) close(w http.ResponseWriter, r *http.Request) {
+	if h.key != nil {
+		writeError(w, http.StatusBadRequest, errors.New("id is not sealed, use POST /close"))
+		return
+	}
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	h.closeID(w, uint32(id))
+}
+
+// closeSealed closes the notification with the ID in the sealed body.
+func (h *handler) closeSealed(w http.ResponseWriter, r *http.Request) {
+	if h.key == nil {
+		writeError(w, http.StatusNotFound, errors.New("use POST /close/{id}"))
+		return
+	}
+	var req struct {
+		ID uint32 `json:"id"`
+	}
+	if err := h.decode(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	h.closeID(w, req.ID)
+}
+
+func (h *handler) closeID(w http.ResponseWriter, id uint32) {
+	if _, err := h.n.CloseNotification(id); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) capabilities(w http.ResponseWriter, r *http.Request) {
+	caps, err := h.n.GetCapabilities()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"capabilities": caps})
+}
+
+type closedEvent struct {
+	ID     uint32 `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type actionEvent struct {
+	ID              uint32 `json:"id"`
+	ActionKey       string `json:"action_key"`
+	ActivationToken string `json:"activation_token,omitempty"`
+}
+
+// sealedEvent is what the "sealed" events of a sealed handler hold.
+type sealedEvent struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+func (h *handler) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	events, cancel := h.events.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			name, data := sseEvent(event)
+			if h.key != nil {
+				box, err := h.seal(sealedEvent{Event: name, Data: data})
+				if err != nil {
+					return
+				}
+				name, data = "sealed", box
+			}
+			payload, err := json.Marshal(data)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// sseEvent returns the event name and data sent for event.
+func sseEvent(event fanout.Event) (string, interface{}) {
+	if c := event.Closed; c != nil {
+		return "closed", closedEvent{ID: c.Id, Reason: c.Reason.String()}
+	}
+	a := event.Action
+	return "action", actionEvent{ID: a.Id, ActionKey: a.ActionKey, ActivationToken: a.ActivationToken}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,228 @@
+// Package notifymail delivers notifications by email, for unattended
+// servers that run the same notification call sites as the desktop build.
+//
+// A Digest batches the notifications sent within a window and mails them as
+// one message:
+//
+//	d, err := notifymail.NewDigest(notifymail.Config{
+//		Addr: "smtp.example.com:587",
+//		Auth: smtp.PlainAuth("", "alerts", password, "smtp.example.com"),
+//		From: "alerts@example.com",
+//		To:   []string{"ops@example.com"},
+//	})
+//	...
+//	defer d.Close() // mails what is still batched
+package notifymail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/smtp"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// Config configures a Digest.
+type Config struct {
+	Addr string    // host:port of the SMTP server
+	Auth smtp.Auth // optional
+	From string
+	To   []string
+	// Subject of the messages, "Notifications from <hostname>" if empty.
+	// The number of notifications is prepended.
+	Subject string
+	// Window is how long notifications are batched, 15 minutes if 0.
+	// Critical notifications are mailed right away, with the batch so far.
+	Window time.Duration
+	// MaxBatch is the most notifications kept for one message, 1000 if 0.
+	// Past it, e.g. while the SMTP server is unreachable, the oldest are
+	// dropped, and the next message says how many.
+	MaxBatch int
+	// Clock times the window, notify.SystemClock if nil.
+	Clock notify.Clock
+	// SendMail sends a message, smtp.SendMail if nil.
+	SendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// markupTags are the tags of the body markup of the spec.
+var markupTags = regexp.MustCompile(`</?(b|i|u|a|img)(\s[^>]*)?/?>`)
+
+type entry struct {
+	at   time.Time
+	note notify.Notification
+}
+
+// Digest is a notify.Notifier mailing the notifications sent to it in
+// batches. Signals are never emitted.
+type Digest struct {
+	*notify.NullNotifier
+	config Config
+
+	lock    sync.Mutex
+	batch   []entry
+	dropped int // notifications dropped from the batch since the last message
+	timer   notify.Alarm
+}
+
+// NewDigest creates a Digest mailing with config.
+func NewDigest(config Config) (*Digest, error) {
+	if config.Addr == "" || config.From == "" || len(config.To) == 0 {
+		return nil, errors.New("notifymail: Addr, From and To are required")
+	}
+	if config.Subject == "" {
+		host, _ := os.Hostname()
+		config.Subject = "Notifications from " + host
+	}
+	if config.Window == 0 {
+		config.Window = 15 * time.Minute
+	}
+	if config.MaxBatch <= 0 {
+		config.MaxBatch = 1000
+	}
+	if config.Clock == nil {
+		config.Clock = notify.SystemClock
+	}
+	if config.SendMail == nil {
+		config.SendMail = smtp.SendMail
+	}
+	return &Digest{NullNotifier: notify.NewNullNotifier(), config: config}, nil
+}
+
+// SendNotification adds note to the batch. A critical note has the batch
+// mailed right away, and the error of doing so is returned.
+func (d *Digest) SendNotification(note notify.Notification) (uint32, error) {
+	id, _ := d.NullNotifier.SendNotification(note)
+	d.lock.Lock()
+	d.batch = append(d.batch, entry{at: d.config.Clock.Now(), note: note.Clone()})
+	d.trim()
+	d.schedule()
+	d.lock.Unlock()
+	if u, ok := note.Hints[notify.HintUrgency]; ok && u.Value() == byte(notify.UrgencyCritical) {
+		if err := d.Flush(context.Background()); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
+}
+
+// Flush mails the batch now. On failure the notifications are kept for the
+// next attempt, after another window, up to Config.MaxBatch.
+func (d *Digest) Flush(ctx context.Context) error {
+	d.lock.Lock()
+	batch, dropped := d.batch, d.dropped
+	d.batch, d.dropped = nil, 0
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.lock.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- d.config.SendMail(d.config.Addr, d.config.Auth, d.config.From, d.config.To, d.message(batch, dropped))
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		d.lock.Lock()
+		d.batch = append(batch, d.batch...)
+		d.dropped += dropped
+		d.trim()
+		d.schedule()
+		d.lock.Unlock()
+	}
+	return err
+}
+
+// trim drops the oldest notifications of the batch past MaxBatch. d.lock
+// must be held.
+func (d *Digest) trim() {
+	if excess := len(d.batch) - d.config.MaxBatch; excess > 0 {
+		d.batch = append([]entry(nil), d.batch[excess:]...)
+		d.dropped += excess
+	}
+}
+
+// schedule has the batch mailed at the end of the window, unless that is
+// already planned. d.lock must be held.
+func (d *Digest) schedule() {
+	if d.timer != nil {
+		return
+	}
+	d.timer = d.config.Clock.AfterFunc(d.config.Window, func() {
+		if err := d.Flush(context.Background()); err != nil {
+			log.Printf("notifymail: error mailing digest: %v", err)
+		}
+	})
+}
+
+// message returns the mail for batch, saying how many notifications were
+// dropped before it.
+func (d *Digest) message(batch []entry, dropped int) []byte {
+	var body bytes.Buffer
+	qp := quotedprintable.NewWriter(&body)
+	if dropped > 0 {
+		fmt.Fprintf(qp, "%v dropped, there were too many to keep.\r\n\r\n",
+			notify.Count(dropped, "older notification was", "older notifications were"))
+	}
+	for _, e := range batch {
+		fmt.Fprintf(qp, "[%v] ", e.at.Format("2006-01-02 15:04:05"))
+		if e.note.AppName != "" {
+			fmt.Fprintf(qp, "%v: ", e.note.AppName)
+		}
+		fmt.Fprintf(qp, "%v\r\n", e.note.Summary)
+		for _, line := range strings.Split(markupTags.ReplaceAllString(e.note.Body, ""), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(qp, "    %v\r\n", line)
+			}
+		}
+		fmt.Fprint(qp, "\r\n")
+	}
+	qp.Close()
+
+	subject := fmt.Sprintf("%v: %v", notify.Count(len(batch), "notification", "notifications"), d.config.Subject)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %v\r\n", d.config.From)
+	fmt.Fprintf(&msg, "To: %v\r\n", strings.Join(d.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %v\r\n", d.config.Clock.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes()
+}
+
+// WithApp returns a child of d, see notify.NewChild.
+func (d *Digest) WithApp(appName, appIcon string) notify.Notifier {
+	return notify.NewChild(d, appName, appIcon)
+}
+
+// Close mails what is still batched and closes the signal channels.
+func (d *Digest) Close() error {
+	err := d.Flush(context.Background())
+	d.lock.Lock()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.lock.Unlock()
+	d.NullNotifier.Close()
+	return err
+}
@@ -0,0 +1,61 @@
+package notifymail
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/esiqveland/notify"
+)
+
+func TestDigestDropsOldest(t *testing.T) {
+	var sent []string
+	down := true
+	d, err := NewDigest(Config{
+		Addr:     "localhost:25",
+		From:     "alerts@example.com",
+		To:       []string{"ops@example.com"},
+		MaxBatch: 2,
+		SendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			if down {
+				return errors.New("connection refused")
+			}
+			sent = append(sent, string(msg))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	for _, summary := range []string{"one", "two", "three"} {
+		d.SendNotification(notify.Notification{Summary: summary})
+	}
+	if err := d.Flush(context.Background()); err == nil {
+		t.Fatal("flushed with the server down")
+	}
+	d.SendNotification(notify.Notification{Summary: "four"})
+	down = false
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %v messages, want 1", len(sent))
+	}
+	msg := sent[0]
+	if !strings.Contains(msg, "2 older notifications were dropped") {
+		t.Errorf("message doesn't report the drops:\n%v", msg)
+	}
+	for _, summary := range []string{"three", "four"} {
+		if !strings.Contains(msg, summary) {
+			t.Errorf("message lacks %q:\n%v", summary, msg)
+		}
+	}
+	for _, summary := range []string{"one", "two"} {
+		if strings.Contains(msg, summary+"\r\n") {
+			t.Errorf("message has dropped %q:\n%v", summary, msg)
+		}
+	}
+}
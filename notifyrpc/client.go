@@ -0,0 +1,149 @@
+package notifyrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"google.golang.org/grpc"
+)
+
+const channelBufferSize = 10
+
+// client implements notify.Notifier on top of a NotificationsClient.
+type client struct {
+	rpc NotificationsClient
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	closer chan *notify.NotificationClosedSignal
+	action chan *notify.ActionInvokedSignal
+}
+
+// NewNotifier returns a notify.Notifier using the Notifications service on
+// cc. Like with notify.New, the caller must consume the NotificationClosed
+// and ActionInvoked channels, and call Close() when done; the connection is
+// left open.
+func NewNotifier(cc grpc.ClientConnInterface) (notify.Notifier, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &client{
+		rpc:    NewNotificationsClient(cc),
+		cancel: cancel,
+		closer: make(chan *notify.NotificationClosedSignal, channelBufferSize),
+		action: make(chan *notify.ActionInvokedSignal, channelBufferSize),
+	}
+	events, err := c.rpc.Events(ctx, &EventsRequest{})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.wg.Add(1)
+	go c.receive(ctx, events)
+	return c, nil
+}
+
+// receive delivers events from the stream to the signal channels, until ctx
+// is cancelled or the stream ends.
+func (c *client) receive(ctx context.Context, events Notifications_EventsClient) {
+	defer c.wg.Done()
+	for {
+		event, err := events.Recv()
+		if err != nil {
+			return
+		}
+		switch e := event.GetEvent().(type) {
+		case *Event_NotificationClosed:
+			select {
+			case c.closer <- &notify.NotificationClosedSignal{
+				Id:     e.NotificationClosed.GetId(),
+				Reason: notify.Reason(e.NotificationClosed.GetReason()),
+			}:
+			case <-ctx.Done():
+				return
+			}
+		case *Event_ActionInvoked:
+			select {
+			case c.action <- &notify.ActionInvokedSignal{
+				Id:              e.ActionInvoked.GetId(),
+				ActionKey:       e.ActionInvoked.GetActionKey(),
+				ActivationToken: e.ActionInvoked.GetActivationToken(),
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (c *client) SendNotification(n notify.Notification) (uint32, error) {
+	note, err := toProto(n)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.rpc.SendNotification(context.Background(), &SendNotificationRequest{Notification: note})
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetId(), nil
+}
+
+func (c *client) GetCapabilities() ([]string, error) {
+	resp, err := c.rpc.GetCapabilities(context.Background(), &GetCapabilitiesRequest{})
+	if err != nil {
+		return []string{}, err
+	}
+	return resp.GetCapabilities(), nil
+}
+
+func (c *client) GetServerInformation() (notify.ServerInformation, error) {
+	resp, err := c.rpc.GetServerInformation(context.Background(), &GetServerInformationRequest{})
+	if err != nil {
+		return notify.ServerInformation{}, err
+	}
+	return notify.ServerInformation{
+		Name:        resp.GetName(),
+		Vendor:      resp.GetVendor(),
+		Version:     resp.GetVersion(),
+		SpecVersion: resp.GetSpecVersion(),
+	}, nil
+}
+
+func (c *client) CloseNotification(id uint32) (bool, error) {
+	_, err := c.rpc.CloseNotification(context.Background(), &CloseNotificationRequest{Id: id})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *client) CloseNotifications(ids ...uint32) error {
+	var first error
+	for _, id := range ids {
+		if _, err := c.CloseNotification(id); err != nil && first == nil {
+			first = fmt.Errorf("closing %v: %w", id, err)
+		}
+	}
+	return first
+}
+
+func (c *client) NotificationClosed() <-chan *notify.NotificationClosedSignal {
+	return c.closer
+}
+
+func (c *client) ActionInvoked() <-chan *notify.ActionInvokedSignal {
+	return c.action
+}
+
+func (c *client) WithApp(appName, appIcon string) notify.Notifier {
+	return notify.NewChild(c, appName, appIcon)
+}
+
+// Close stops receiving events and closes the signal channels.
+func (c *client) Close() error {
+	c.cancel()
+	c.wg.Wait()
+	close(c.closer)
+	close(c.action)
+	return nil
+}
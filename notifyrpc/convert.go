@@ -0,0 +1,91 @@
+package notifyrpc
+
+import (
+	"encoding/json"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+// jsonHints is the part of the JSON form of notify.Notification holding the
+// hints, which is reused for Hint values.
+type jsonHints struct {
+	Hints map[string]jsonHint `json:"hints"`
+}
+
+type jsonHint struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+func toProto(n notify.Notification) (*Notification, error) {
+	hints, err := hintsToProto(n.Hints)
+	if err != nil {
+		return nil, err
+	}
+	return &Notification{
+		AppName:       n.AppName,
+		ReplacesId:    n.ReplacesID,
+		AppIcon:       n.AppIcon,
+		Summary:       n.Summary,
+		Body:          n.Body,
+		Actions:       n.Actions,
+		Hints:         hints,
+		ExpireTimeout: n.ExpireTimeout,
+	}, nil
+}
+
+func fromProto(n *Notification) (notify.Notification, error) {
+	hints, err := hintsFromProto(n.GetHints())
+	if err != nil {
+		return notify.Notification{}, err
+	}
+	return notify.Notification{
+		AppName:       n.GetAppName(),
+		ReplacesID:    n.GetReplacesId(),
+		AppIcon:       n.GetAppIcon(),
+		Summary:       n.GetSummary(),
+		Body:          n.GetBody(),
+		Actions:       n.GetActions(),
+		Hints:         hints,
+		ExpireTimeout: n.GetExpireTimeout(),
+	}, nil
+}
+
+func hintsToProto(hints map[string]dbus.Variant) (map[string]*Hint, error) {
+	if len(hints) == 0 {
+		return nil, nil
+	}
+	data, err := notify.Notification{Hints: hints}.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var j jsonHints
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	ret := make(map[string]*Hint, len(j.Hints))
+	for k, v := range j.Hints {
+		ret[k] = &Hint{Type: v.Type, Value: string(v.Value)}
+	}
+	return ret, nil
+}
+
+func hintsFromProto(hints map[string]*Hint) (map[string]dbus.Variant, error) {
+	if len(hints) == 0 {
+		return nil, nil
+	}
+	j := jsonHints{Hints: make(map[string]jsonHint, len(hints))}
+	for k, v := range hints {
+		j.Hints[k] = jsonHint{Type: v.GetType(), Value: json.RawMessage(v.GetValue())}
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	var n notify.Notification
+	if err := n.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return n.Hints, nil
+}
@@ -0,0 +1,22 @@
+/*
+Package notifyrpc exposes a notify.Notifier over gRPC, so other processes,
+remote ones and ones not written in go, can use the notification server of
+a desktop.
+
+The service is defined in notify.proto. Server implements it on top of a
+Notifier:
+
+	n, err := notify.New(conn)
+	...
+	s := grpc.NewServer()
+	notifyrpc.RegisterNotificationsServer(s, notifyrpc.NewServer(n))
+	s.Serve(l)
+
+and NewNotifier returns a notify.Notifier talking to such a server.
+
+Hints travel as their D-Bus type signature and a JSON value, in the same
+form Notification.MarshalJSON uses.
+*/
+package notifyrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative notify.proto
@@ -0,0 +1,906 @@
+// Notifications exposes a desktop's notification server over gRPC.
+//
+// The messages mirror the freedesktop notification interface, see
+// https://developer.gnome.org/notification-spec/
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: notify.proto
+
+package notifyrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Notification struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AppName    string                 `protobuf:"bytes,1,opt,name=app_name,json=appName,proto3" json:"app_name,omitempty"`
+	ReplacesId uint32                 `protobuf:"varint,2,opt,name=replaces_id,json=replacesId,proto3" json:"replaces_id,omitempty"`
+	AppIcon    string                 `protobuf:"bytes,3,opt,name=app_icon,json=appIcon,proto3" json:"app_icon,omitempty"`
+	Summary    string                 `protobuf:"bytes,4,opt,name=summary,proto3" json:"summary,omitempty"`
+	Body       string                 `protobuf:"bytes,5,opt,name=body,proto3" json:"body,omitempty"`
+	// Pairs of (action_key, label).
+	Actions []string         `protobuf:"bytes,6,rep,name=actions,proto3" json:"actions,omitempty"`
+	Hints   map[string]*Hint `protobuf:"bytes,7,rep,name=hints,proto3" json:"hints,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Milliseconds; -1 leaves it to the server, 0 never expires.
+	ExpireTimeout int32 `protobuf:"varint,8,opt,name=expire_timeout,json=expireTimeout,proto3" json:"expire_timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notification) Reset() {
+	*x = Notification{}
+	mi := &file_notify_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notification) ProtoMessage() {}
+
+func (x *Notification) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notification.ProtoReflect.Descriptor instead.
+func (*Notification) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Notification) GetAppName() string {
+	if x != nil {
+		return x.AppName
+	}
+	return ""
+}
+
+func (x *Notification) GetReplacesId() uint32 {
+	if x != nil {
+		return x.ReplacesId
+	}
+	return 0
+}
+
+func (x *Notification) GetAppIcon() string {
+	if x != nil {
+		return x.AppIcon
+	}
+	return ""
+}
+
+func (x *Notification) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Notification) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *Notification) GetActions() []string {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+func (x *Notification) GetHints() map[string]*Hint {
+	if x != nil {
+		return x.Hints
+	}
+	return nil
+}
+
+func (x *Notification) GetExpireTimeout() int32 {
+	if x != nil {
+		return x.ExpireTimeout
+	}
+	return 0
+}
+
+// Hint is a D-Bus variant: its type signature, e.g. "y" or "(iiibiiay)", and
+// its value as JSON. Byte arrays are base64 strings, structs arrays of their
+// fields.
+type Hint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hint) Reset() {
+	*x = Hint{}
+	mi := &file_notify_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hint) ProtoMessage() {}
+
+func (x *Hint) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hint.ProtoReflect.Descriptor instead.
+func (*Hint) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hint) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Hint) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type SendNotificationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Notification  *Notification          `protobuf:"bytes,1,opt,name=notification,proto3" json:"notification,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendNotificationRequest) Reset() {
+	*x = SendNotificationRequest{}
+	mi := &file_notify_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendNotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendNotificationRequest) ProtoMessage() {}
+
+func (x *SendNotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendNotificationRequest.ProtoReflect.Descriptor instead.
+func (*SendNotificationRequest) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SendNotificationRequest) GetNotification() *Notification {
+	if x != nil {
+		return x.Notification
+	}
+	return nil
+}
+
+type SendNotificationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendNotificationResponse) Reset() {
+	*x = SendNotificationResponse{}
+	mi := &file_notify_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendNotificationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendNotificationResponse) ProtoMessage() {}
+
+func (x *SendNotificationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendNotificationResponse.ProtoReflect.Descriptor instead.
+func (*SendNotificationResponse) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SendNotificationResponse) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CloseNotificationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseNotificationRequest) Reset() {
+	*x = CloseNotificationRequest{}
+	mi := &file_notify_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseNotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseNotificationRequest) ProtoMessage() {}
+
+func (x *CloseNotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseNotificationRequest.ProtoReflect.Descriptor instead.
+func (*CloseNotificationRequest) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CloseNotificationRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CloseNotificationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseNotificationResponse) Reset() {
+	*x = CloseNotificationResponse{}
+	mi := &file_notify_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseNotificationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseNotificationResponse) ProtoMessage() {}
+
+func (x *CloseNotificationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseNotificationResponse.ProtoReflect.Descriptor instead.
+func (*CloseNotificationResponse) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{5}
+}
+
+type GetCapabilitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCapabilitiesRequest) Reset() {
+	*x = GetCapabilitiesRequest{}
+	mi := &file_notify_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCapabilitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapabilitiesRequest) ProtoMessage() {}
+
+func (x *GetCapabilitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapabilitiesRequest.ProtoReflect.Descriptor instead.
+func (*GetCapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{6}
+}
+
+type GetCapabilitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Capabilities  []string               `protobuf:"bytes,1,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCapabilitiesResponse) Reset() {
+	*x = GetCapabilitiesResponse{}
+	mi := &file_notify_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCapabilitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCapabilitiesResponse) ProtoMessage() {}
+
+func (x *GetCapabilitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCapabilitiesResponse.ProtoReflect.Descriptor instead.
+func (*GetCapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetCapabilitiesResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type GetServerInformationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServerInformationRequest) Reset() {
+	*x = GetServerInformationRequest{}
+	mi := &file_notify_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerInformationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerInformationRequest) ProtoMessage() {}
+
+func (x *GetServerInformationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerInformationRequest.ProtoReflect.Descriptor instead.
+func (*GetServerInformationRequest) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{8}
+}
+
+type ServerInformation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Vendor        string                 `protobuf:"bytes,2,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	SpecVersion   string                 `protobuf:"bytes,4,opt,name=spec_version,json=specVersion,proto3" json:"spec_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerInformation) Reset() {
+	*x = ServerInformation{}
+	mi := &file_notify_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerInformation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerInformation) ProtoMessage() {}
+
+func (x *ServerInformation) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerInformation.ProtoReflect.Descriptor instead.
+func (*ServerInformation) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ServerInformation) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ServerInformation) GetVendor() string {
+	if x != nil {
+		return x.Vendor
+	}
+	return ""
+}
+
+func (x *ServerInformation) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ServerInformation) GetSpecVersion() string {
+	if x != nil {
+		return x.SpecVersion
+	}
+	return ""
+}
+
+type EventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventsRequest) Reset() {
+	*x = EventsRequest{}
+	mi := &file_notify_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsRequest) ProtoMessage() {}
+
+func (x *EventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{10}
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*Event_NotificationClosed
+	//	*Event_ActionInvoked
+	Event         isEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_notify_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Event) GetEvent() isEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Event) GetNotificationClosed() *NotificationClosed {
+	if x != nil {
+		if x, ok := x.Event.(*Event_NotificationClosed); ok {
+			return x.NotificationClosed
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetActionInvoked() *ActionInvoked {
+	if x != nil {
+		if x, ok := x.Event.(*Event_ActionInvoked); ok {
+			return x.ActionInvoked
+		}
+	}
+	return nil
+}
+
+type isEvent_Event interface {
+	isEvent_Event()
+}
+
+type Event_NotificationClosed struct {
+	NotificationClosed *NotificationClosed `protobuf:"bytes,1,opt,name=notification_closed,json=notificationClosed,proto3,oneof"`
+}
+
+type Event_ActionInvoked struct {
+	ActionInvoked *ActionInvoked `protobuf:"bytes,2,opt,name=action_invoked,json=actionInvoked,proto3,oneof"`
+}
+
+func (*Event_NotificationClosed) isEvent_Event() {}
+
+func (*Event_ActionInvoked) isEvent_Event() {}
+
+type NotificationClosed struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// 1 expired, 2 dismissed by the user, 3 closed by a call, 4 undefined.
+	Reason        uint32 `protobuf:"varint,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NotificationClosed) Reset() {
+	*x = NotificationClosed{}
+	mi := &file_notify_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotificationClosed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotificationClosed) ProtoMessage() {}
+
+func (x *NotificationClosed) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotificationClosed.ProtoReflect.Descriptor instead.
+func (*NotificationClosed) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NotificationClosed) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *NotificationClosed) GetReason() uint32 {
+	if x != nil {
+		return x.Reason
+	}
+	return 0
+}
+
+type ActionInvoked struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ActionKey       string                 `protobuf:"bytes,2,opt,name=action_key,json=actionKey,proto3" json:"action_key,omitempty"`
+	ActivationToken string                 `protobuf:"bytes,3,opt,name=activation_token,json=activationToken,proto3" json:"activation_token,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ActionInvoked) Reset() {
+	*x = ActionInvoked{}
+	mi := &file_notify_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActionInvoked) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActionInvoked) ProtoMessage() {}
+
+func (x *ActionInvoked) ProtoReflect() protoreflect.Message {
+	mi := &file_notify_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActionInvoked.ProtoReflect.Descriptor instead.
+func (*ActionInvoked) Descriptor() ([]byte, []int) {
+	return file_notify_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ActionInvoked) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ActionInvoked) GetActionKey() string {
+	if x != nil {
+		return x.ActionKey
+	}
+	return ""
+}
+
+func (x *ActionInvoked) GetActivationToken() string {
+	if x != nil {
+		return x.ActivationToken
+	}
+	return ""
+}
+
+var File_notify_proto protoreflect.FileDescriptor
+
+const file_notify_proto_rawDesc = "" +
+	"\n" +
+	"\fnotify.proto\x12\tnotify.v1\"\xd9\x02\n" +
+	"\fNotification\x12\x19\n" +
+	"\bapp_name\x18\x01 \x01(\tR\aappName\x12\x1f\n" +
+	"\vreplaces_id\x18\x02 \x01(\rR\n" +
+	"replacesId\x12\x19\n" +
+	"\bapp_icon\x18\x03 \x01(\tR\aappIcon\x12\x18\n" +
+	"\asummary\x18\x04 \x01(\tR\asummary\x12\x12\n" +
+	"\x04body\x18\x05 \x01(\tR\x04body\x12\x18\n" +
+	"\aactions\x18\x06 \x03(\tR\aactions\x128\n" +
+	"\x05hints\x18\a \x03(\v2\".notify.v1.Notification.HintsEntryR\x05hints\x12%\n" +
+	"\x0eexpire_timeout\x18\b \x01(\x05R\rexpireTimeout\x1aI\n" +
+	"\n" +
+	"HintsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12%\n" +
+	"\x05value\x18\x02 \x01(\v2\x0f.notify.v1.HintR\x05value:\x028\x01\"0\n" +
+	"\x04Hint\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"V\n" +
+	"\x17SendNotificationRequest\x12;\n" +
+	"\fnotification\x18\x01 \x01(\v2\x17.notify.v1.NotificationR\fnotification\"*\n" +
+	"\x18SendNotificationResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\"*\n" +
+	"\x18CloseNotificationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\"\x1b\n" +
+	"\x19CloseNotificationResponse\"\x18\n" +
+	"\x16GetCapabilitiesRequest\"=\n" +
+	"\x17GetCapabilitiesResponse\x12\"\n" +
+	"\fcapabilities\x18\x01 \x03(\tR\fcapabilities\"\x1d\n" +
+	"\x1bGetServerInformationRequest\"|\n" +
+	"\x11ServerInformation\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06vendor\x18\x02 \x01(\tR\x06vendor\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12!\n" +
+	"\fspec_version\x18\x04 \x01(\tR\vspecVersion\"\x0f\n" +
+	"\rEventsRequest\"\xa5\x01\n" +
+	"\x05Event\x12P\n" +
+	"\x13notification_closed\x18\x01 \x01(\v2\x1d.notify.v1.NotificationClosedH\x00R\x12notificationClosed\x12A\n" +
+	"\x0eaction_invoked\x18\x02 \x01(\v2\x18.notify.v1.ActionInvokedH\x00R\ractionInvokedB\a\n" +
+	"\x05event\"<\n" +
+	"\x12NotificationClosed\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\rR\x06reason\"i\n" +
+	"\rActionInvoked\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x1d\n" +
+	"\n" +
+	"action_key\x18\x02 \x01(\tR\tactionKey\x12)\n" +
+	"\x10activation_token\x18\x03 \x01(\tR\x0factivationToken2\xbc\x03\n" +
+	"\rNotifications\x12[\n" +
+	"\x10SendNotification\x12\".notify.v1.SendNotificationRequest\x1a#.notify.v1.SendNotificationResponse\x12^\n" +
+	"\x11CloseNotification\x12#.notify.v1.CloseNotificationRequest\x1a$.notify.v1.CloseNotificationResponse\x12X\n" +
+	"\x0fGetCapabilities\x12!.notify.v1.GetCapabilitiesRequest\x1a\".notify.v1.GetCapabilitiesResponse\x12\\\n" +
+	"\x14GetServerInformation\x12&.notify.v1.GetServerInformationRequest\x1a\x1c.notify.v1.ServerInformation\x126\n" +
+	"\x06Events\x12\x18.notify.v1.EventsRequest\x1a\x10.notify.v1.Event0\x01B(Z&github.com/esiqveland/notify/notifyrpcb\x06proto3"
+
+var (
+	file_notify_proto_rawDescOnce sync.Once
+	file_notify_proto_rawDescData []byte
+)
+
+func file_notify_proto_rawDescGZIP() []byte {
+	file_notify_proto_rawDescOnce.Do(func() {
+		file_notify_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_notify_proto_rawDesc), len(file_notify_proto_rawDesc)))
+	})
+	return file_notify_proto_rawDescData
+}
+
+var file_notify_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_notify_proto_goTypes = []any{
+	(*Notification)(nil),                // 0: notify.v1.Notification
+	(*Hint)(nil),                        // 1: notify.v1.Hint
+	(*SendNotificationRequest)(nil),     // 2: notify.v1.SendNotificationRequest
+	(*SendNotificationResponse)(nil),    // 3: notify.v1.SendNotificationResponse
+	(*CloseNotificationRequest)(nil),    // 4: notify.v1.CloseNotificationRequest
+	(*CloseNotificationResponse)(nil),   // 5: notify.v1.CloseNotificationResponse
+	(*GetCapabilitiesRequest)(nil),      // 6: notify.v1.GetCapabilitiesRequest
+	(*GetCapabilitiesResponse)(nil),     // 7: notify.v1.GetCapabilitiesResponse
+	(*GetServerInformationRequest)(nil), // 8: notify.v1.GetServerInformationRequest
+	(*ServerInformation)(nil),           // 9: notify.v1.ServerInformation
+	(*EventsRequest)(nil),               // 10: notify.v1.EventsRequest
+	(*Event)(nil),                       // 11: notify.v1.Event
+	(*NotificationClosed)(nil),          // 12: notify.v1.NotificationClosed
+	(*ActionInvoked)(nil),               // 13: notify.v1.ActionInvoked
+	nil,                                 // 14: notify.v1.Notification.HintsEntry
+}
+var file_notify_proto_depIdxs = []int32{
+	14, // 0: notify.v1.Notification.hints:type_name -> notify.v1.Notification.HintsEntry
+	0,  // 1: notify.v1.SendNotificationRequest.notification:type_name -> notify.v1.Notification
+	12, // 2: notify.v1.Event.notification_closed:type_name -> notify.v1.NotificationClosed
+	13, // 3: notify.v1.Event.action_invoked:type_name -> notify.v1.ActionInvoked
+	1,  // 4: notify.v1.Notification.HintsEntry.value:type_name -> notify.v1.Hint
+	2,  // 5: notify.v1.Notifications.SendNotification:input_type -> notify.v1.SendNotificationRequest
+	4,  // 6: notify.v1.Notifications.CloseNotification:input_type -> notify.v1.CloseNotificationRequest
+	6,  // 7: notify.v1.Notifications.GetCapabilities:input_type -> notify.v1.GetCapabilitiesRequest
+	8,  // 8: notify.v1.Notifications.GetServerInformation:input_type -> notify.v1.GetServerInformationRequest
+	10, // 9: notify.v1.Notifications.Events:input_type -> notify.v1.EventsRequest
+	3,  // 10: notify.v1.Notifications.SendNotification:output_type -> notify.v1.SendNotificationResponse
+	5,  // 11: notify.v1.Notifications.CloseNotification:output_type -> notify.v1.CloseNotificationResponse
+	7,  // 12: notify.v1.Notifications.GetCapabilities:output_type -> notify.v1.GetCapabilitiesResponse
+	9,  // 13: notify.v1.Notifications.GetServerInformation:output_type -> notify.v1.ServerInformation
+	11, // 14: notify.v1.Notifications.Events:output_type -> notify.v1.Event
+	10, // [10:15] is the sub-list for method output_type
+	5,  // [5:10] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_notify_proto_init() }
+func file_notify_proto_init() {
+	if File_notify_proto != nil {
+		return
+	}
+	file_notify_proto_msgTypes[11].OneofWrappers = []any{
+		(*Event_NotificationClosed)(nil),
+		(*Event_ActionInvoked)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_notify_proto_rawDesc), len(file_notify_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_notify_proto_goTypes,
+		DependencyIndexes: file_notify_proto_depIdxs,
+		MessageInfos:      file_notify_proto_msgTypes,
+	}.Build()
+	File_notify_proto = out.File
+	file_notify_proto_goTypes = nil
+	file_notify_proto_depIdxs = nil
+}
@@ -0,0 +1,104 @@
+package notifyrpc
+
+import (
+	"context"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/internal/fanout"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// eventBufferSize is how many events a slow Events stream may fall behind
+// before events for it are dropped.
+const eventBufferSize = 16
+
+// Server implements NotificationsServer on top of a notify.Notifier.
+// It consumes the Notifier's signal channels and hands the signals to every
+// Events stream.
+type Server struct {
+	UnimplementedNotificationsServer
+
+	n      notify.Notifier
+	events *fanout.Fanout
+}
+
+// NewServer creates a Server sending notifications with n.
+func NewServer(n notify.Notifier) *Server {
+	return &Server{
+		n:      n,
+		events: fanout.New(n, eventBufferSize),
+	}
+}
+func (s *Server) SendNotification(ctx context.Context, req *SendNotificationRequest) (*SendNotificationResponse, error) {
+	note, err := fromProto(req.GetNotification())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid notification: %v", err)
+	}
+	id, err := s.n.SendNotification(note)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &SendNotificationResponse{Id: id}, nil
+}
+
+func (s *Server) CloseNotification(ctx context.Context, req *CloseNotificationRequest) (*CloseNotificationResponse, error) {
+	if _, err := s.n.CloseNotification(req.GetId()); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &CloseNotificationResponse{}, nil
+}
+
+func (s *Server) GetCapabilities(ctx context.Context, req *GetCapabilitiesRequest) (*GetCapabilitiesResponse, error) {
+	caps, err := s.n.GetCapabilities()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &GetCapabilitiesResponse{Capabilities: caps}, nil
+}
+
+func (s *Server) GetServerInformation(ctx context.Context, req *GetServerInformationRequest) (*ServerInformation, error) {
+	info, err := s.n.GetServerInformation()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &ServerInformation{
+		Name:        info.Name,
+		Vendor:      info.Vendor,
+		Version:     info.Version,
+		SpecVersion: info.SpecVersion,
+	}, nil
+}
+
+func (s *Server) Events(req *EventsRequest, stream Notifications_EventsServer) error {
+	events, cancel := s.events.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toEvent(event)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func toEvent(event fanout.Event) *Event {
+	if c := event.Closed; c != nil {
+		return &Event{Event: &Event_NotificationClosed{NotificationClosed: &NotificationClosed{
+			Id:     c.Id,
+			Reason: uint32(c.Reason),
+		}}}
+	}
+	a := event.Action
+	return &Event{Event: &Event_ActionInvoked{ActionInvoked: &ActionInvoked{
+		Id:              a.Id,
+		ActionKey:       a.ActionKey,
+		ActivationToken: a.ActivationToken,
+	}}}
+}
@@ -0,0 +1,325 @@
+// Package notifystats measures how an application's notifications are
+// received: per category how many are sent, and how many the user clicks,
+// dismisses or lets expire, so applications can tune how noisy they are.
+//
+// A Tracker wraps the Notifier the application sends through:
+//
+//	t := notifystats.NewTracker(n)
+//	// use t instead of n
+//	...
+//	t.Stats().WriteJSON(os.Stdout)
+//
+// FromHistory computes the same numbers over a history recorded by package
+// history, e.g. by notify-logger, for the notifications of all
+// applications:
+//
+//	s, err := history.OpenReadOnly(history.DefaultPath())
+//	...
+//	notifystats.FromHistory(s.Entries()).WriteJSON(os.Stdout)
+package notifystats
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/history"
+)
+
+const (
+	channelBufferSize = 10
+
+	// maxSamples bounds the time-to-dismiss samples kept per category.
+	maxSamples = 1000
+
+	// maxLive bounds the notifications a Tracker waits on to finish. Past
+	// it, the oldest are forgotten, and don't count as finished.
+	maxLive = 10000
+	// liveMaxAge is how long a Tracker waits on a notification to finish,
+	// servers don't always tell, e.g. after they restarted.
+	liveMaxAge = 24 * time.Hour
+	// sweepInterval is how often notifications older than liveMaxAge are
+	// forgotten.
+	sweepInterval = time.Minute
+
+	// clickGrace is how long a dismissal waits for an ActionInvoked signal
+	// before it counts: servers close a notification as dismissed after an
+	// action, and the two signals arrive on different channels in no
+	// particular order.
+	clickGrace = time.Second
+)
+
+// CategoryStats are the numbers for one category.
+type CategoryStats struct {
+	Sent      int `json:"sent"`
+	Clicked   int `json:"clicked"`   // an action was invoked
+	Dismissed int `json:"dismissed"` // closed by the user without an action
+	Expired   int `json:"expired"`
+	Closed    int `json:"closed"` // closed by the application
+
+	// ClickRate and DismissRate are Clicked and Dismissed as a fraction of
+	// the notifications that finished, i.e. were clicked or closed.
+	ClickRate   float64 `json:"click_rate"`
+	DismissRate float64 `json:"dismiss_rate"`
+	// MedianTimeToDismiss is the median time dismissed notifications were
+	// on screen.
+	MedianTimeToDismiss time.Duration `json:"median_time_to_dismiss_ns"`
+}
+
+// Stats are the numbers per category, by the value of the category hint.
+// Notifications without one are counted under "".
+type Stats struct {
+	Categories map[string]CategoryStats `json:"categories"`
+}
+
+// WriteJSON writes s to w as JSON.
+func (s Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Tracker is a notify.Notifier that passes everything on to another
+// Notifier, while counting what happens to the notifications sent.
+type Tracker struct {
+	notify.Notifier
+	clock notify.Clock
+
+	lock       sync.Mutex
+	categories map[string]*category
+	live       map[uint32]*sent
+	swept      time.Time
+	closer     chan *notify.NotificationClosedSignal
+	action     chan *notify.ActionInvokedSignal
+}
+
+type category struct {
+	stats   CategoryStats
+	samples []time.Duration
+}
+
+// sent is a notification that hasn't finished yet.
+type sent struct {
+	category string
+	at       time.Time
+	clicked  bool
+	closed   time.Time // when it was dismissed, see clickGrace
+}
+
+// NewTracker creates a Tracker for n, on the clock of n (see
+// notify.ClockOf).
+// The Tracker takes over consuming n's signal channels; consume the
+// Tracker's channels instead.
+func NewTracker(n notify.Notifier) *Tracker {
+	t := &Tracker{
+		Notifier:   n,
+		clock:      notify.ClockOf(n),
+		categories: make(map[string]*category),
+		live:       make(map[uint32]*sent),
+		closer:     make(chan *notify.NotificationClosedSignal, channelBufferSize),
+		action:     make(chan *notify.ActionInvokedSignal, channelBufferSize),
+	}
+	go func() {
+		for c := range n.NotificationClosed() {
+			t.closed(c.Id, c.Reason)
+			t.closer <- c
+		}
+		close(t.closer)
+	}()
+	go func() {
+		for a := range n.ActionInvoked() {
+			t.clicked(a.Id)
+			t.action <- a
+		}
+		close(t.action)
+	}()
+	return t
+}
+
+func (t *Tracker) SendNotification(note notify.Notification) (uint32, error) {
+	id, err := t.Notifier.SendNotification(note)
+	if err != nil || id == 0 {
+		return id, err
+	}
+	name, _ := note.Hints[notify.HintCategory].Value().(string)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.category(name).stats.Sent++
+	now := t.clock.Now()
+	t.live[id] = &sent{category: name, at: now}
+	t.sweep(now)
+	return id, nil
+}
+
+// sweep forgets notifications that didn't finish in liveMaxAge, at most
+// every sweepInterval, and the oldest beyond maxLive. t.lock must be held.
+func (t *Tracker) sweep(now time.Time) {
+	if now.Sub(t.swept) >= sweepInterval {
+		t.swept = now
+		for id, s := range t.live {
+			if now.Sub(s.at) > liveMaxAge {
+				delete(t.live, id)
+			}
+		}
+	}
+	if len(t.live) <= maxLive {
+		return
+	}
+	// forget a tenth at once, not to sort on every send.
+	ids := make([]uint32, 0, len(t.live))
+	for id := range t.live {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return t.live[ids[i]].at.Before(t.live[ids[j]].at) })
+	for _, id := range ids[:len(ids)-maxLive*9/10] {
+		delete(t.live, id)
+	}
+}
+
+func (t *Tracker) NotificationClosed() <-chan *notify.NotificationClosedSignal {
+	return t.closer
+}
+
+func (t *Tracker) ActionInvoked() <-chan *notify.ActionInvokedSignal {
+	return t.action
+}
+
+// WithApp returns a child sending through t, so its notifications are
+// counted too.
+func (t *Tracker) WithApp(appName, appIcon string) notify.Notifier {
+	return notify.NewChild(t, appName, appIcon)
+}
+
+// category returns the counters of name. Must be called with t.lock held.
+func (t *Tracker) category(name string) *category {
+	c, ok := t.categories[name]
+	if !ok {
+		c = &category{}
+		t.categories[name] = c
+	}
+	return c
+}
+
+func (t *Tracker) clicked(id uint32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s, ok := t.live[id]
+	if !ok || s.clicked {
+		return
+	}
+	s.clicked = true
+	t.category(s.category).stats.Clicked++
+}
+
+func (t *Tracker) closed(id uint32, reason notify.Reason) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s, ok := t.live[id]
+	if !ok {
+		// not ours, or sent before the Tracker.
+		return
+	}
+	if s.clicked {
+		// servers close notifications after an action, that's no dismissal.
+		delete(t.live, id)
+		return
+	}
+	c := t.category(s.category)
+	switch reason {
+	case notify.ReasonDismissedByUser:
+		s.closed = t.clock.Now()
+		t.clock.AfterFunc(clickGrace, func() {
+			t.dismissed(id, s)
+		})
+		return
+	case notify.ReasonExpired:
+		c.stats.Expired++
+	default:
+		c.stats.Closed++
+	}
+	delete(t.live, id)
+}
+
+// dismissed counts s as dismissed, unless an action came in after all.
+func (t *Tracker) dismissed(id uint32, s *sent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.live[id] == s {
+		delete(t.live, id)
+	}
+	if s.clicked {
+		return
+	}
+	c := t.category(s.category)
+	c.stats.Dismissed++
+	if len(c.samples) < maxSamples {
+		c.samples = append(c.samples, s.closed.Sub(s.at))
+	}
+}
+
+// Stats returns the numbers so far.
+func (t *Tracker) Stats() Stats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return stats(t.categories)
+}
+
+// FromHistory returns the numbers of the notifications in entries. Those
+// still pending count as sent only.
+func FromHistory(entries []history.Entry) Stats {
+	categories := make(map[string]*category)
+	for _, e := range entries {
+		name, _ := e.Notification.Hints[notify.HintCategory].Value().(string)
+		c, ok := categories[name]
+		if !ok {
+			c = &category{}
+			categories[name] = c
+		}
+		c.stats.Sent++
+		switch e.Outcome {
+		case notify.OutcomePending, notify.OutcomeTimeout:
+		case notify.OutcomeActionInvoked:
+			c.stats.Clicked++
+		case notify.OutcomeDismissed:
+			c.stats.Dismissed++
+			if len(c.samples) < maxSamples && !e.Ended.IsZero() {
+				c.samples = append(c.samples, e.Ended.Sub(e.Time))
+			}
+		case notify.OutcomeExpired:
+			c.stats.Expired++
+		default:
+			c.stats.Closed++
+		}
+	}
+	return stats(categories)
+}
+
+func stats(categories map[string]*category) Stats {
+	s := Stats{Categories: make(map[string]CategoryStats, len(categories))}
+	for name, c := range categories {
+		stats := c.stats
+		if finished := stats.Clicked + stats.Dismissed + stats.Expired + stats.Closed; finished > 0 {
+			stats.ClickRate = float64(stats.Clicked) / float64(finished)
+			stats.DismissRate = float64(stats.Dismissed) / float64(finished)
+		}
+		stats.MedianTimeToDismiss = median(c.samples)
+		s.Categories[name] = stats
+	}
+	return s
+}
+
+func median(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,120 @@
+package notifytest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// Clock is a notify.Clock whose time only moves when told to, so tests of
+// quiet hours, storm guards, retries, reminders and expiry run instantly:
+//
+//	clock := notifytest.NewClock(time.Date(2024, 1, 1, 22, 0, 0, 0, time.Local))
+//	n, _ := notify.New(nil, notify.WithDryRun(), notify.WithClock(clock),
+//		notify.WithQuietHours(quiet))
+//	...
+//	clock.Advance(9 * time.Hour) // quiet hours are over
+//
+// Functions scheduled with AfterFunc run in Advance and Set, not in
+// goroutines of their own.
+type Clock struct {
+	lock   sync.Mutex
+	now    time.Time
+	alarms []*alarm
+	added  chan struct{} // closed and replaced on every AfterFunc
+}
+
+type alarm struct {
+	clock *Clock
+	at    time.Time
+	f     func()
+}
+
+// NewClock returns a Clock showing now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now, added: make(chan struct{})}
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f for when the clock has moved on by d.
+func (c *Clock) AfterFunc(d time.Duration, f func()) notify.Alarm {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	a := &alarm{clock: c, at: c.now.Add(d), f: f}
+	c.alarms = append(c.alarms, a)
+	close(c.added)
+	c.added = make(chan struct{})
+	return a
+}
+
+// Stop cancels the call of a, reporting whether it was still pending.
+func (a *alarm) Stop() bool {
+	c := a.clock
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i, b := range c.alarms {
+		if b == a {
+			c.alarms = append(c.alarms[:i], c.alarms[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock on by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, calling the functions that came due on the way
+// in the order they are due, each with the clock showing its time. Functions
+// they schedule run too if due by t.
+func (c *Clock) Set(t time.Time) {
+	for {
+		c.lock.Lock()
+		sort.SliceStable(c.alarms, func(i, j int) bool {
+			return c.alarms[i].at.Before(c.alarms[j].at)
+		})
+		if len(c.alarms) == 0 || c.alarms[0].at.After(t) {
+			c.now = t
+			c.lock.Unlock()
+			return
+		}
+		a := c.alarms[0]
+		c.alarms = c.alarms[1:]
+		if a.at.After(c.now) {
+			c.now = a.at
+		}
+		c.lock.Unlock()
+		a.f()
+	}
+}
+
+// Pending returns how many functions are scheduled.
+func (c *Clock) Pending() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.alarms)
+}
+
+// WaitPending waits until at least count functions are scheduled, e.g. for a
+// goroutine to reach a notify.Sleep before advancing the clock past it.
+func (c *Clock) WaitPending(count int) {
+	for {
+		c.lock.Lock()
+		pending, added := len(c.alarms), c.added
+		c.lock.Unlock()
+		if pending >= count {
+			return
+		}
+		<-added
+	}
+}
@@ -0,0 +1,18 @@
+/*
+Package notifytest provides tools for testing code built on the notify package
+without a running notification server.
+
+Fake is an in-memory notify.Notifier that records what is sent and lets tests
+deliver ActionInvoked and NotificationClosed signals. It can also script the
+failures to test retries and fallbacks against: sends failing with
+ErrNoNotificationServer, actions arriving late, servers closing
+notifications for other reasons, and capabilities changing.
+
+Recorder wraps a real Notifier and writes every sent notification and received
+signal to a transcript, which Replay later feeds into a Fake, so interaction
+flows captured once against a real desktop can be rerun as tests.
+
+Clock is a notify.Clock moved by hand, for testing time-dependent behavior
+like quiet hours and retries without waiting.
+*/
+package notifytest
@@ -0,0 +1,233 @@
+package notifytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+const channelBufferSize = 10
+
+// ErrNoNotificationServer is the error of a send while no notification
+// server runs, e.g. before the desktop started. notify retries it.
+var ErrNoNotificationServer error = &dbus.Error{
+	Name: "org.freedesktop.DBus.Error.ServiceUnknown",
+	Body: []interface{}{"The name org.freedesktop.Notifications was not provided by any .service files"},
+}
+
+// Sent is a notification received by a Fake, with the ID it was given.
+type Sent struct {
+	ID           uint32
+	Notification notify.Notification
+}
+
+// Fake is a notify.Notifier that keeps sent notifications in memory instead
+// of talking to a notification server.
+//
+// Like a real Notifier, the channels returned by NotificationClosed() and
+// ActionInvoked() must be consumed, signals are delivered synchronously.
+type Fake struct {
+	Capabilities []string
+	Info         notify.ServerInformation
+	// Clock times the signals of EmitActionInvokedAfter,
+	// notify.SystemClock if nil.
+	Clock notify.Clock
+
+	lock    sync.Mutex
+	lastID  uint32
+	sent    []Sent
+	changed chan struct{} // closed and replaced on every send
+	closer  chan *notify.NotificationClosedSignal
+	action  chan *notify.ActionInvokedSignal
+
+	calls   int           // of SendNotification
+	failNth map[int]error // by call, see FailSend
+	failAll error         // see FailSends
+	reasons []notify.Reason
+}
+
+// NewFake creates a Fake advertising the "actions" and "body" capabilities.
+func NewFake() *Fake {
+	return &Fake{
+		Capabilities: []string{"actions", "body"},
+		Info: notify.ServerInformation{
+			Name:        "notifytest",
+			Vendor:      "esiqveland",
+			Version:     "0.1",
+			SpecVersion: "1.2",
+		},
+		changed: make(chan struct{}),
+		closer:  make(chan *notify.NotificationClosedSignal, channelBufferSize),
+		action:  make(chan *notify.ActionInvokedSignal, channelBufferSize),
+		failNth: make(map[int]error),
+	}
+}
+
+// SendNotification records n and returns its ID. IDs are handed out the way
+// a server does: increasing from 1, or ReplacesID if set. Sends failed with
+// FailSend or FailSends return the error and record nothing.
+func (f *Fake) SendNotification(n notify.Notification) (uint32, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls++
+	if err, ok := f.failNth[f.calls]; ok {
+		delete(f.failNth, f.calls)
+		return 0, err
+	}
+	if f.failAll != nil {
+		return 0, f.failAll
+	}
+	id := n.ReplacesID
+	if id == 0 {
+		f.lastID++
+		id = f.lastID
+	}
+	f.sent = append(f.sent, Sent{ID: id, Notification: n})
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return id, nil
+}
+
+func (f *Fake) GetCapabilities() ([]string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.Capabilities...), nil
+}
+
+func (f *Fake) GetServerInformation() (notify.ServerInformation, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.Info, nil
+}
+
+// CloseNotification emits NotificationClosed with ReasonClosedByCall,
+// as a server does, or with the next reason set by SetCloseReasons.
+func (f *Fake) CloseNotification(id uint32) (bool, error) {
+	f.EmitNotificationClosed(id, f.closeReason())
+	return true, nil
+}
+
+// CloseNotifications emits NotificationClosed for each of ids.
+func (f *Fake) CloseNotifications(ids ...uint32) error {
+	for _, id := range ids {
+		f.EmitNotificationClosed(id, f.closeReason())
+	}
+	return nil
+}
+
+func (f *Fake) closeReason() notify.Reason {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.reasons) == 0 {
+		return notify.ReasonClosedByCall
+	}
+	reason := f.reasons[0]
+	f.reasons = append(f.reasons[1:], reason)
+	return reason
+}
+
+func (f *Fake) NotificationClosed() <-chan *notify.NotificationClosedSignal {
+	return f.closer
+}
+
+func (f *Fake) ActionInvoked() <-chan *notify.ActionInvokedSignal {
+	return f.action
+}
+
+// WithApp returns a child sending through f, see notify.NewChild.
+func (f *Fake) WithApp(appName, appIcon string) notify.Notifier {
+	return notify.NewChild(f, appName, appIcon)
+}
+
+// Close closes the signal channels.
+func (f *Fake) Close() error {
+	close(f.closer)
+	close(f.action)
+	return nil
+}
+
+// EmitNotificationClosed delivers a NotificationClosed signal.
+func (f *Fake) EmitNotificationClosed(id uint32, reason notify.Reason) {
+	f.closer <- &notify.NotificationClosedSignal{Id: id, Reason: reason}
+}
+
+// EmitActionInvoked delivers an ActionInvoked signal.
+func (f *Fake) EmitActionInvoked(id uint32, key string) {
+	f.action <- &notify.ActionInvokedSignal{Id: id, ActionKey: key}
+}
+
+// EmitActionInvokedAfter delivers an ActionInvoked signal once d has passed
+// on f.Clock, like a user taking their time, and returns right away.
+func (f *Fake) EmitActionInvokedAfter(d time.Duration, id uint32, key string) {
+	clock := f.Clock
+	if clock == nil {
+		clock = notify.SystemClock
+	}
+	clock.AfterFunc(d, func() {
+		go f.EmitActionInvoked(id, key)
+	})
+}
+
+// Sent returns the notifications sent so far, in order.
+func (f *Fake) Sent() []Sent {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]Sent{}, f.sent...)
+}
+
+// WaitSent blocks until at least count notifications were sent, or ctx is
+// done. It returns the notifications sent so far.
+func (f *Fake) WaitSent(ctx context.Context, count int) ([]Sent, error) {
+	for {
+		f.lock.Lock()
+		if len(f.sent) >= count {
+			sent := append([]Sent{}, f.sent...)
+			f.lock.Unlock()
+			return sent, nil
+		}
+		changed := f.changed
+		f.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return f.Sent(), ctx.Err()
+		}
+	}
+}
+
+// FailSend makes the nth call of SendNotification, counting from 1 since f
+// was created, fail with err, e.g. ErrNoNotificationServer.
+func (f *Fake) FailSend(nth int, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.failNth[nth] = err
+}
+
+// FailSends makes every SendNotification fail with err, as if the server
+// went away, until it is called with nil.
+func (f *Fake) FailSends(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.failAll = err
+}
+
+// SetCloseReasons makes CloseNotification emit reasons in turn, starting
+// over after the last, instead of ReasonClosedByCall. Servers differ in what
+// they report; none restores ReasonClosedByCall.
+func (f *Fake) SetCloseReasons(reasons ...notify.Reason) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.reasons = append([]notify.Reason{}, reasons...)
+}
+
+// SetCapabilities changes the capabilities GetCapabilities reports, e.g. to
+// a server restarted with another configuration in the middle of a test.
+func (f *Fake) SetCapabilities(caps ...string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.Capabilities = append([]string{}, caps...)
+}
@@ -0,0 +1,181 @@
+package notifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+var update = flag.Bool("notifytest.update", false, "rewrite golden files of notifytest.AssertGolden")
+
+// Matcher checks one property of a notification. It returns an error
+// describing the mismatch, or nil.
+type Matcher func(n notify.Notification) error
+
+// Match reports an error on t for every matcher that n fails.
+func Match(t testing.TB, n notify.Notification, matchers ...Matcher) {
+	t.Helper()
+	for _, m := range matchers {
+		if err := m(n); err != nil {
+			t.Errorf("notification %q: %v", n.Summary, err)
+		}
+	}
+}
+
+// AppName matches notifications sent with app name s.
+func AppName(s string) Matcher {
+	return func(n notify.Notification) error {
+		if n.AppName != s {
+			return fmt.Errorf("app name is %q, want %q", n.AppName, s)
+		}
+		return nil
+	}
+}
+
+// Summary matches notifications with summary s.
+func Summary(s string) Matcher {
+	return func(n notify.Notification) error {
+		if n.Summary != s {
+			return fmt.Errorf("summary is %q, want %q", n.Summary, s)
+		}
+		return nil
+	}
+}
+
+// Body matches notifications with body s.
+func Body(s string) Matcher {
+	return func(n notify.Notification) error {
+		if n.Body != s {
+			return fmt.Errorf("body is %q, want %q", n.Body, s)
+		}
+		return nil
+	}
+}
+
+// Action matches notifications offering the action key with label.
+func Action(key, label string) Matcher {
+	return func(n notify.Notification) error {
+		for i := 0; i+1 < len(n.Actions); i += 2 {
+			if n.Actions[i] == key {
+				if n.Actions[i+1] != label {
+					return fmt.Errorf("action %q has label %q, want %q", key, n.Actions[i+1], label)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("no action %q in %q", key, n.Actions)
+	}
+}
+
+// Hint matches notifications with hint key set to value.
+// Values are compared the way they go over the wire, so a hint holding
+// notify.UrgencyCritical matches Hint("urgency", byte(2)), but an int32 hint
+// does not match an int64 value.
+func Hint(key string, value interface{}) Matcher {
+	return func(n notify.Notification) error {
+		got, ok := n.Hints[key]
+		if !ok {
+			return fmt.Errorf("no hint %q", key)
+		}
+		want := dbus.MakeVariant(value)
+		g, err := normalizeHint(got)
+		if err != nil {
+			return err
+		}
+		w, err := normalizeHint(want)
+		if err != nil {
+			return err
+		}
+		if g != w {
+			return fmt.Errorf("hint %q is %v, want %v", key, g, w)
+		}
+		return nil
+	}
+}
+
+// Urgency matches notifications with urgency u.
+func Urgency(u notify.Urgency) Matcher {
+	return Hint(notify.HintUrgency, byte(u))
+}
+
+// normalizeHint returns the JSON encoding of v, which includes its signature.
+func normalizeHint(v dbus.Variant) (string, error) {
+	data, err := json.Marshal(notify.Notification{Hints: map[string]dbus.Variant{"": v}})
+	if err != nil {
+		return "", err
+	}
+	var j struct {
+		Hints map[string]json.RawMessage `json:"hints"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return "", err
+	}
+	return string(j.Hints[""]), nil
+}
+
+// Notifications returns the notifications sent to f so far, without IDs.
+func (f *Fake) Notifications() []notify.Notification {
+	sent := f.Sent()
+	ret := make([]notify.Notification, len(sent))
+	for i, s := range sent {
+		ret[i] = s.Notification
+	}
+	return ret
+}
+
+// AssertGolden compares notes to the golden file at path, and reports an
+// error on t if they differ.
+// Run the test with -notifytest.update to (re)write the golden file.
+//
+// Notifications are stored as indented JSON in which hints carry their D-Bus
+// signature, so golden files only change when what goes over the wire
+// changes: nil and empty actions and hints are written the same, and the
+// sender-pid hint is left out, since it differs on every run.
+func AssertGolden(t testing.TB, path string, notes []notify.Notification) {
+	t.Helper()
+	got, err := golden(notes)
+	if err != nil {
+		t.Fatalf("encoding notifications: %v", err)
+	}
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -notifytest.update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("notifications differ from golden file %v\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func golden(notes []notify.Notification) ([]byte, error) {
+	stripped := make([]notify.Notification, len(notes))
+	for i, n := range notes {
+		hints := make(map[string]dbus.Variant, len(n.Hints))
+		for k, v := range n.Hints {
+			if k != notify.HintSenderPID {
+				hints[k] = v
+			}
+		}
+		n.Hints = hints
+		if n.Actions == nil {
+			n.Actions = []string{}
+		}
+		stripped[i] = n
+	}
+	data, err := json.MarshalIndent(stripped, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
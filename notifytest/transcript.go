@@ -0,0 +1,167 @@
+package notifytest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/esiqveland/notify"
+)
+
+// EventKind is the kind of an Event in a transcript.
+type EventKind string
+
+const (
+	EventNotify EventKind = "notify" // a notification was sent
+	EventClosed EventKind = "closed" // NotificationClosed was received
+	EventAction EventKind = "action" // ActionInvoked was received
+)
+
+// Event is one entry of a transcript. Transcripts are stored as JSON, one
+// event per line.
+type Event struct {
+	Kind         EventKind            `json:"kind"`
+	ID           uint32               `json:"id"`
+	Notification *notify.Notification `json:"notification,omitempty"`
+	Reason       notify.Reason        `json:"reason,omitempty"`
+	ActionKey    string               `json:"action_key,omitempty"`
+	Error        string               `json:"error,omitempty"` // error returned by SendNotification
+}
+
+// ReadTranscript reads all events of a transcript from r.
+func ReadTranscript(r io.Reader) ([]Event, error) {
+	var events []Event
+	dec := json.NewDecoder(r)
+	for {
+		var e Event
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, e)
+	}
+}
+
+// Recorder is a notify.Notifier that passes everything on to another
+// Notifier, while writing sent notifications and received signals to a
+// transcript.
+type Recorder struct {
+	notify.Notifier
+
+	lock   sync.Mutex
+	enc    *json.Encoder
+	err    error
+	closer chan *notify.NotificationClosedSignal
+	action chan *notify.ActionInvokedSignal
+}
+
+// NewRecorder creates a Recorder for n, writing the transcript to w.
+// The Recorder takes over consuming n's signal channels; consume the
+// Recorder's channels instead.
+func NewRecorder(n notify.Notifier, w io.Writer) *Recorder {
+	r := &Recorder{
+		Notifier: n,
+		enc:      json.NewEncoder(w),
+		closer:   make(chan *notify.NotificationClosedSignal, channelBufferSize),
+		action:   make(chan *notify.ActionInvokedSignal, channelBufferSize),
+	}
+	go func() {
+		for c := range n.NotificationClosed() {
+			r.write(Event{Kind: EventClosed, ID: c.Id, Reason: c.Reason})
+			r.closer <- c
+		}
+		close(r.closer)
+	}()
+	go func() {
+		for a := range n.ActionInvoked() {
+			r.write(Event{Kind: EventAction, ID: a.Id, ActionKey: a.ActionKey})
+			r.action <- a
+		}
+		close(r.action)
+	}()
+	return r
+}
+
+func (r *Recorder) SendNotification(note notify.Notification) (uint32, error) {
+	id, err := r.Notifier.SendNotification(note)
+	e := Event{Kind: EventNotify, ID: id, Notification: &note}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	r.write(e)
+	return id, err
+}
+
+func (r *Recorder) NotificationClosed() <-chan *notify.NotificationClosedSignal {
+	return r.closer
+}
+
+func (r *Recorder) ActionInvoked() <-chan *notify.ActionInvokedSignal {
+	return r.action
+}
+
+// WithApp returns a child sending through r, so its notifications are
+// recorded too.
+func (r *Recorder) WithApp(appName, appIcon string) notify.Notifier {
+	return notify.NewChild(r, appName, appIcon)
+}
+
+// Err returns the first error writing the transcript, if any.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(e Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(e)
+}
+
+// Replay feeds the signals of a transcript into fake, in recorded order.
+// Each signal is delivered once the code under test has sent all the
+// notifications recorded before it; recorded IDs are translated to the IDs
+// fake handed out. Failed sends in the transcript are replayed as successful.
+//
+// Replay is meant to run in its own goroutine while the code under test
+// uses fake. It returns when all events are replayed or ctx is done.
+func Replay(ctx context.Context, fake *Fake, events []Event) error {
+	ids := make(map[uint32]uint32)
+	sent := 0
+	for _, e := range events {
+		switch e.Kind {
+		case EventNotify:
+			sent++
+			got, err := fake.WaitSent(ctx, sent)
+			if err != nil {
+				return err
+			}
+			ids[e.ID] = got[sent-1].ID
+		case EventClosed:
+			fake.EmitNotificationClosed(translate(ids, e.ID), e.Reason)
+		case EventAction:
+			fake.EmitActionInvoked(translate(ids, e.ID), e.ActionKey)
+		default:
+			return fmt.Errorf("unknown event kind: %v", e.Kind)
+		}
+	}
+	return nil
+}
+
+// translate maps a recorded ID to the one handed out by the fake. IDs of
+// notifications sent by other applications are kept as they are.
+func translate(ids map[uint32]uint32, id uint32) uint32 {
+	if got, ok := ids[id]; ok {
+		return got
+	}
+	return id
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// NullNotifier is a Notifier that discards everything, for benchmarks and
+// flags like --no-notifications. Sends succeed with IDs counting up from 1,
+// or ReplacesID if set, and no signals are ever emitted.
+type NullNotifier struct {
+	lastID    uint32 // accessed atomically
+	closer    chan *NotificationClosedSignal
+	action    chan *ActionInvokedSignal
+	closeOnce sync.Once
+}
+
+// NewNullNotifier creates a NullNotifier.
+func NewNullNotifier() *NullNotifier {
+	return &NullNotifier{
+		closer: make(chan *NotificationClosedSignal),
+		action: make(chan *ActionInvokedSignal),
+	}
+}
+
+// SendNotification returns an ID for note without sending it.
+func (n *NullNotifier) SendNotification(note Notification) (uint32, error) {
+	if note.ReplacesID != 0 {
+		return note.ReplacesID, nil
+	}
+	return atomic.AddUint32(&n.lastID, 1), nil
+}
+
+// GetCapabilities returns no capabilities.
+func (n *NullNotifier) GetCapabilities() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetServerInformation returns a server named "null".
+func (n *NullNotifier) GetServerInformation() (ServerInformation, error) {
+	return ServerInformation{Name: "null", Vendor: "esiqveland"}, nil
+}
+
+// CloseNotification does nothing.
+func (n *NullNotifier) CloseNotification(id uint32) (bool, error) {
+	return true, nil
+}
+
+// CloseNotifications does nothing.
+func (n *NullNotifier) CloseNotifications(ids ...uint32) error {
+	return nil
+}
+
+// NotificationClosed returns a channel that is closed by Close.
+func (n *NullNotifier) NotificationClosed() <-chan *NotificationClosedSignal {
+	return n.closer
+}
+
+// ActionInvoked returns a channel that is closed by Close.
+func (n *NullNotifier) ActionInvoked() <-chan *ActionInvokedSignal {
+	return n.action
+}
+
+// WithApp returns a child of n, see NewChild.
+func (n *NullNotifier) WithApp(appName, appIcon string) Notifier {
+	return NewChild(n, appName, appIcon)
+}
+
+// Close closes the signal channels.
+func (n *NullNotifier) Close() error {
+	n.closeOnce.Do(func() {
+		close(n.closer)
+		close(n.action)
+	})
+	return nil
+}
+
+// CollectorNotifier is a NullNotifier that keeps what is sent and closed,
+// for tests that only look at what an application sends. For scripted
+// signals and failures see notifytest.Fake.
+type CollectorNotifier struct {
+	*NullNotifier
+
+	lock   sync.Mutex
+	sent   []Notification
+	closed []uint32
+}
+
+// NewCollectorNotifier creates a CollectorNotifier.
+func NewCollectorNotifier() *CollectorNotifier {
+	return &CollectorNotifier{NullNotifier: NewNullNotifier()}
+}
+
+// SendNotification records note, with its ID in ReplacesID, and returns
+// the ID.
+func (c *CollectorNotifier) SendNotification(note Notification) (uint32, error) {
+	id, _ := c.NullNotifier.SendNotification(note)
+	note = note.Clone()
+	note.ReplacesID = id
+	c.lock.Lock()
+	c.sent = append(c.sent, note)
+	c.lock.Unlock()
+	return id, nil
+}
+
+// CloseNotification records id as closed.
+func (c *CollectorNotifier) CloseNotification(id uint32) (bool, error) {
+	return true, c.CloseNotifications(id)
+}
+
+// CloseNotifications records ids as closed.
+func (c *CollectorNotifier) CloseNotifications(ids ...uint32) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = append(c.closed, ids...)
+	return nil
+}
+
+// WithApp returns a child of c, see NewChild.
+func (c *CollectorNotifier) WithApp(appName, appIcon string) Notifier {
+	return NewChild(c, appName, appIcon)
+}
+
+// Sent returns the notifications sent so far, in order. ReplacesID holds
+// the ID each was given.
+func (c *CollectorNotifier) Sent() []Notification {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]Notification{}, c.sent...)
+}
+
+// Closed returns the IDs closed so far, in order.
+func (c *CollectorNotifier) Closed() []uint32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]uint32{}, c.closed...)
+}
+
+// Reset forgets what was sent and closed.
+func (c *CollectorNotifier) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.sent, c.closed = nil, nil
+}
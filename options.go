@@ -0,0 +1,43 @@
+package notify
+
+// Option configures a Notifier created with New.
+type Option func(*notifier)
+
+// WithoutSenderPID stops the Notifier from setting the sender-pid hint
+// on every notification it sends.
+func WithoutSenderPID() Option {
+	return func(n *notifier) {
+		n.senderPID = false
+	}
+}
+
+// WithBusAddress makes New connect to the bus at address instead of using
+// a connection passed in, e.g. "unix:path=/run/user/1000/bus" or
+// "tcp:host=10.0.0.2,port=5555". Pass a nil conn to New along with it.
+// Useful for remote sessions, containers, and tests running their own
+// dbus-daemon.
+//
+// The connection is owned by the Notifier and closed by Close().
+func WithBusAddress(address string) Option {
+	return func(n *notifier) {
+		n.address = address
+	}
+}
+
+// WithoutClosingConn makes Close leave the connection passed to New open,
+// for connections the application keeps using, like the one returned by
+// dbus.SessionBus(). By default Close closes it.
+func WithoutClosingConn() Option {
+	return func(n *notifier) {
+		n.keepConn = true
+	}
+}
+
+// WithFlushOnClose makes Close send the notifications held back by quiet
+// hours instead of discarding them, so none are lost when a long-running
+// host shuts a Notifier down.
+func WithFlushOnClose() Option {
+	return func(n *notifier) {
+		n.flushOnClose = true
+	}
+}
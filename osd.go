@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Hints of Ubuntu's Notify-OSD, which advertises them as capabilities of the
+// same names. Not part of the spec.
+const (
+	// HintCanonicalAppend makes the body of the notification be appended
+	// to a shown one with the same summary, like the lines of a chat,
+	// STRING "true".
+	HintCanonicalAppend = "x-canonical-append"
+	// HintCanonicalSynchronous makes the notification a confirmation
+	// bubble, like that of a volume key, shown at once and replacing the
+	// previous one of the same kind, STRING with the kind, e.g. "volume".
+	HintCanonicalSynchronous = "x-canonical-private-synchronous"
+)
+
+// hasCapability reports whether the server of n advertises capability.
+func hasCapability(n Notifier, capability string) bool {
+	caps, err := n.GetCapabilities()
+	if err != nil {
+		return false
+	}
+	for _, c := range caps {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendTo adds text as a new line to the body of the notification of h.
+// Servers advertising HintCanonicalAppend append it to the bubble shown;
+// elsewhere the notification is replaced by one with the longer body.
+// It is queued behind the other updates of h and, like them, fails with
+// ErrDismissed once the notification was closed.
+func AppendTo(h *Handle, text string) error {
+	appends := hasCapability(h.n, HintCanonicalAppend)
+	return h.do(func() error {
+		h.lock.Lock()
+		id, note, closed := h.id, h.note.Clone(), h.closed
+		h.lock.Unlock()
+		if closed != nil {
+			return closed
+		}
+		body := text
+		if note.Body != "" {
+			body = note.Body + "\n" + text
+		}
+		send := note
+		if appends {
+			// the server finds the bubble by its summary.
+			send = note.WithBody(text).WithHint(HintCanonicalAppend, "true")
+			send.ReplacesID = 0
+		} else {
+			send.Body = body
+			send.ReplacesID = id
+		}
+		newID, err := h.n.SendNotification(send)
+		if err != nil {
+			return err
+		}
+		note.Body = body
+		h.lock.Lock()
+		h.id, h.note = newID, note
+		h.lock.Unlock()
+		return nil
+	})
+}
+
+var (
+	syncLock    sync.Mutex
+	syncBubbles = make(map[syncBubble]uint32) // the IDs shown by SyncBubble
+)
+
+type syncBubble struct {
+	n    Notifier
+	kind string
+}
+
+// syncIcons are the icons of the kinds of confirmation bubbles Notify-OSD
+// has, by kind and the value from which on they are used.
+var syncIcons = map[string][]struct {
+	from int
+	icon string
+}{
+	"volume": {
+		{0, "notification-audio-volume-muted"},
+		{1, "notification-audio-volume-low"},
+		{34, "notification-audio-volume-medium"},
+		{67, "notification-audio-volume-high"},
+	},
+	"brightness": {
+		{0, "notification-display-brightness-off"},
+		{1, "notification-display-brightness-low"},
+		{34, "notification-display-brightness-medium"},
+		{67, "notification-display-brightness-high"},
+	},
+}
+
+// SyncBubble shows value, in percent, in a confirmation bubble of kind, e.g.
+// "volume" or "brightness", as keyboard keys do. Each bubble replaces the
+// previous one of its kind. Servers advertising HintCanonicalSynchronous
+// show it as such; elsewhere it is a transient notification with a
+// progress bar, replaced through HintStackTag and its ID.
+func SyncBubble(n Notifier, kind string, value int) (uint32, error) {
+	note := Notification{
+		Summary:       kind,
+		Body:          fmt.Sprintf("%d%%", value),
+		ExpireTimeout: -1,
+	}
+	if kind != "" {
+		note.Summary = strings.ToUpper(kind[:1]) + kind[1:]
+	}
+	for _, i := range syncIcons[kind] {
+		if value >= i.from {
+			note.AppIcon = i.icon
+		}
+	}
+	note.SetProgress(value)
+	note.SetHint(HintTransient, true)
+	key := syncBubble{n: n, kind: kind}
+	if hasCapability(n, HintCanonicalSynchronous) {
+		note.SetHint(HintCanonicalSynchronous, kind)
+	} else {
+		note.SetHint(HintStackTag, "x-notify-sync-"+kind)
+		syncLock.Lock()
+		note.ReplacesID = syncBubbles[key]
+		syncLock.Unlock()
+	}
+	id, err := n.SendNotification(note)
+	if err != nil {
+		return 0, err
+	}
+	syncLock.Lock()
+	syncBubbles[key] = id
+	syncLock.Unlock()
+	return id, nil
+}
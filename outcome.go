@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Outcome is how a notification ended, from the signals passed to its
+// Handle.
+type Outcome int
+
+const (
+	OutcomePending         Outcome = iota // still shown, or no signal passed yet
+	OutcomeActionInvoked                  // the user invoked an action, see Handle.Action
+	OutcomeDismissed                      // the user dismissed it, ReasonDismissedByUser
+	OutcomeExpired                        // it timed out, ReasonExpired
+	OutcomeClosedByCall                   // Close or CloseNotification, ReasonClosedByCall
+	OutcomeServerRestarted                // lost with its server, see WithRestartDetection
+	OutcomeUnknown                        // closed for a reason the server didn't tell
+	OutcomeTimeout                        // no signal within the ResponseTimeout
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomePending:
+		return "Pending"
+	case OutcomeActionInvoked:
+		return "ActionInvoked"
+	case OutcomeDismissed:
+		return "Dismissed"
+	case OutcomeExpired:
+		return "Expired"
+	case OutcomeClosedByCall:
+		return "ClosedByCall"
+	case OutcomeServerRestarted:
+		return "ServerRestarted"
+	case OutcomeTimeout:
+		return "Timeout"
+	default:
+		return "Unknown"
+	}
+}
+
+// MarshalText encodes o as its name, e.g. in JSON.
+func (o Outcome) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText decodes the name of an outcome.
+func (o *Outcome) UnmarshalText(text []byte) error {
+	for c := OutcomePending; c <= OutcomeTimeout; c++ {
+		if c.String() == string(text) {
+			*o = c
+			return nil
+		}
+	}
+	return fmt.Errorf("notify: unknown outcome %q", text)
+}
+
+// Acknowledged reports whether o means a user saw the notification and
+// acted on it: an action was invoked or they dismissed it.
+func (o Outcome) Acknowledged() bool {
+	return o == OutcomeActionInvoked || o == OutcomeDismissed
+}
+
+// Outcome returns the outcome of a notification closed for r.
+func (r Reason) Outcome() Outcome {
+	switch r {
+	case ReasonDismissedByUser:
+		return OutcomeDismissed
+	case ReasonExpired:
+		return OutcomeExpired
+	case ReasonClosedByCall:
+		return OutcomeClosedByCall
+	case ReasonServerRestarted:
+		return OutcomeServerRestarted
+	default:
+		return OutcomeUnknown
+	}
+}
+
+// Outcome returns how the notification of h ended so far. Pass the signals
+// to HandleAction and HandleClosed for it to be known.
+func (h *Handle) Outcome() Outcome {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.outcome
+}
+
+// Acknowledged reports whether the user invoked an action on the
+// notification of h or dismissed it, as opposed to it expiring or being
+// closed by a call.
+func (h *Handle) Acknowledged() bool {
+	return h.Outcome().Acknowledged()
+}
+
+// Action returns the key of the action invoked, for OutcomeActionInvoked.
+func (h *Handle) Action() string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.action
+}
+
+// WaitOutcome blocks until the outcome of the notification of h is known,
+// or ctx is done, and returns it.
+func (h *Handle) WaitOutcome(ctx context.Context) (Outcome, error) {
+	select {
+	case <-h.decided:
+		return h.Outcome(), nil
+	case <-ctx.Done():
+		return OutcomePending, ctx.Err()
+	}
+}
+
+// HandleAction records the action invoked if signal is for the notification
+// of h. Call it from the loop reading ActionInvoked.
+func (h *Handle) HandleAction(signal *ActionInvokedSignal) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if signal.Id == h.id && h.decide(OutcomeActionInvoked) {
+		h.action = signal.ActionKey
+	}
+}
+
+// decide sets the outcome unless it is known already, and reports whether
+// it did. h.lock must be held.
+func (h *Handle) decide(o Outcome) bool {
+	if h.outcome != OutcomePending {
+		return false
+	}
+	h.outcome = o
+	close(h.decided)
+	if h.timeout != nil {
+		h.timeout.Stop()
+	}
+	return true
+}
+
+// ResponseTimeout gives the notification of a Handle d to end, independent
+// of its ExpireTimeout: after d without an outcome it is OutcomeTimeout,
+// which returns WaitOutcome, and signals coming later are ignored. Use it
+// with servers that never emit signals for some notifications, e.g.
+// resident ones, so waiting goroutines don't leak. The Notifier's clock
+// times d, see WithClock.
+func ResponseTimeout(d time.Duration) HandleOption {
+	return func(h *Handle) {
+		h.responseTimeout = d
+	}
+}
+
+// startTimeout starts the response timeout of h, if set.
+func (h *Handle) startTimeout() {
+	if h.responseTimeout <= 0 {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.timeout = ClockOf(h.n).AfterFunc(h.responseTimeout, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		h.decide(OutcomeTimeout)
+	})
+}
+
+// ClockOf returns the clock of n, see WithClock and
+// ConsoleNotifier.SetClock, or SystemClock for other Notifiers. Helpers
+// wrapping a Notifier time themselves by it.
+func ClockOf(n Notifier) Clock {
+	if c, ok := n.(*child); ok {
+		n = c.Notifier
+	}
+	switch nn := n.(type) {
+	case *notifier:
+		return nn.clock
+	case *ConsoleNotifier:
+		return nn.clock
+	}
+	return SystemClock
+}
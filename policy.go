@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/esiqveland/notify/internal/configfile"
+)
+
+// Policy decides at runtime which notifications are sent, so applications
+// can offer settings like "mute build notifications" backed by it:
+// everything can be disabled, and notifications muted by category (the
+// category hint) or tag (HintStackTag).
+//
+// Muting a category also mutes its subcategories: muting "email" mutes
+// "email.arrived" and "email.bounced".
+//
+// A Policy is safe for concurrent use, and can be shared by Notifiers.
+type Policy struct {
+	lock     sync.RWMutex
+	path     string
+	disabled bool
+	category map[string]bool
+	tag      map[string]bool
+}
+
+// policyFile is the JSON form of a Policy.
+type policyFile struct {
+	Disabled   bool     `json:"disabled"`
+	Categories []string `json:"muted_categories"`
+	Tags       []string `json:"muted_tags"`
+}
+
+// NewPolicy returns a Policy allowing everything, kept in memory only.
+func NewPolicy() *Policy {
+	return &Policy{
+		category: make(map[string]bool),
+		tag:      make(map[string]bool),
+	}
+}
+
+// LoadPolicy reads a Policy from the JSON file at path, and saves it there
+// on every change. A missing file gives a Policy allowing everything.
+func LoadPolicy(path string) (*Policy, error) {
+	p := NewPolicy()
+	p.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f policyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	p.disabled = f.Disabled
+	for _, c := range f.Categories {
+		p.category[c] = true
+	}
+	for _, t := range f.Tags {
+		p.tag[t] = true
+	}
+	return p, nil
+}
+
+// WithPolicy makes the Notifier drop notifications p doesn't allow.
+// SendNotification returns ID 0 and no error for them.
+func WithPolicy(p *Policy) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.policy = p
+	}
+}
+
+// SetDisabled disables or enables all notifications.
+func (p *Policy) SetDisabled(disabled bool) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.disabled = disabled
+	return p.save()
+}
+
+// Disabled reports whether all notifications are disabled.
+func (p *Policy) Disabled() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.disabled
+}
+
+// MuteCategory mutes notifications of category and its subcategories.
+func (p *Policy) MuteCategory(category string) error {
+	return p.set(p.category, category, true)
+}
+
+// UnmuteCategory undoes MuteCategory.
+func (p *Policy) UnmuteCategory(category string) error {
+	return p.set(p.category, category, false)
+}
+
+// MuteTag mutes notifications carrying tag.
+func (p *Policy) MuteTag(tag string) error {
+	return p.set(p.tag, tag, true)
+}
+
+// UnmuteTag undoes MuteTag.
+func (p *Policy) UnmuteTag(tag string) error {
+	return p.set(p.tag, tag, false)
+}
+
+func (p *Policy) set(m map[string]bool, key string, muted bool) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if muted {
+		m[key] = true
+	} else {
+		delete(m, key)
+	}
+	return p.save()
+}
+
+// Allows reports whether note may be sent.
+func (p *Policy) Allows(note Notification) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.disabled {
+		return false
+	}
+	if tag := hintString(note, HintStackTag); tag != "" && p.tag[tag] {
+		return false
+	}
+	category := hintString(note, HintCategory)
+	for category != "" {
+		if p.category[category] {
+			return false
+		}
+		i := strings.LastIndexByte(category, '.')
+		if i < 0 {
+			break
+		}
+		category = category[:i]
+	}
+	return true
+}
+
+// save writes the policy to its file, if it has one. Must be called with
+// p.lock held.
+func (p *Policy) save() error {
+	if p.path == "" {
+		return nil
+	}
+	f := policyFile{
+		Disabled:   p.disabled,
+		Categories: sortedKeys(p.category),
+		Tags:       sortedKeys(p.tag),
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0700); err != nil {
+		return err
+	}
+	return configfile.Write(p.path, append(data, '\n'))
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
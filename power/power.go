@@ -0,0 +1,276 @@
+// Package power shows notifications for battery events reported by UPower:
+// low and critical battery levels, and the charger being plugged in or out.
+//
+//	system, err := dbus.SystemBus()
+//	...
+//	w, err := power.Watch(system, notifier, power.Config{})
+//	...
+//	defer w.Close()
+package power
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	upowerDestination     = "org.freedesktop.UPower"
+	displayDevicePath     = "/org/freedesktop/UPower/devices/DisplayDevice"
+	upowerDeviceInterface = "org.freedesktop.UPower.Device"
+
+	propertiesInterface = "org.freedesktop.DBus.Properties"
+	propertiesChanged   = "org.freedesktop.DBus.Properties.PropertiesChanged"
+
+	signalBufferSize = 10
+)
+
+// State is the charging state of a UPower device.
+type State uint32
+
+const (
+	StateUnknown          State = 0
+	StateCharging         State = 1
+	StateDischarging      State = 2
+	StateEmpty            State = 3
+	StateFullyCharged     State = 4
+	StatePendingCharge    State = 5
+	StatePendingDischarge State = 6
+)
+
+// Config configures a Watcher. The zero value is usable.
+type Config struct {
+	Low      float64 // percentage for the low battery warning, default 20
+	Critical float64 // percentage for the critical battery warning, default 5
+
+	Charging bool // notify when the charger is plugged in or out
+	Full     bool // notify when the battery is fully charged
+
+	AppName string // defaults to "Power"
+}
+
+// Watcher watches the battery through UPower's display device, which
+// combines all batteries of the system, and notifies about its events.
+// All its notifications replace each other.
+type Watcher struct {
+	conn   *dbus.Conn
+	n      notify.Notifier
+	config Config
+	signal chan *dbus.Signal
+	done   chan struct{}
+
+	lock    sync.Mutex
+	percent float64
+	state   State
+	present bool
+	level   level  // the warning last given
+	id      uint32 // of the last notification
+}
+
+// level is a battery level band.
+type level int
+
+const (
+	levelNormal level = iota
+	levelLow
+	levelCritical
+)
+
+// Watch starts watching UPower on the system bus connection system and
+// sends notifications through n.
+//
+// Caller is responsible to call Close() to stop watching.
+func Watch(system *dbus.Conn, n notify.Notifier, config Config) (*Watcher, error) {
+	if config.Low == 0 {
+		config.Low = 20
+	}
+	if config.Critical == 0 {
+		config.Critical = 5
+	}
+	if config.AppName == "" {
+		config.AppName = "Power"
+	}
+	w := &Watcher{
+		conn:   system,
+		n:      n,
+		config: config,
+		signal: make(chan *dbus.Signal, signalBufferSize),
+		done:   make(chan struct{}),
+	}
+	if err := system.AddMatchSignal(w.match()...); err != nil {
+		return nil, err
+	}
+	system.Signal(w.signal)
+
+	props := make(map[string]dbus.Variant)
+	obj := system.Object(upowerDestination, displayDevicePath)
+	if err := obj.Call(propertiesInterface+".GetAll", 0, upowerDeviceInterface).Store(&props); err != nil {
+		w.stop()
+		return nil, err
+	}
+	w.lock.Lock()
+	// no notifications for the state at startup, only for a battery that is
+	// already low.
+	w.state = w.currentState(props)
+	w.update(props)
+	w.lock.Unlock()
+
+	go w.run()
+	return w, nil
+}
+
+func (w *Watcher) match() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(displayDevicePath),
+		dbus.WithMatchInterface(propertiesInterface),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, upowerDeviceInterface),
+	}
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case signal := <-w.signal:
+			if signal == nil {
+				return
+			}
+			if signal.Path != displayDevicePath || signal.Name != propertiesChanged {
+				continue
+			}
+			var iface string
+			var changed map[string]dbus.Variant
+			var invalidated []string
+			if err := dbus.Store(signal.Body, &iface, &changed, &invalidated); err != nil || iface != upowerDeviceInterface {
+				continue
+			}
+			w.lock.Lock()
+			w.update(changed)
+			w.lock.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Percentage returns the last known battery level.
+func (w *Watcher) Percentage() float64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.percent
+}
+
+// currentState returns the State in props, or the last known one.
+func (w *Watcher) currentState(props map[string]dbus.Variant) State {
+	if v, ok := props["State"]; ok {
+		if s, ok := v.Value().(uint32); ok {
+			return State(s)
+		}
+	}
+	return w.state
+}
+
+// update applies changed properties and notifies about what changed.
+// w.lock must be held.
+func (w *Watcher) update(props map[string]dbus.Variant) {
+	if v, ok := props["IsPresent"]; ok {
+		w.present, _ = v.Value().(bool)
+	}
+	if v, ok := props["Percentage"]; ok {
+		w.percent, _ = v.Value().(float64)
+	}
+	if !w.present {
+		return
+	}
+	state := w.currentState(props)
+	if state != w.state {
+		w.state = state
+		w.stateChanged()
+	}
+	w.checkLevel()
+}
+
+func (w *Watcher) stateChanged() {
+	switch w.state {
+	case StateCharging:
+		w.level = levelNormal
+		if w.config.Charging {
+			w.notify("Charging", "battery-good-charging", notify.UrgencyLow)
+		}
+	case StateDischarging:
+		if w.config.Charging {
+			w.notify("Running on battery", "battery-good", notify.UrgencyLow)
+		}
+	case StateFullyCharged:
+		if w.config.Full {
+			w.notify("Battery fully charged", "battery-full-charged", notify.UrgencyLow)
+		}
+	}
+}
+
+// checkLevel warns once when the battery drops into the low or critical band.
+func (w *Watcher) checkLevel() {
+	if w.state != StateDischarging {
+		return
+	}
+	current := levelNormal
+	switch {
+	case w.percent <= w.config.Critical:
+		current = levelCritical
+	case w.percent <= w.config.Low:
+		current = levelLow
+	}
+	if current <= w.level {
+		if current == levelNormal {
+			w.level = levelNormal
+		}
+		return
+	}
+	w.level = current
+	if current == levelCritical {
+		w.notify("Battery critically low", "battery-caution", notify.UrgencyCritical)
+		return
+	}
+	w.notify("Battery low", "battery-low", notify.UrgencyNormal)
+}
+
+// notify replaces the previous notification with summary and the level.
+func (w *Watcher) notify(summary, icon string, u notify.Urgency) {
+	percent := int(math.Round(w.percent))
+	note := notify.Notification{
+		AppName:       w.config.AppName,
+		AppIcon:       icon,
+		ReplacesID:    w.id,
+		Summary:       summary,
+		Body:          fmt.Sprintf("%d%% remaining", percent),
+		ExpireTimeout: -1,
+	}
+	if w.state == StateCharging || w.state == StateFullyCharged {
+		note.Body = fmt.Sprintf("%d%% charged", percent)
+	}
+	note.SetUrgency(u)
+	note.SetProgress(percent)
+	note.SetHint(notify.HintCategory, "device")
+	id, err := w.n.SendNotification(note)
+	if err != nil {
+		log.Printf("power: error sending notification: %v", err)
+		return
+	}
+	w.id = id
+}
+
+func (w *Watcher) stop() {
+	w.conn.RemoveSignal(w.signal)
+	w.conn.RemoveMatchSignal(w.match()...)
+}
+
+// Close stops watching. The connection and the Notifier are left open.
+func (w *Watcher) Close() error {
+	close(w.done)
+	w.stop()
+	return nil
+}
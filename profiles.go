@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify/internal/configfile"
+	"github.com/godbus/dbus/v5"
+)
+
+// Profiles let end users customize the notifications of applications by
+// category, without changes to the applications: the icon, urgency, timeout
+// and sound, and which backend delivers them. They are read from a JSON file,
+// by default ProfilesPath:
+//
+//	{
+//	  "categories": {
+//	    "email": {"urgency": 0, "timeout": 3000},
+//	    "im.received": {"sound": "message-new-instant", "backend": "phone"}
+//	  }
+//	}
+//
+// A profile for a category also applies to its subcategories, "email" to
+// "email.arrived"; the most specific one wins. Values set in a profile
+// override what the application set.
+//
+// The file is checked for changes at send time, so edits take effect
+// without restarting applications. A Profiles is safe for concurrent use.
+type Profiles struct {
+	path string
+
+	lock     sync.Mutex
+	modified time.Time
+	profiles map[string]Profile
+}
+
+// Profile is the customization of one category.
+type Profile struct {
+	Icon    string   `json:"icon,omitempty"`
+	Urgency *Urgency `json:"urgency,omitempty"`
+	Timeout *int32   `json:"timeout,omitempty"` // ExpireTimeout in milliseconds
+	Sound   string   `json:"sound,omitempty"`   // themed sound name, see HintSoundName
+	// MaxActions is how many actions a Menu shows at a time, see ShowMenu.
+	MaxActions int `json:"max_actions,omitempty"`
+	// Backend names the Notifier to deliver through, see WithBackend.
+	Backend string `json:"backend,omitempty"`
+}
+
+// profilesFile is the JSON form of Profiles.
+type profilesFile struct {
+	Categories map[string]Profile `json:"categories"`
+}
+
+// ProfilesPath returns the default location of the profiles file,
+// notify/profiles.json in the XDG config directory.
+func ProfilesPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "notify", "profiles.json")
+}
+
+// LoadProfiles reads the profiles file at path. A missing file gives no
+// profiles, until it is created.
+func LoadProfiles(path string) (*Profiles, error) {
+	p := &Profiles{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// WithProfiles makes the Notifier apply p to the notifications it sends.
+func WithProfiles(p *Profiles) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.profiles = p
+	}
+}
+
+// WithBackend registers b under name, for profiles routing notifications to
+// it. The IDs of routed notifications are b's, close them through b.
+func WithBackend(name string, b Notifier) Option {
+	return func(n *notifier) {
+		n.staged = true
+		if n.backends == nil {
+			n.backends = make(map[string]Notifier)
+		}
+		n.backends[name] = b
+	}
+}
+
+// reload reads the file if it changed since it was last read.
+// p.lock must be held, except from LoadProfiles.
+func (p *Profiles) reload() error {
+	return configfile.Reload(p.path, &p.modified, func(data []byte) error {
+		var f profilesFile
+		if data != nil {
+			if err := json.Unmarshal(data, &f); err != nil {
+				return fmt.Errorf("notify: %v: %w", p.path, err)
+			}
+		}
+		p.profiles = f.Categories
+		return nil
+	})
+}
+
+// Lookup returns the profile for category, and whether there is one.
+func (p *Profiles) Lookup(category string) (Profile, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err := p.reload(); err != nil {
+		// keep the profiles we have, a half written file must not reset
+		// them.
+		log.Printf("error reloading notification profiles: %v", err)
+	}
+	for category != "" {
+		if profile, ok := p.profiles[category]; ok {
+			return profile, true
+		}
+		i := strings.LastIndexByte(category, '.')
+		if i < 0 {
+			break
+		}
+		category = category[:i]
+	}
+	return Profile{}, false
+}
+
+// apply returns note customized by profile.
+func (profile Profile) apply(note Notification) Notification {
+	if profile.Icon != "" {
+		note.AppIcon = profile.Icon
+	}
+	if profile.Timeout != nil {
+		note.ExpireTimeout = *profile.Timeout
+	}
+	if profile.Urgency != nil {
+		note = withHint(note, HintUrgency, dbus.MakeVariant(byte(*profile.Urgency)))
+	}
+	if profile.Sound != "" {
+		note = withHint(note, HintSoundName, dbus.MakeVariant(profile.Sound))
+	}
+	return note
+}
+
+// applyProfile customizes note by its profile, and returns the backend it
+// is routed to, if any.
+func (n *notifier) applyProfile(note Notification) (Notification, Notifier) {
+	if n.profiles == nil {
+		return note, nil
+	}
+	profile, ok := n.profiles.Lookup(hintString(note, HintCategory))
+	if !ok {
+		return note, nil
+	}
+	note = profile.apply(note)
+	if profile.Backend == "" {
+		return note, nil
+	}
+	b, ok := n.backends[profile.Backend]
+	if !ok {
+		n.tracef("no backend %q, sending as usual", profile.Backend)
+		return note, nil
+	}
+	return note, b
+}
+
+// withHint returns note with hint key set to value, in a copy of the Hints
+// map.
+func withHint(note Notification, key string, value dbus.Variant) Notification {
+	note = withoutHints(note)
+	note.Hints[key] = value
+	return note
+}
@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// QuietHours holds back non-critical notifications during recurring
+// periods, e.g. nights and weekends, and optionally while the do not
+// disturb mode of the server is on.
+// Notifications with UrgencyCritical are always delivered.
+//
+// Held back notifications are queued and delivered when the quiet period
+// and do not disturb end, unless Drop is set. With Digest set, two or more queued
+// notifications are collapsed into a single "N notifications while you were
+// away" notification listing their summaries.
+// SendNotification returns ID 0 for notifications that were held back;
+// the server never hands out 0 as an ID.
+type QuietHours struct {
+	Periods []QuietPeriod
+	Drop    bool // discard held back notifications instead of queueing them
+	Digest  bool // deliver queued notifications as one digest notification
+	// DoNotDisturb also holds notifications back while the server is in do
+	// not disturb mode, for the servers Diagnose knows to report it. The
+	// server is asked in the background at most every few seconds while
+	// sending, so a change takes effect from the send after; and it is
+	// polled while notifications are queued. Never through the portal.
+	DoNotDisturb bool
+}
+
+const (
+	// dndPollInterval is how often the do not disturb mode is checked while
+	// notifications are queued for it to end.
+	dndPollInterval = 30 * time.Second
+	// dndMaxAge is how long the mode is taken to stay as last asked.
+	dndMaxAge = 5 * time.Second
+	// dndTimeout bounds asking the server.
+	dndTimeout = time.Second
+)
+
+// QuietPeriod is a daily time range, given as offsets from local midnight.
+// A period whose Start is after its End spans midnight, e.g. 22:00 to 07:00.
+type QuietPeriod struct {
+	Start time.Duration
+	End   time.Duration
+	Days  []time.Weekday // days the period starts on. Empty means every day.
+}
+
+// WithQuietHours makes the Notifier hold back non-critical notifications
+// during the periods in q.
+func WithQuietHours(q QuietHours) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.quiet = &q
+	}
+}
+
+// activeUntil reports whether t is within quiet hours, and if so, when
+// the quiet hours end.
+func (q *QuietHours) activeUntil(t time.Time) (time.Time, bool) {
+	var end time.Time
+	active := false
+	for _, p := range q.Periods {
+		if e, ok := p.activeUntil(t); ok && e.After(end) {
+			end, active = e, true
+		}
+	}
+	return end, active
+}
+
+func (p QuietPeriod) activeUntil(t time.Time) (time.Time, bool) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if p.Start <= p.End {
+		if p.onDay(midnight.Weekday()) && offset >= p.Start && offset < p.End {
+			return midnight.Add(p.End), true
+		}
+		return time.Time{}, false
+	}
+	// spans midnight: either started today, or started yesterday.
+	if p.onDay(midnight.Weekday()) && offset >= p.Start {
+		return midnight.AddDate(0, 0, 1).Add(p.End), true
+	}
+	yesterday := midnight.AddDate(0, 0, -1)
+	if p.onDay(yesterday.Weekday()) && offset < p.End {
+		return midnight.Add(p.End), true
+	}
+	return time.Time{}, false
+}
+
+func (p QuietPeriod) onDay(day time.Weekday) bool {
+	if len(p.Days) == 0 {
+		return true
+	}
+	for _, d := range p.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// holdBack queues or drops note if quiet hours or do not disturb are
+// active. It returns false if note should be delivered right away.
+func (n *notifier) holdBack(note Notification) bool {
+	if n.quiet == nil || urgency(note) == UrgencyCritical {
+		return false
+	}
+	dnd := n.doNotDisturb()
+	n.quietLock.Lock()
+	defer n.quietLock.Unlock()
+	now := n.clock.Now()
+	end, ok := n.quiet.activeUntil(now)
+	if !ok && !dnd {
+		return false
+	}
+	if n.quiet.Drop {
+		return true
+	}
+	n.quietQueue = append(n.quietQueue, note)
+	if n.quietTimer == nil {
+		n.quietTimer = n.clock.AfterFunc(quietWait(now, end, ok), n.releaseQuiet)
+	}
+	return true
+}
+
+// quietWait returns how long to wait before checking again whether held
+// back notifications can be released: until end if quiet hours are active,
+// otherwise until do not disturb is polled.
+func quietWait(now, end time.Time, active bool) time.Duration {
+	if active {
+		return end.Sub(now)
+	}
+	return dndPollInterval
+}
+
+// releaseQuiet delivers notifications queued during quiet hours and do not
+// disturb.
+func (n *notifier) releaseQuiet() {
+	dnd := n.quiet.DoNotDisturb && n.askDoNotDisturb()
+	n.quietLock.Lock()
+	n.quietTimer = nil
+	now := n.clock.Now()
+	// periods can be back to back, wait for the last one to end.
+	if end, ok := n.quiet.activeUntil(now); ok || dnd {
+		n.quietTimer = n.clock.AfterFunc(quietWait(now, end, ok), n.releaseQuiet)
+		n.quietLock.Unlock()
+		return
+	}
+	queue := n.quietQueue
+	n.quietQueue = nil
+	n.quietLock.Unlock()
+	n.sendHeldBack(queue)
+}
+
+// doNotDisturb reports whether notifications are held back for the do not
+// disturb mode of the server, as last asked. Sends don't wait for the
+// server, or gsettings on GNOME: an answer older than dndMaxAge is
+// refreshed in the background, for the sends after. A server that doesn't
+// tell is taken to be off.
+func (n *notifier) doNotDisturb() bool {
+	if !n.quiet.DoNotDisturb || n.dryRun || n.portal {
+		return false
+	}
+	n.quietLock.Lock()
+	on := n.dndOn
+	stale := n.dndAt.IsZero() || n.clock.Now().Sub(n.dndAt) >= dndMaxAge
+	ask := stale && !n.dndAsking
+	if ask {
+		n.dndAsking = true
+	}
+	n.quietLock.Unlock()
+	if ask {
+		if n.addHandler() {
+			go func() {
+				defer n.handlers.Done()
+				n.askDoNotDisturb()
+			}()
+		} else {
+			n.quietLock.Lock()
+			n.dndAsking = false
+			n.quietLock.Unlock()
+		}
+	}
+	return on
+}
+
+// askDoNotDisturb asks the server whether its do not disturb mode is on,
+// and remembers the answer for doNotDisturb.
+func (n *notifier) askDoNotDisturb() bool {
+	if n.dryRun || n.portal {
+		return false
+	}
+	// asked without the lock, it is a call to the server.
+	ctx, cancel := context.WithTimeout(context.Background(), dndTimeout)
+	defer cancel()
+	on, _, _ := doNotDisturbOn(ctx, n.object())
+	n.quietLock.Lock()
+	n.dndOn, n.dndAt, n.dndAsking = on, n.clock.Now(), false
+	n.quietLock.Unlock()
+	return on
+}
+
+// sendHeldBack sends the notifications queue held back by quiet hours.
+func (n *notifier) sendHeldBack(queue []Notification) {
+	if n.quiet.Digest && len(queue) > 1 {
+		queue = []Notification{digest(queue)}
+	}
+	for _, note := range queue {
+		if _, err := n.sendJournaled(note); err != nil {
+			log.Printf("error sending notification held back by quiet hours: %v", err)
+		}
+	}
+}
+
+// digest collapses queue into a single notification.
+// App identity and the sender-pid hint are taken from the first notification.
+func digest(queue []Notification) Notification {
+	first := queue[0]
+	summaries := make([]string, 0, len(queue))
+	for _, note := range queue {
+		summaries = append(summaries, note.Summary)
+	}
+	hints := map[string]dbus.Variant{}
+	if pid, ok := first.Hints[HintSenderPID]; ok {
+		hints[HintSenderPID] = pid
+	}
+	return Notification{
+		AppName:       first.AppName,
+		AppIcon:       first.AppIcon,
+		Summary:       fmt.Sprintf("%d notifications while you were away", len(queue)),
+		Body:          strings.Join(summaries, "\n"),
+		Hints:         hints,
+		ExpireTimeout: -1,
+	}
+}
+
+// stopQuiet stops the release timer, and sends the queued notifications if
+// flush or discards them.
+func (n *notifier) stopQuiet(flush bool) {
+	n.quietLock.Lock()
+	if n.quietTimer != nil {
+		n.quietTimer.Stop()
+		n.quietTimer = nil
+	}
+	queue := n.quietQueue
+	n.quietQueue = nil
+	n.quietLock.Unlock()
+	if flush && len(queue) > 0 {
+		n.sendHeldBack(queue)
+	}
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Notification daemons may accept a notification, hand out an ID and never
+// show it, e.g. when rate-limiting or in do-not-disturb modes; the only
+// trace on the bus is a NotificationClosed signal right after. Which
+// notifications really made it to the screen can't be observed over D-Bus,
+// so receipts go by that trace.
+
+// WithDeliveryReceipts flags notifications that were likely dropped by the
+// daemon: NotificationClosedSignal.Dropped is set when a notification sent by
+// this Notifier closes within window of being sent, without the user
+// dismissing it, this Notifier closing it, or its own ExpireTimeout running
+// out. A window of a few hundred milliseconds is a good start.
+func WithDeliveryReceipts(window time.Duration) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.receipts = &receipts{
+			window: window,
+			sent:   make(map[uint32]receipt),
+		}
+	}
+}
+
+// receipts tracks recently sent notifications.
+type receipts struct {
+	window time.Duration
+
+	lock sync.Mutex
+	sent map[uint32]receipt
+}
+
+type receipt struct {
+	deadline time.Time // closing before it without a reason means dropped
+	closing  bool      // closed by CloseNotification
+}
+
+// record remembers that note was sent as id at now.
+func (r *receipts) record(id uint32, note Notification, now time.Time) {
+	window := r.window
+	if timeout := time.Duration(note.ExpireTimeout) * time.Millisecond; timeout > 0 && timeout < window {
+		window = timeout
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	// entries past their deadline can't flag anything any more.
+	for sentID, s := range r.sent {
+		if now.After(s.deadline) {
+			delete(r.sent, sentID)
+		}
+	}
+	r.sent[id] = receipt{deadline: now.Add(window)}
+}
+
+// closing records that id is being closed by CloseNotification.
+func (r *receipts) closing(id uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if s, ok := r.sent[id]; ok {
+		s.closing = true
+		r.sent[id] = s
+	}
+}
+
+// dropped reports whether id closing for reason at now means it was
+// dropped, and forgets id.
+func (r *receipts) dropped(id uint32, reason Reason, now time.Time) bool {
+	r.lock.Lock()
+	s, ok := r.sent[id]
+	delete(r.sent, id)
+	r.lock.Unlock()
+	return ok && !s.closing && reason != ReasonDismissedByUser && now.Before(s.deadline)
+}
@@ -0,0 +1,38 @@
+package notify
+
+import "regexp"
+
+// redactMask replaces redacted text.
+const redactMask = "[redacted]"
+
+// Patterns for WithRedaction.
+var (
+	// EmailPattern matches email addresses.
+	EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// TokenPattern matches common API tokens and secrets: bearer tokens,
+	// JWTs, GitHub, Slack and AWS keys and long hex or base64 strings.
+	TokenPattern = regexp.MustCompile(`(?i:bearer\s+)[A-Za-z0-9._~+/=-]+` +
+		`|eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+` +
+		`|\b(?:gh[pousr]_[A-Za-z0-9]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b` +
+		`|\b[A-Fa-f0-9]{32,}\b|\b[A-Za-z0-9+/]{40,}={0,2}`)
+)
+
+// WithRedaction makes the Notifier mask everything matching one of patterns
+// in the summary and body, e.g. EmailPattern and TokenPattern, before a
+// notification is sent or traced. Use it for notifications generated from
+// logs or emails that may show up on shared screens.
+func WithRedaction(patterns ...*regexp.Regexp) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.redact = append(n.redact, patterns...)
+	}
+}
+
+// redacted returns note with the notifier's patterns masked.
+func (n *notifier) redacted(note Notification) Notification {
+	for _, p := range n.redact {
+		note.Summary = p.ReplaceAllString(note.Summary, redactMask)
+		note.Body = p.ReplaceAllString(note.Body, redactMask)
+	}
+	return note
+}
@@ -0,0 +1,45 @@
+/*
+Package relay forwards notifications from system services into a user
+session.
+
+System daemons usually have no access to the session bus of the user they
+want to notify. A relay runs inside the user session, listens on a Unix
+socket and sends each notification it receives to the session notification
+server. Services use a Client to talk to it:
+
+	c, err := relay.Dial(relay.SocketPath(1000))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	id, err := c.SendNotification(notify.Notification{Summary: "Backup done"})
+
+The same protocol works across machines: Serve accepts any net.Listener,
+e.g. one from tls.Listen, and NewClient any connection. A Forwarder, used as
+the Renderer of a server.Server, sends everything a machine receives to a
+relay elsewhere; see the notify-forward command.
+
+It also serves local processes: short-lived scripts can go through the
+relay's connection instead of setting up their own D-Bus connection each
+time, see the notifyctl command.
+
+Requests and replies are JSON objects, one per line, so clients in other
+languages are easy to write. The command field selects the request:
+
+	{"command": "notify", ...}       send the notification given by the other
+	                                 fields, in the JSON form of
+	                                 notify.Notification; replies {"id": ID}.
+	                                 The default when command is left out.
+	{"command": "close", "id": ID}   close a notification; replies {}
+	{"command": "capabilities"}      replies {"capabilities": [...]}
+	{"command": "info"}              replies {"info": {"Name": ...}}
+
+A failed request replies {"error": "message"}.
+
+Across machines the notifications can be sealed end to end with a
+pre-shared key, see package seal, so proxies and jump hosts in between
+can't read them: with ServeSealed and NewSealedClient or
+NewSealedForwarder, every request and reply is sent as
+{"sealed": "<base64 box>"} holding its JSON form.
+*/
+package relay
@@ -0,0 +1,101 @@
+package relay
+
+import (
+	"log"
+	"net"
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/seal"
+	"github.com/esiqveland/notify/server"
+)
+
+// Forwarder is a server.Renderer that ships every notification the server
+// receives to a relay, usually on another machine, which shows it there.
+// Run it behind a server.Server on a headless machine to get its
+// notifications on your desktop.
+//
+// Only notifications travel: closing a notification locally doesn't close
+// the forwarded one, and actions invoked remotely are not reported back.
+type Forwarder struct {
+	dial func() (net.Conn, error)
+	key  *seal.Key // seals notifications, see NewSealedForwarder
+
+	lock   sync.Mutex
+	client *Client
+	ids    map[uint32]uint32 // local ID to remote ID, for replacing notifications
+}
+
+// NewForwarder returns a Forwarder connecting to the relay with dial.
+// The connection is made on the first notification, and made again after
+// an error.
+func NewForwarder(dial func() (net.Conn, error)) *Forwarder {
+	return &Forwarder{
+		dial: dial,
+		ids:  make(map[uint32]uint32),
+	}
+}
+
+// NewSealedForwarder is NewForwarder for a relay run with ServeSealed,
+// sealing every notification with key.
+func NewSealedForwarder(dial func() (net.Conn, error), key seal.Key) *Forwarder {
+	f := NewForwarder(dial)
+	f.key = &key
+	return f
+}
+
+// Show forwards n.
+func (f *Forwarder) Show(n server.Notification) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	note := n.Notification
+	note.ReplacesID = f.ids[n.ID]
+	id, err := f.send(note)
+	if err != nil {
+		log.Printf("error forwarding notification %v: %v", n.ID, err)
+		return
+	}
+	f.ids[n.ID] = id
+}
+
+// Hide forgets the notification with id.
+func (f *Forwarder) Hide(id uint32) {
+	f.lock.Lock()
+	delete(f.ids, id)
+	f.lock.Unlock()
+}
+
+// Close closes the connection to the relay.
+func (f *Forwarder) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.client == nil {
+		return nil
+	}
+	err := f.client.Close()
+	f.client = nil
+	return err
+}
+
+// send sends note, connecting first if needed. A failed send is retried once
+// on a new connection, in case the relay was restarted. Must be called with
+// f.lock held.
+func (f *Forwarder) send(note notify.Notification) (uint32, error) {
+	for attempt := 0; ; attempt++ {
+		if f.client == nil {
+			conn, err := f.dial()
+			if err != nil {
+				return 0, err
+			}
+			f.client = NewClient(conn)
+			f.client.key = f.key
+		}
+		id, err := f.client.SendNotification(note)
+		if _, remote := err.(remoteError); err == nil || remote || attempt > 0 {
+			return id, err
+		}
+		f.client.Close()
+		f.client = nil
+	}
+}
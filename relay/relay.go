@@ -0,0 +1,285 @@
+package relay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/seal"
+)
+
+// Commands of the protocol. A request without a command is a notify request.
+const (
+	commandNotify       = "notify"
+	commandClose        = "close"
+	commandCapabilities = "capabilities"
+	commandInfo         = "info"
+)
+
+// SocketPath returns the default relay socket of the user with the given
+// uid, in their runtime directory. Only that user can enter the directory,
+// whatever the mode of the socket, so a relay serving other users, e.g.
+// system services, must listen somewhere they can reach.
+func SocketPath(uid int) string {
+	return fmt.Sprintf("/run/user/%d/notify-relay.sock", uid)
+}
+
+// request is what is read from a request besides the notification.
+type request struct {
+	Command string `json:"command"`
+	ID      uint32 `json:"id"`
+}
+
+// sealed is a request or reply sealed with a pre-shared key.
+type sealed struct {
+	Sealed []byte `json:"sealed"`
+}
+
+// reply is the response to one request.
+type reply struct {
+	ID           uint32                    `json:"id,omitempty"`
+	Capabilities []string                  `json:"capabilities,omitempty"`
+	Info         *notify.ServerInformation `json:"info,omitempty"`
+	Error        string                    `json:"error,omitempty"`
+}
+
+// Serve accepts connections on l and handles the requests on them with n,
+// until l is closed.
+func Serve(l net.Listener, n notify.Notifier) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(conn, n, nil)
+	}
+}
+
+// ServeSealed is Serve for clients sealing their requests with key, see
+// NewSealedClient. Requests that are not sealed with key are refused, and
+// replies are sealed too.
+func ServeSealed(l net.Listener, n notify.Notifier, key seal.Key) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(conn, n, &key)
+	}
+}
+
+func serveConn(conn net.Conn, n notify.Notifier, key *seal.Key) {
+	defer conn.Close()
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	enc := json.NewEncoder(conn)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			var syntax *json.SyntaxError
+			if errors.As(err, &syntax) {
+				enc.Encode(reply{Error: err.Error()})
+			}
+			return
+		}
+		var r reply
+		var err error
+		if key != nil {
+			raw, err = open(key, raw)
+		}
+		if err == nil {
+			r, err = handle(raw, n)
+		}
+		if err != nil {
+			log.Printf("error handling relay request: %v", err)
+			r.Error = err.Error()
+		}
+		var out interface{} = r
+		if key != nil {
+			if out, err = sealJSON(key, r); err != nil {
+				return
+			}
+		}
+		if err := enc.Encode(out); err != nil {
+			return
+		}
+	}
+}
+
+// open returns the request sealed in raw.
+func open(key *seal.Key, raw json.RawMessage) (json.RawMessage, error) {
+	var s sealed
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	if s.Sealed == nil {
+		return nil, errors.New("request is not sealed")
+	}
+	return key.Open(s.Sealed)
+}
+
+// sealJSON seals the JSON form of v.
+func sealJSON(key *seal.Key, v interface{}) (sealed, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sealed{}, err
+	}
+	box, err := key.Seal(data)
+	return sealed{Sealed: box}, err
+}
+
+// handle runs one request.
+func handle(raw json.RawMessage, n notify.Notifier) (reply, error) {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return reply{}, err
+	}
+	switch req.Command {
+	case "", commandNotify:
+		var note notify.Notification
+		if err := json.Unmarshal(raw, &note); err != nil {
+			return reply{}, err
+		}
+		id, err := n.SendNotification(note)
+		return reply{ID: id}, err
+	case commandClose:
+		_, err := n.CloseNotification(req.ID)
+		return reply{}, err
+	case commandCapabilities:
+		caps, err := n.GetCapabilities()
+		return reply{Capabilities: caps}, err
+	case commandInfo:
+		info, err := n.GetServerInformation()
+		return reply{Info: &info}, err
+	}
+	return reply{}, fmt.Errorf("unknown command: %q", req.Command)
+}
+
+// remoteError is an error reported by the relay, as opposed to one talking
+// to it.
+type remoteError string
+
+func (e remoteError) Error() string {
+	return string(e)
+}
+
+// Client sends notifications through a relay.
+// It is safe for concurrent use.
+type Client struct {
+	lock sync.Mutex
+	conn net.Conn
+	dec  *json.Decoder
+	enc  *json.Encoder
+	key  *seal.Key // seals requests, see NewSealedClient
+}
+
+// Dial connects to the relay listening on the Unix socket at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a Client talking to a relay over conn, e.g. a TLS
+// connection to a relay on another machine.
+func NewClient(conn net.Conn) *Client {
+	return &Client{
+		conn: conn,
+		dec:  json.NewDecoder(bufio.NewReader(conn)),
+		enc:  json.NewEncoder(conn),
+	}
+}
+
+// NewSealedClient is NewClient for a relay run with ServeSealed: requests
+// and replies are sealed with key, so whatever carries them between the
+// client and the relay can't read the notifications.
+func NewSealedClient(conn net.Conn, key seal.Key) *Client {
+	c := NewClient(conn)
+	c.key = &key
+	return c
+}
+
+// SendNotification sends note to the session notification server through the
+// relay, and returns the ID the server assigned.
+func (c *Client) SendNotification(note notify.Notification) (uint32, error) {
+	r, err := c.call(note)
+	return r.ID, err
+}
+
+// CloseNotification closes the notification with id.
+func (c *Client) CloseNotification(id uint32) error {
+	_, err := c.call(request{Command: commandClose, ID: id})
+	return err
+}
+
+// GetCapabilities returns the capabilities of the session notification
+// server.
+func (c *Client) GetCapabilities() ([]string, error) {
+	r, err := c.call(request{Command: commandCapabilities})
+	return r.Capabilities, err
+}
+
+// GetServerInformation returns the information on the session notification
+// server.
+func (c *Client) GetServerInformation() (notify.ServerInformation, error) {
+	r, err := c.call(request{Command: commandInfo})
+	if r.Info == nil {
+		return notify.ServerInformation{}, err
+	}
+	return *r.Info, err
+}
+
+// call sends req and waits for the reply.
+func (c *Client) call(req interface{}) (reply, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.key != nil {
+		s, err := sealJSON(c.key, req)
+		if err != nil {
+			return reply{}, err
+		}
+		req = s
+	}
+	if err := c.enc.Encode(req); err != nil {
+		return reply{}, err
+	}
+	var r reply
+	if c.key == nil {
+		if err := c.dec.Decode(&r); err != nil {
+			return reply{}, err
+		}
+	} else {
+		var raw json.RawMessage
+		if err := c.dec.Decode(&raw); err != nil {
+			return reply{}, err
+		}
+		data, err := open(c.key, raw)
+		if err != nil {
+			return reply{}, err
+		}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return reply{}, err
+		}
+	}
+	if r.Error != "" {
+		return r, remoteError(r.Error)
+	}
+	return r, nil
+}
+
+// Close closes the connection to the relay.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
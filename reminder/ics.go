@@ -0,0 +1,197 @@
+package reminder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadICS reads the events of the iCalendar file at path, see ParseICS.
+func LoadICS(path string) ([]Reminder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseICS(f)
+}
+
+// ParseICS reads the VEVENTs of an iCalendar (RFC 5545) stream as
+// reminders. The VALARMs of an event with a relative or absolute TRIGGER
+// become its lead times.
+//
+// Only what reminders need is understood: UID, SUMMARY, DESCRIPTION,
+// DTSTART (UTC, floating, with a TZID, or a date) and VALARM TRIGGER.
+// Recurrence rules are not expanded, a recurring event reminds of its first
+// occurrence only.
+func ParseICS(r io.Reader) ([]Reminder, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var reminders []Reminder
+	var event *Reminder
+	var triggers []string // of the event's alarms, resolved once DTSTART is known
+	inAlarm := false
+	for i, line := range lines {
+		name, params, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event, triggers = &Reminder{}, nil
+		case name == "BEGIN" && value == "VALARM":
+			inAlarm = true
+		case name == "END" && value == "VALARM":
+			inAlarm = false
+		case name == "END" && value == "VEVENT":
+			if event == nil {
+				continue
+			}
+			if event.At.IsZero() {
+				return nil, fmt.Errorf("reminder: event %q has no DTSTART", event.UID)
+			}
+			for _, t := range triggers {
+				if lead, err := parseTrigger(t, event.At); err == nil {
+					event.Leads = append(event.Leads, lead)
+				}
+			}
+			reminders = append(reminders, *event)
+			event = nil
+		case event == nil:
+			// properties of the calendar itself.
+		case inAlarm:
+			if name == "TRIGGER" {
+				if params["VALUE"] == "DATE-TIME" {
+					value = "@" + value
+				}
+				triggers = append(triggers, value)
+			}
+		case name == "UID":
+			event.UID = value
+		case name == "SUMMARY":
+			event.Summary = unescape(value)
+		case name == "DESCRIPTION":
+			event.Description = unescape(value)
+		case name == "DTSTART":
+			at, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("reminder: line %d: %w", i+1, err)
+			}
+			event.At = at
+		}
+	}
+	return reminders, nil
+}
+
+// unfold reads the content lines of r, joining folded continuation lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty splits a content line NAME;PARAM=VALUE:VALUE.
+func splitProperty(line string) (string, map[string]string, string, bool) {
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		return "", nil, "", false
+	}
+	parts := strings.Split(line[:colon], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], true
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseTime parses a DATE-TIME or DATE value.
+func parseTime(value string, params map[string]string) (time.Time, error) {
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	switch {
+	case params["VALUE"] == "DATE" || len(value) == len("20060102"):
+		return time.ParseInLocation("20060102", value, loc)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+// parseTrigger returns how long before at a TRIGGER fires.
+// Absolute triggers are given with a leading "@".
+func parseTrigger(trigger string, at time.Time) (time.Duration, error) {
+	if strings.HasPrefix(trigger, "@") {
+		t, err := parseTime(trigger[1:], nil)
+		if err != nil {
+			return 0, err
+		}
+		return at.Sub(t), nil
+	}
+	d, err := parseDuration(trigger)
+	return -d, err
+}
+
+// parseDuration parses an RFC 5545 duration like -PT15M or P1DT2H.
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	s = s[1:]
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	var d time.Duration
+	for s != "" {
+		if s[0] == 'T' {
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+			s = s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		n, err := strconv.Atoi(s[:i])
+		unit, ok := units[s[i]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		d += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	return sign * d, nil
+}
@@ -0,0 +1,336 @@
+// Package reminder schedules notifications ahead of events, e.g. those of an
+// iCalendar file, with snoozing and state that survives restarts.
+//
+//	events, err := reminder.LoadICS("calendar.ics")
+//	...
+//	e, err := reminder.New(notifier, reminder.Config{StatePath: "reminders.json"})
+//	...
+//	defer e.Close()
+//	e.Set(events)
+//	for action := range notifier.ActionInvoked() {
+//		e.HandleAction(action)
+//	}
+package reminder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/internal/configfile"
+)
+
+const (
+	actionSnooze  = "snooze"
+	actionDefault = "default"
+
+	// forgetAfter is how long after an event its state is kept.
+	forgetAfter = 24 * time.Hour
+	// lateness is how long after its event started a reminder that was
+	// missed is still shown.
+	lateness = time.Minute
+)
+
+// Reminder is an event to remind of.
+type Reminder struct {
+	UID         string // identifies the event across reloads
+	Summary     string
+	Description string
+	At          time.Time       // when the event starts
+	Leads       []time.Duration // how long before At to remind, Config.Lead if empty
+}
+
+// Config configures an Engine.
+type Config struct {
+	// StatePath is the file that records which reminders were shown and
+	// which are snoozed. Without it nothing is remembered across restarts.
+	StatePath string
+	Lead      time.Duration // default lead time, 10 minutes if 0
+	Snooze    time.Duration // how long Snooze postpones, 5 minutes if 0
+	AppName   string
+	AppIcon   string // defaults to "appointment-soon"
+	// Clock tells when reminders are due, notify.SystemClock if nil.
+	Clock notify.Clock
+}
+
+// Engine shows reminders when they are due.
+//
+// Reminders that came due while the program wasn't running are shown as soon
+// as the Engine starts, unless their event already started.
+type Engine struct {
+	n      notify.Notifier
+	config Config
+
+	lock      sync.Mutex
+	reminders []Reminder
+	state     state
+	shown     map[uint32]string // key of the occurrence by notification ID
+	timer     notify.Alarm
+	closed    bool
+}
+
+// state is what is kept in Config.StatePath.
+type state struct {
+	Fired   map[string]time.Time `json:"fired"`   // event start by occurrence key
+	Snoozed map[string]snoozed   `json:"snoozed"` // by occurrence key
+}
+
+type snoozed struct {
+	Until   time.Time `json:"until"`
+	At      time.Time `json:"at"` // event start
+	Summary string    `json:"summary"`
+	Body    string    `json:"body"`
+}
+
+// New creates an Engine sending notifications through n, loading its state
+// from config.StatePath if that exists.
+//
+// Caller is responsible to call Close() to stop it.
+func New(n notify.Notifier, config Config) (*Engine, error) {
+	if config.Lead == 0 {
+		config.Lead = 10 * time.Minute
+	}
+	if config.Snooze == 0 {
+		config.Snooze = 5 * time.Minute
+	}
+	if config.AppIcon == "" {
+		config.AppIcon = "appointment-soon"
+	}
+	if config.Clock == nil {
+		config.Clock = notify.SystemClock
+	}
+	e := &Engine{
+		n:      n,
+		config: config,
+		state: state{
+			Fired:   make(map[string]time.Time),
+			Snoozed: make(map[string]snoozed),
+		},
+		shown: make(map[uint32]string),
+	}
+	if config.StatePath != "" {
+		data, err := os.ReadFile(config.StatePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &e.state); err != nil {
+				return nil, fmt.Errorf("reminder: %v: %w", config.StatePath, err)
+			}
+		}
+	}
+	e.lock.Lock()
+	e.schedule()
+	e.lock.Unlock()
+	return e, nil
+}
+
+// Set replaces the reminders of the Engine, e.g. after the calendar file
+// changed. Reminders already shown are not shown again.
+func (e *Engine) Set(reminders []Reminder) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.reminders = append([]Reminder(nil), reminders...)
+	e.schedule()
+}
+
+// occurrence is one reminder of an event, at one of its lead times.
+type occurrence struct {
+	key     string
+	due     time.Time
+	at      time.Time
+	note    notify.Notification
+	snoozed bool // due is the end of a snooze
+}
+
+func (e *Engine) occurrences() []occurrence {
+	var ret []occurrence
+	for _, r := range e.reminders {
+		leads := r.Leads
+		if len(leads) == 0 {
+			leads = []time.Duration{e.config.Lead}
+		}
+		for _, lead := range leads {
+			due := r.At.Add(-lead)
+			ret = append(ret, occurrence{
+				key:  r.UID + "/" + strconv.FormatInt(due.Unix(), 10),
+				due:  due,
+				at:   r.At,
+				note: e.notification(r.Summary, body(r)),
+			})
+		}
+	}
+	for key, s := range e.state.Snoozed {
+		ret = append(ret, occurrence{
+			key:     key,
+			due:     s.Until,
+			at:      s.At,
+			note:    e.notification(s.Summary, s.Body),
+			snoozed: true,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].due.Before(ret[j].due) })
+	return ret
+}
+
+func body(r Reminder) string {
+	text := r.At.Local().Format("15:04")
+	if r.Description != "" {
+		text += "\n" + r.Description
+	}
+	return text
+}
+
+func (e *Engine) notification(summary, body string) notify.Notification {
+	note := notify.Notification{
+		AppName:       e.config.AppName,
+		AppIcon:       e.config.AppIcon,
+		Summary:       summary,
+		Body:          body,
+		Actions:       []string{actionDefault, "Dismiss", actionSnooze, "Snooze"},
+		ExpireTimeout: 0, // stay until the user reacts
+	}
+	note.SetHint(notify.HintCategory, "x-reminder")
+	return note
+}
+
+// schedule shows what is due and sets the timer for the next reminder.
+// e.lock must be held.
+func (e *Engine) schedule() {
+	if e.closed {
+		return
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	now := e.config.Clock.Now()
+	changed := e.forget(now)
+	var next time.Time
+	for _, o := range e.occurrences() {
+		if !o.snoozed {
+			_, fired := e.state.Fired[o.key]
+			_, snoozed := e.state.Snoozed[o.key]
+			if fired || snoozed || o.at.Add(lateness).Before(now) {
+				continue
+			}
+		}
+		if o.due.After(now) {
+			next = o.due
+			break
+		}
+		id, err := e.n.SendNotification(o.note)
+		if err != nil {
+			log.Printf("reminder: error showing %q: %v", o.note.Summary, err)
+			continue
+		}
+		e.shown[id] = o.key
+		e.state.Fired[o.key] = o.at
+		delete(e.state.Snoozed, o.key)
+		changed = true
+	}
+	if changed {
+		e.save()
+	}
+	if !next.IsZero() {
+		e.timer = e.config.Clock.AfterFunc(next.Sub(now), func() {
+			e.lock.Lock()
+			defer e.lock.Unlock()
+			e.schedule()
+		})
+	}
+}
+
+// forget drops the state of events that are long over.
+func (e *Engine) forget(now time.Time) bool {
+	changed := false
+	for key, at := range e.state.Fired {
+		if at.Add(forgetAfter).Before(now) {
+			delete(e.state.Fired, key)
+			changed = true
+		}
+	}
+	for key, s := range e.state.Snoozed {
+		if s.At.Add(forgetAfter).Before(now) {
+			delete(e.state.Snoozed, key)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// HandleAction snoozes the reminder signal is for if the user picked Snooze.
+// Call it from the loop reading ActionInvoked.
+func (e *Engine) HandleAction(signal *notify.ActionInvokedSignal) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	key, ok := e.shown[signal.Id]
+	if !ok {
+		return
+	}
+	delete(e.shown, signal.Id)
+	if signal.ActionKey != actionSnooze {
+		return
+	}
+	for _, o := range e.occurrences() {
+		if o.key == key {
+			e.state.Snoozed[key] = snoozed{
+				Until:   e.config.Clock.Now().Add(e.config.Snooze),
+				At:      o.at,
+				Summary: o.note.Summary,
+				Body:    o.note.Body,
+			}
+			e.save()
+			e.schedule()
+			return
+		}
+	}
+}
+
+// HandleClosed forgets the notification signal is for. Call it from the
+// loop reading NotificationClosed.
+func (e *Engine) HandleClosed(signal *notify.NotificationClosedSignal) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	delete(e.shown, signal.Id)
+}
+
+// save writes the state to Config.StatePath. e.lock must be held.
+func (e *Engine) save() {
+	if e.config.StatePath == "" {
+		return
+	}
+	if err := writeState(e.config.StatePath, e.state); err != nil {
+		log.Printf("reminder: error saving state: %v", err)
+	}
+}
+
+func writeState(path string, s state) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return configfile.Write(path, append(data, '\n'))
+}
+
+// Close stops showing reminders. The Notifier is left open.
+func (e *Engine) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.closed = true
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	return nil
+}
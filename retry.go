@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// RetryPolicy configures how SendNotification retries on transient D-Bus
+// failures: the notification server not (yet) owning its name, e.g. while
+// the desktop is starting, timeouts, and disconnects.
+type RetryPolicy struct {
+	// MaxAttempts caps the number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. 0 means no cap.
+	MaxBackoff time.Duration
+	// Multiplier grows the wait after each attempt. Values below 1 mean 2.
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction of it, e.g. 0.2 for
+	// ±20%, so many clients don't retry in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryPolicy makes 5 attempts over about 3 seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// transientErrors are the D-Bus errors worth retrying.
+var transientErrors = map[string]bool{
+	"org.freedesktop.DBus.Error.ServiceUnknown": true,
+	"org.freedesktop.DBus.Error.NameHasNoOwner": true,
+	"org.freedesktop.DBus.Error.NoReply":        true,
+	"org.freedesktop.DBus.Error.Timeout":        true,
+	"org.freedesktop.DBus.Error.TimedOut":       true,
+	"org.freedesktop.DBus.Error.Disconnected":   true,
+}
+
+// WithRetry makes SendNotification retry transient failures according to
+// policy. When all attempts fail, the error wraps the last failure.
+// Only SendNotification is retried; the other calls fail right away.
+func WithRetry(policy RetryPolicy) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.retry = &policy
+	}
+}
+
+// isTransient reports whether err may go away by trying again.
+func isTransient(err error) bool {
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) {
+		return transientErrors[dbusErr.Name]
+	}
+	var dbusErrPtr *dbus.Error
+	if errors.As(err, &dbusErrPtr) {
+		return transientErrors[dbusErrPtr.Name]
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// backoff returns the wait after the given attempt, counting from 1.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	wait := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		wait *= multiplier
+		if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+			wait = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(wait)
+}
+
+// withRetry calls fn until it succeeds, fails with a permanent error, or the
+// attempts of the policy are used up.
+func (n *notifier) withRetry(fn func() (uint32, error)) (uint32, error) {
+	id, err := fn()
+	if n.retry == nil || err == nil {
+		return id, err
+	}
+	attempt := 1
+	for ; attempt < n.retry.MaxAttempts && isTransient(err); attempt++ {
+		wait := n.retry.backoff(attempt)
+		n.tracef("retry attempt=%v wait=%v err=%v", attempt+1, wait, err)
+		Sleep(n.clock, wait)
+		id, err = fn()
+		if err == nil {
+			return id, nil
+		}
+	}
+	if attempt == 1 {
+		return id, err
+	}
+	return id, fmt.Errorf("notify: giving up after %d attempts: %w", attempt, err)
+}
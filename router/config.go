@@ -0,0 +1,108 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// configFile is the JSON form of a Config:
+//
+//	{
+//	  "mode": "failover",
+//	  "routes": ["desktop", "phone"],
+//	  "interval": "30s",
+//	  "timeout": "5s"
+//	}
+type configFile struct {
+	Mode     string   `json:"mode"` // "failover" (the default) or "fanout"
+	Routes   []string `json:"routes"`
+	Interval string   `json:"interval,omitempty"`
+	Timeout  string   `json:"timeout,omitempty"`
+}
+
+// LoadConfig reads a Config from the JSON file at path, which names the
+// backends to route to among backends, the ones the program can offer. See
+// ReloadOnSignal to pick up changes without a restart.
+func LoadConfig(path string, backends map[string]Backend) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var f configFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Config{}, fmt.Errorf("router: %v: %w", path, err)
+	}
+	var config Config
+	switch f.Mode {
+	case "", "failover":
+		config.Mode = Failover
+	case "fanout":
+		config.Mode = FanOut
+	default:
+		return Config{}, fmt.Errorf("router: %v: unknown mode %q", path, f.Mode)
+	}
+	for _, name := range f.Routes {
+		b, ok := backends[name]
+		if !ok {
+			return Config{}, fmt.Errorf("router: %v: unknown backend %q", path, name)
+		}
+		config.Routes = append(config.Routes, Route{Name: name, Backend: b})
+	}
+	if config.Interval, err = parseDuration(f.Interval); err != nil {
+		return Config{}, fmt.Errorf("router: %v: interval: %w", path, err)
+	}
+	if config.Timeout, err = parseDuration(f.Timeout); err != nil {
+		return Config{}, fmt.Errorf("router: %v: timeout: %w", path, err)
+	}
+	return config, nil
+}
+
+// parseDuration parses an optional duration.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// ReloadOnSignal reloads r from the configuration file at path, see
+// LoadConfig, each time the process receives one of signals, SIGHUP if
+// none, until r is closed. When the file fails to load or apply, r keeps
+// its configuration and failed, if not nil, is called with the error.
+func (r *Router) ReloadOnSignal(path string, backends map[string]Backend, failed func(error), signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return errors.New("router: closed")
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-sig:
+			}
+			config, err := LoadConfig(path, backends)
+			if err == nil {
+				err = r.Reload(config)
+			}
+			if err != nil && failed != nil {
+				failed(err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,45 @@
+/*
+Package router sends notifications through several backends, e.g. the
+desktop and a phone reached through a relay, as one notify.Notifier.
+
+In Failover mode a notification goes to the first healthy backend, in the
+order given; in FanOut mode to every healthy one. A backend is unhealthy
+after a send to it failed or its Healthy probe did; unhealthy backends are
+probed again periodically and take part again once the probe works. Events
+reports these transitions:
+
+	r, err := router.New(router.Config{
+		Mode: router.Failover,
+		Routes: []router.Route{
+			{Name: "desktop", Backend: router.Probe(desktop)},
+			{Name: "phone", Backend: router.Probe(phone)},
+		},
+	})
+	if err != nil {
+		...
+	}
+	defer r.Close()
+	go func() {
+		for e := range r.Events() {
+			log.Printf("backend %s healthy: %v (%v)", e.Backend, e.Healthy, e.Err)
+		}
+	}()
+
+SendNotification returns an error only if no backend showed the
+notification; Send reports the ID, error and latency of each backend, so
+failed legs can be retried on their own.
+
+Reload changes the backends and mode of a running Router, e.g. from a file
+read with LoadConfig, without losing what it knows of the notifications
+shown. ReloadOnSignal does so from a file on SIGHUP:
+
+	err = r.ReloadOnSignal(path, backends, func(err error) {
+		log.Printf("keeping the routing configuration: %v", err)
+	})
+
+The Router hands out its own notification IDs and translates the signals of
+the backends to them. As with any Notifier, the channels returned by
+NotificationClosed and ActionInvoked must be consumed. A notification shown
+by several backends is reported closed when the last of them closed it.
+*/
+package router
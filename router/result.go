@@ -0,0 +1,126 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// FanoutResult is the outcome of Send.
+type FanoutResult struct {
+	// ID is the ID of the notification, 0 if no backend showed it.
+	ID uint32
+	// Legs are the backends tried, in order.
+	Legs []Leg
+}
+
+// Leg is the outcome of sending to one backend.
+type Leg struct {
+	Backend string
+	ID      uint32 // of the backend's copy, 0 if Err is set
+	Err     error
+	Latency time.Duration
+}
+
+// Failed returns the names of the backends that failed.
+func (r FanoutResult) Failed() []string {
+	var names []string
+	for _, leg := range r.Legs {
+		if leg.Err != nil {
+			names = append(names, leg.Backend)
+		}
+	}
+	return names
+}
+
+// Send is SendNotification, reporting the outcome and latency of each
+// backend tried. The error is only set if no backend showed note.
+//
+// With names, note is sent to each of the named backends, healthy or not,
+// whatever the mode. This retries the legs that failed: give the ID of the
+// result as the ReplacesID of note, and the backends that now show it are
+// added to the notification.
+//
+//	result, err := r.Send(note)
+//	if failed := result.Failed(); err == nil && len(failed) > 0 {
+//		note.ReplacesID = result.ID
+//		result, err = r.Send(note, failed...)
+//	}
+func (r *Router) Send(note notify.Notification, names ...string) (FanoutResult, error) {
+	r.lock.Lock()
+	mode := r.config.Mode
+	replaces := make(map[string]uint32, len(r.copies[note.ReplacesID]))
+	for name, id := range r.copies[note.ReplacesID] {
+		replaces[name] = id
+	}
+	var routes []Route
+	var unknown []string
+	for _, name := range names {
+		if b, ok := r.backends[name]; ok {
+			routes = append(routes, b.Route)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	r.lock.Unlock()
+
+	var result FanoutResult
+	if len(names) == 0 {
+		routes = r.healthy()
+	}
+	if len(names) == 0 && mode == Failover {
+		for _, route := range routes {
+			leg := r.sendLeg(route, note, replaces[route.Name])
+			result.Legs = append(result.Legs, leg)
+			if leg.Err == nil {
+				break
+			}
+		}
+	} else {
+		result.Legs = make([]Leg, len(routes))
+		var wg sync.WaitGroup
+		for i, route := range routes {
+			wg.Add(1)
+			go func(i int, route Route) {
+				defer wg.Done()
+				result.Legs[i] = r.sendLeg(route, note, replaces[route.Name])
+			}(i, route)
+		}
+		wg.Wait()
+	}
+	for _, name := range unknown {
+		result.Legs = append(result.Legs, Leg{Backend: name, Err: errors.New("unknown backend")})
+	}
+
+	sent := make(map[string]uint32)
+	var errs []error
+	for _, leg := range result.Legs {
+		if leg.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", leg.Backend, leg.Err))
+			continue
+		}
+		sent[leg.Backend] = leg.ID
+	}
+	if len(sent) == 0 {
+		return result, errors.Join(append([]error{ErrNoBackend}, errs...)...)
+	}
+	result.ID = r.record(note.ReplacesID, sent)
+	return result, nil
+}
+
+// sendLeg sends note to one backend, as a replacement of its copy
+// replaces, and marks the backend unhealthy if that fails.
+func (r *Router) sendLeg(route Route, note notify.Notification, replaces uint32) Leg {
+	note.ReplacesID = replaces
+	start := time.Now()
+	id, err := route.Backend.SendNotification(note)
+	leg := Leg{Backend: route.Name, ID: id, Err: err, Latency: time.Since(start)}
+	if err != nil {
+		leg.ID = 0
+		r.setHealth(route.Name, err)
+	}
+	return leg
+}
@@ -0,0 +1,495 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+const (
+	defaultInterval = 30 * time.Second
+	defaultTimeout  = 5 * time.Second
+
+	channelBufferSize = 10
+	// eventBufferSize is how many events Events may fall behind before
+	// events are dropped.
+	eventBufferSize = 16
+)
+
+// ErrNoBackend is returned when no backend is healthy.
+var ErrNoBackend = errors.New("router: no healthy backend")
+
+// Backend is a Notifier that can tell whether it works.
+type Backend interface {
+	notify.Notifier
+	// Healthy returns nil if notifications sent now would likely be
+	// shown, or why not.
+	Healthy(ctx context.Context) error
+}
+
+// Probe returns n as a Backend. Unless n has a Healthy method of its own, it
+// is healthy when GetServerInformation works.
+func Probe(n notify.Notifier) Backend {
+	if b, ok := n.(Backend); ok {
+		return b
+	}
+	return probe{n}
+}
+
+type probe struct {
+	notify.Notifier
+}
+
+func (p probe) Healthy(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.GetServerInformation()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p probe) Flush(ctx context.Context) error {
+	return notify.Flush(ctx, p.Notifier)
+}
+
+// Mode is how a Router picks the backends of a notification.
+type Mode int
+
+const (
+	// Failover sends to the first healthy backend, in order.
+	Failover Mode = iota
+	// FanOut sends to every healthy backend.
+	FanOut
+)
+
+// Route is a backend with the name it is reported under.
+type Route struct {
+	Name    string
+	Backend Backend
+}
+
+// Config configures a Router.
+type Config struct {
+	Mode   Mode
+	Routes []Route
+	// Interval is how often unhealthy backends are probed, 30 seconds if 0.
+	Interval time.Duration
+	// Timeout bounds one probe, 5 seconds if 0.
+	Timeout time.Duration
+	// Clock times the probes, notify.SystemClock if nil.
+	Clock notify.Clock
+}
+
+// Event reports that a backend became healthy or unhealthy.
+type Event struct {
+	Backend string
+	Healthy bool
+	Err     error // why it is unhealthy
+}
+
+// Router is a notify.Notifier sending through backends, see the package
+// documentation.
+type Router struct {
+	closer chan *notify.NotificationClosedSignal
+	action chan *notify.ActionInvokedSignal
+	events chan Event
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	lock     sync.Mutex
+	config   Config              // as of the last Reload
+	backends map[string]*backend // every backend taken over, by name
+	routes   []*backend          // those of config, in order
+	lastID   uint32
+	copies   map[uint32]map[string]uint32 // backend IDs by backend name, by ID
+	ids      map[copyKey]uint32           // ID by backend ID
+	closed   bool
+}
+
+type backend struct {
+	Route
+	err error // why it is unhealthy, nil while healthy
+}
+
+// copyKey identifies the copy of a notification shown by a backend.
+type copyKey struct {
+	backend string
+	id      uint32
+}
+
+// New returns a Router for config, taking over its backends: it consumes
+// their signals and closes them on Close.
+//
+// Caller is responsible to call Close() to stop it.
+func New(config Config) (*Router, error) {
+	r := &Router{
+		closer:   make(chan *notify.NotificationClosedSignal, channelBufferSize),
+		action:   make(chan *notify.ActionInvokedSignal, channelBufferSize),
+		events:   make(chan Event, eventBufferSize),
+		done:     make(chan struct{}),
+		backends: make(map[string]*backend),
+		copies:   make(map[uint32]map[string]uint32),
+		ids:      make(map[copyKey]uint32),
+	}
+	if err := r.Reload(config); err != nil {
+		return nil, err
+	}
+	go r.check()
+	return r, nil
+}
+
+// Reload replaces the configuration of the Router, e.g. after its
+// configuration file changed, see LoadConfig. Backends are told apart by
+// name: those new to the Router are taken over like those given to New,
+// those left out are no longer sent to but stay open, so the notifications
+// they show keep reporting their signals.
+//
+// Sends in progress finish with the backends they started with, and
+// notifications already shown keep their IDs, so they can still be replaced
+// and closed.
+func (r *Router) Reload(config Config) error {
+	if len(config.Routes) == 0 {
+		return errors.New("router: no backends")
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.Clock == nil {
+		config.Clock = notify.SystemClock
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return errors.New("router: closed")
+	}
+	var routes []*backend
+	var added []Route
+	names := make(map[string]bool)
+	for _, route := range config.Routes {
+		if names[route.Name] {
+			return fmt.Errorf("router: backend %q given twice", route.Name)
+		}
+		names[route.Name] = true
+		b, ok := r.backends[route.Name]
+		if ok && b.Backend != route.Backend {
+			return fmt.Errorf("router: backend %q is another backend already", route.Name)
+		}
+		if !ok {
+			b = &backend{Route: route}
+			added = append(added, route)
+		}
+		routes = append(routes, b)
+	}
+	for _, b := range routes {
+		if _, ok := r.backends[b.Name]; !ok {
+			r.backends[b.Name] = b
+			r.wg.Add(1)
+			go r.forward(b.Route)
+		}
+	}
+	r.config, r.routes = config, routes
+	if len(added) > 0 {
+		go r.probe(added)
+	}
+	return nil
+}
+
+// Events returns the channel reporting backends becoming healthy or
+// unhealthy. Events are dropped if it isn't consumed.
+func (r *Router) Events() <-chan Event {
+	return r.events
+}
+
+// healthy returns the healthy backends, in order.
+func (r *Router) healthy() []Route {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var routes []Route
+	for _, b := range r.routes {
+		if b.err == nil {
+			routes = append(routes, b.Route)
+		}
+	}
+	return routes
+}
+
+// setHealth records the outcome of using backend name.
+func (r *Router) setHealth(name string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return
+	}
+	b, ok := r.backends[name]
+	if !ok || (b.err == nil) == (err == nil) {
+		return
+	}
+	b.err = err
+	select {
+	case r.events <- Event{Backend: name, Healthy: err == nil, Err: err}:
+	default:
+	}
+}
+
+// check probes the unhealthy backends every interval.
+func (r *Router) check() {
+	for {
+		r.lock.Lock()
+		interval, clock := r.config.Interval, r.config.Clock
+		r.lock.Unlock()
+		wake := make(chan struct{})
+		alarm := clock.AfterFunc(interval, func() { close(wake) })
+		select {
+		case <-wake:
+			r.probe(r.unhealthy())
+		case <-r.done:
+			alarm.Stop()
+			return
+		}
+	}
+}
+
+func (r *Router) unhealthy() []Route {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var routes []Route
+	for _, b := range r.backends {
+		if b.err != nil {
+			routes = append(routes, b.Route)
+		}
+	}
+	return routes
+}
+
+func (r *Router) probe(routes []Route) {
+	r.lock.Lock()
+	timeout := r.config.Timeout
+	r.lock.Unlock()
+	for _, route := range routes {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := route.Backend.Healthy(ctx)
+		cancel()
+		r.setHealth(route.Name, err)
+	}
+}
+
+// SendNotification sends note through the backends picked by the mode. A
+// backend failing to send is marked unhealthy; in Failover mode the next
+// one is tried. See Send for the outcome of each backend.
+func (r *Router) SendNotification(note notify.Notification) (uint32, error) {
+	result, err := r.Send(note)
+	return result.ID, err
+}
+
+// record remembers the copies of a notification, replacing the one with ID
+// replaces if that is known, and returns its ID.
+func (r *Router) record(replaces uint32, sent map[string]uint32) uint32 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	id := replaces
+	copies, ok := r.copies[id]
+	if !ok {
+		r.lastID++
+		id = r.lastID
+		copies = make(map[string]uint32)
+		r.copies[id] = copies
+	}
+	for name, backendID := range sent {
+		copies[name] = backendID
+		r.ids[copyKey{name, backendID}] = id
+	}
+	return id
+}
+
+// forward translates the signals of a backend until it is closed.
+func (r *Router) forward(route Route) {
+	defer r.wg.Done()
+	closed, actions := route.Backend.NotificationClosed(), route.Backend.ActionInvoked()
+	for closed != nil || actions != nil {
+		select {
+		case signal, ok := <-closed:
+			if !ok {
+				closed = nil
+				continue
+			}
+			if id, last := r.closedCopy(copyKey{route.Name, signal.Id}); last {
+				s := *signal
+				s.Id = id
+				r.emitClosed(&s)
+			}
+		case signal, ok := <-actions:
+			if !ok {
+				actions = nil
+				continue
+			}
+			r.lock.Lock()
+			id, known := r.ids[copyKey{route.Name, signal.Id}]
+			r.lock.Unlock()
+			if known {
+				s := *signal
+				s.Id = id
+				select {
+				case r.action <- &s:
+				case <-r.done:
+				}
+			}
+		}
+	}
+}
+
+// closedCopy forgets a closed copy, and returns the ID of its notification
+// and whether it was the last copy.
+func (r *Router) closedCopy(key copyKey) (uint32, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	id, ok := r.ids[key]
+	if !ok {
+		return 0, false
+	}
+	delete(r.ids, key)
+	copies := r.copies[id]
+	delete(copies, key.backend)
+	if len(copies) > 0 {
+		return id, false
+	}
+	delete(r.copies, id)
+	return id, true
+}
+
+func (r *Router) emitClosed(signal *notify.NotificationClosedSignal) {
+	select {
+	case r.closer <- signal:
+	case <-r.done:
+	}
+}
+
+// first returns the first healthy backend.
+func (r *Router) first() (Backend, error) {
+	routes := r.healthy()
+	if len(routes) == 0 {
+		return nil, ErrNoBackend
+	}
+	return routes[0].Backend, nil
+}
+
+// GetCapabilities returns the capabilities of the first healthy backend.
+func (r *Router) GetCapabilities() ([]string, error) {
+	b, err := r.first()
+	if err != nil {
+		return nil, err
+	}
+	return b.GetCapabilities()
+}
+
+// GetServerInformation returns the information of the first healthy
+// backend.
+func (r *Router) GetServerInformation() (notify.ServerInformation, error) {
+	b, err := r.first()
+	if err != nil {
+		return notify.ServerInformation{}, err
+	}
+	return b.GetServerInformation()
+}
+
+// CloseNotification closes every copy of notification id.
+func (r *Router) CloseNotification(id uint32) (bool, error) {
+	r.lock.Lock()
+	copies := make(map[string]uint32, len(r.copies[id]))
+	for name, backendID := range r.copies[id] {
+		copies[name] = backendID
+	}
+	backends := make(map[string]Backend)
+	for name, b := range r.backends {
+		backends[name] = b.Backend
+	}
+	r.lock.Unlock()
+
+	closed := false
+	var errs []error
+	for name, backendID := range copies {
+		ok, err := backends[name].CloseNotification(backendID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		closed = closed || ok
+	}
+	return closed, errors.Join(errs...)
+}
+
+// CloseNotifications closes every copy of the notifications ids.
+func (r *Router) CloseNotifications(ids ...uint32) error {
+	var errs []error
+	for _, id := range ids {
+		if _, err := r.CloseNotification(id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) NotificationClosed() <-chan *notify.NotificationClosedSignal {
+	return r.closer
+}
+
+func (r *Router) ActionInvoked() <-chan *notify.ActionInvokedSignal {
+	return r.action
+}
+
+func (r *Router) WithApp(appName, appIcon string) notify.Notifier {
+	return notify.NewChild(r, appName, appIcon)
+}
+
+// Flush flushes every backend, see notify.Flush.
+func (r *Router) Flush(ctx context.Context) error {
+	r.lock.Lock()
+	var routes []Route
+	for _, b := range r.backends {
+		routes = append(routes, b.Route)
+	}
+	r.lock.Unlock()
+	var errs []error
+	for _, route := range routes {
+		if err := notify.Flush(ctx, route.Backend); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the Router and closes its backends and channels.
+func (r *Router) Close() error {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.events) // setHealth checks closed
+	backends := r.backends
+	r.lock.Unlock()
+
+	close(r.done)
+	var errs []error
+	for _, b := range backends {
+		if err := b.Backend.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+		}
+	}
+	r.wg.Wait()
+	close(r.closer)
+	close(r.action)
+	return errors.Join(errs...)
+}
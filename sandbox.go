@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+const flatpakInfo = "/.flatpak-info"
+
+// Sandbox is an application sandbox the process can run in.
+type Sandbox int
+
+const (
+	SandboxNone Sandbox = iota
+	SandboxFlatpak
+	SandboxSnap
+)
+
+func (s Sandbox) String() string {
+	switch s {
+	case SandboxNone:
+		return "None"
+	case SandboxFlatpak:
+		return "Flatpak"
+	case SandboxSnap:
+		return "Snap"
+	default:
+		return "Other"
+	}
+}
+
+// DetectSandbox reports whether the process runs inside a Flatpak or Snap
+// sandbox. In both, talking to org.freedesktop.Notifications directly often
+// fails or shows up as the wrong application, and the notification portal
+// should be used instead.
+func DetectSandbox() Sandbox {
+	if _, err := os.Stat(flatpakInfo); err == nil {
+		return SandboxFlatpak
+	}
+	if os.Getenv("SNAP") != "" && os.Getenv("SNAP_NAME") != "" {
+		return SandboxSnap
+	}
+	return SandboxNone
+}
+
+// SandboxAppID returns the application ID of the sandboxed application,
+// which is also the name of its desktop entry, or "" outside of a sandbox.
+//
+// For Flatpak this is the Application name from /.flatpak-info.
+// Snaps don't expose an application name, so the snap name is used for both
+// parts of the snap_app desktop entry name, which matches the common case of
+// a snap with a single application.
+func SandboxAppID() string {
+	switch DetectSandbox() {
+	case SandboxFlatpak:
+		return flatpakAppID()
+	case SandboxSnap:
+		name := os.Getenv("SNAP_NAME")
+		return name + "_" + name
+	}
+	return ""
+}
+
+// flatpakAppID reads the name key of the [Application] group in
+// /.flatpak-info.
+func flatpakAppID() string {
+	return readKeyFile(flatpakInfo, "Application")["name"]
+}
+
+// readKeyFile returns the keys of group in the desktop entry style key file
+// at path, e.g. a .desktop file. Localized keys like Name[de] are kept as is.
+func readKeyFile(path, group string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	keys := make(map[string]string)
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = line[1 : len(line)-1]
+			continue
+		}
+		if current != group || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+			keys[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return keys
+}
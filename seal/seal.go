@@ -0,0 +1,91 @@
+// Package seal encrypts notification payloads end to end with a pre-shared
+// key, so relays and proxies between the sending and the showing machine
+// can't read them. It uses NaCl secretbox (XSalsa20 and Poly1305).
+//
+// Create a key once and give it to both ends:
+//
+//	key, err := seal.GenerateKey()
+//	...
+//	fmt.Println(key) // base64, for seal.ParseKey or a key file
+//
+// Sealing only hides and authenticates the content; it doesn't stop a relay
+// from dropping, delaying or replaying sealed messages.
+package seal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const nonceSize = 24
+
+// ErrOpen is returned for messages that were not sealed with the key, or
+// were modified.
+var ErrOpen = errors.New("seal: message not sealed with this key")
+
+// Key is a pre-shared secretbox key.
+type Key [32]byte
+
+// GenerateKey returns a new random Key.
+func GenerateKey() (Key, error) {
+	var k Key
+	_, err := rand.Read(k[:])
+	return k, err
+}
+
+// ParseKey parses a Key in the base64 form String returns.
+func ParseKey(s string) (Key, error) {
+	var k Key
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return k, fmt.Errorf("seal: invalid key: %w", err)
+	}
+	if len(data) != len(k) {
+		return k, fmt.Errorf("seal: invalid key: %d bytes instead of %d", len(data), len(k))
+	}
+	copy(k[:], data)
+	return k, nil
+}
+
+// LoadKey reads a Key from the file at path, which holds it in base64.
+func LoadKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+	return ParseKey(string(data))
+}
+
+// String returns the key in base64.
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+// Seal encrypts and authenticates message, with a random nonce in front.
+func (k *Key) Seal(message []byte) ([]byte, error) {
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	return secretbox.Seal(nonce[:], message, &nonce, (*[32]byte)(k)), nil
+}
+
+// Open decrypts a message sealed with Seal.
+func (k *Key) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < nonceSize+secretbox.Overhead {
+		return nil, ErrOpen
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed)
+	message, ok := secretbox.Open(nil, sealed[nonceSize:], &nonce, (*[32]byte)(k))
+	if !ok {
+		return nil, ErrOpen
+	}
+	return message, nil
+}
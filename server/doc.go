@@ -0,0 +1,13 @@
+/*
+Package server implements the server side of the freedesktop notification
+interface, for writing notification daemons in go.
+
+A Server owns the org.freedesktop.Notifications name on a bus connection,
+keeps track of active notifications and hands them to a Renderer for display.
+Interactions the renderer reports back through InvokeAction and Dismiss are
+emitted as the ActionInvoked and NotificationClosed signals clients listen
+for.
+
+See: https://developer.gnome.org/notification-spec/
+*/
+package server
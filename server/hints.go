@@ -0,0 +1,167 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/esiqveland/notify"
+)
+
+// Any application on the bus can send notifications, so the accessors below
+// treat hints as untrusted input: they never panic on unexpected types or
+// malformed values, they report a missing or unusable hint instead.
+
+const (
+	hintImageData       = "image-data"
+	hintImageDataLegacy = "image_data" // spec 1.1
+	hintIconData        = "icon_data"  // spec 1.0
+
+	// maxImageSize caps width*height of image-data, so a bogus size can't
+	// make us allocate gigabytes.
+	maxImageSize = 4096 * 4096
+)
+
+// ErrNoImage is returned by Image if the notification has no image-data hint.
+var ErrNoImage = errors.New("no image-data hint")
+
+// HintString returns the string value of hint key, and whether it was set
+// with that type.
+func (n Notification) HintString(key string) (string, bool) {
+	v, ok := n.Hints[key]
+	if !ok {
+		return "", false
+	}
+	s, ok := v.Value().(string)
+	return s, ok
+}
+
+// HintBool returns the boolean value of hint key, and whether it was set
+// with that type. Integers are accepted too, since some clients send them.
+func (n Notification) HintBool(key string) (bool, bool) {
+	v, ok := n.Hints[key]
+	if !ok {
+		return false, false
+	}
+	if b, ok := v.Value().(bool); ok {
+		return b, true
+	}
+	if i, ok := toInt64(v.Value()); ok {
+		return i != 0, true
+	}
+	return false, false
+}
+
+// HintInt returns the integer value of hint key, and whether it was set
+// with an integer type.
+func (n Notification) HintInt(key string) (int64, bool) {
+	v, ok := n.Hints[key]
+	if !ok {
+		return 0, false
+	}
+	return toInt64(v.Value())
+}
+
+// Resident reports whether the resident hint is set: the notification stays
+// after one of its actions is invoked, until it is closed explicitly.
+func (n Notification) Resident() bool {
+	b, _ := n.HintBool(notify.HintResident)
+	return b
+}
+
+// Urgency returns the urgency of the notification. Missing or out of range
+// urgency hints give UrgencyNormal, as the spec suggests.
+func (n Notification) Urgency() notify.Urgency {
+	u, ok := n.HintInt(notify.HintUrgency)
+	if !ok || u < int64(notify.UrgencyLow) || u > int64(notify.UrgencyCritical) {
+		return notify.UrgencyNormal
+	}
+	return notify.Urgency(u)
+}
+
+// Image decodes the image-data hint (or its older names image_data and
+// icon_data) of type (iiibiiay): width, height, rowstride, has alpha,
+// bits per sample, channels and the pixel data.
+// Values that don't describe a valid 8 bit RGB or RGBA image are rejected.
+func (n Notification) Image() (image.Image, error) {
+	for _, key := range []string{hintImageData, hintImageDataLegacy, hintIconData} {
+		if v, ok := n.Hints[key]; ok {
+			return decodeImage(v.Value())
+		}
+	}
+	return nil, ErrNoImage
+}
+
+func decodeImage(value interface{}) (image.Image, error) {
+	fields, ok := value.([]interface{})
+	if !ok || len(fields) != 7 {
+		return nil, errors.New("image-data: not a (iiibiiay) struct")
+	}
+	width, ok1 := fields[0].(int32)
+	height, ok2 := fields[1].(int32)
+	stride, ok3 := fields[2].(int32)
+	alpha, ok4 := fields[3].(bool)
+	bits, ok5 := fields[4].(int32)
+	channels, ok6 := fields[5].(int32)
+	data, ok7 := fields[6].([]byte)
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6 && ok7) {
+		return nil, errors.New("image-data: not a (iiibiiay) struct")
+	}
+
+	if width <= 0 || height <= 0 || int64(width)*int64(height) > maxImageSize {
+		return nil, fmt.Errorf("image-data: invalid size %vx%v", width, height)
+	}
+	if bits != 8 {
+		return nil, fmt.Errorf("image-data: unsupported bits per sample: %v", bits)
+	}
+	if (alpha && channels != 4) || (!alpha && channels != 3) {
+		return nil, fmt.Errorf("image-data: %v channels with alpha %v", channels, alpha)
+	}
+	// all sizes are bounded by maxImageSize, int64 can't overflow.
+	rowLen := int64(width) * int64(channels)
+	if int64(stride) < rowLen {
+		return nil, fmt.Errorf("image-data: rowstride %v too small for width %v", stride, width)
+	}
+	// the last row need not be padded to the full rowstride.
+	if need := int64(stride)*int64(height-1) + rowLen; int64(len(data)) < need {
+		return nil, fmt.Errorf("image-data: %v bytes of pixel data, need %v", len(data), need)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	for y := 0; y < int(height); y++ {
+		row := data[y*int(stride):]
+		for x := 0; x < int(width); x++ {
+			p := row[x*int(channels):]
+			c := color.NRGBA{R: p[0], G: p[1], B: p[2], A: 0xff}
+			if alpha {
+				c.A = p[3]
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch i := v.(type) {
+	case byte:
+		return int64(i), true
+	case int16:
+		return int64(i), true
+	case uint16:
+		return int64(i), true
+	case int32:
+		return int64(i), true
+	case uint32:
+		return int64(i), true
+	case int64:
+		return i, true
+	case uint64:
+		if i > 1<<63-1 {
+			return 0, false
+		}
+		return int64(i), true
+	}
+	return 0, false
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+func FuzzDecodeImage(f *testing.F) {
+	f.Add(int32(2), int32(2), int32(6), false, int32(8), int32(3), make([]byte, 12))
+	f.Add(int32(1), int32(1), int32(4), true, int32(8), int32(4), []byte{1, 2, 3, 4})
+	f.Add(int32(3), int32(2), int32(9), false, int32(8), int32(3), make([]byte, 17)) // unpadded last row
+	f.Add(int32(-1), int32(1<<30), int32(0), true, int32(16), int32(0), []byte(nil))
+	f.Fuzz(func(t *testing.T, width, height, stride int32, alpha bool, bits, channels int32, data []byte) {
+		img, err := decodeImage([]interface{}{width, height, stride, alpha, bits, channels, data})
+		if err != nil {
+			return
+		}
+		if b := img.Bounds(); b.Dx() != int(width) || b.Dy() != int(height) {
+			t.Fatalf("decoded %vx%v image-data to bounds %v", width, height, b)
+		}
+	})
+}
+
+// FuzzHints reads hints of every type a sender can put in a variant,
+// under the keys the accessors look at.
+func FuzzHints(f *testing.F) {
+	f.Add(byte(0), int64(1), "", []byte(nil))
+	f.Add(byte(4), int64(2), "", []byte(nil))
+	f.Add(byte(8), int64(0), "im.received", []byte(nil))
+	f.Add(byte(10), int64(1), "", []byte{1, 2, 3})
+	f.Add(byte(11), int64(-1), "x", []byte{0xff})
+	f.Fuzz(func(t *testing.T, kind byte, i int64, s string, b []byte) {
+		v := fuzzVariant(kind, i, s, b)
+		n := Notification{Notification: notify.Notification{Hints: map[string]dbus.Variant{
+			notify.HintUrgency:   v,
+			notify.HintResident:  v,
+			notify.HintTransient: v,
+			notify.HintCategory:  v,
+			hintImageData:        v,
+		}}}
+		for key := range n.Hints {
+			n.HintString(key)
+			n.HintBool(key)
+			n.HintInt(key)
+		}
+		n.Resident()
+		if u := n.Urgency(); u < notify.UrgencyLow || u > notify.UrgencyCritical {
+			t.Fatalf("urgency %v out of range", u)
+		}
+		n.Image()
+	})
+}
+
+// fuzzVariant makes a variant of the type picked by kind from the values.
+// Structs come as []interface{}, like godbus decodes them.
+func fuzzVariant(kind byte, i int64, s string, b []byte) dbus.Variant {
+	switch kind % 13 {
+	case 0:
+		return dbus.MakeVariant(i != 0)
+	case 1:
+		return dbus.MakeVariant(byte(i))
+	case 2:
+		return dbus.MakeVariant(int16(i))
+	case 3:
+		return dbus.MakeVariant(uint16(i))
+	case 4:
+		return dbus.MakeVariant(int32(i))
+	case 5:
+		return dbus.MakeVariant(uint32(i))
+	case 6:
+		return dbus.MakeVariant(i)
+	case 7:
+		return dbus.MakeVariant(uint64(i))
+	case 8:
+		return dbus.MakeVariant(s)
+	case 9:
+		return dbus.MakeVariant(b)
+	case 10:
+		w, h := int32(i&0xff), int32(i>>8&0xff)
+		return dbus.MakeVariant([]interface{}{w, h, int32(i >> 16), i < 0, int32(8), int32(len(s)), b})
+	case 11:
+		return dbus.MakeVariant([]interface{}{s, i, b})
+	default:
+		return dbus.MakeVariant([]string{s})
+	}
+}
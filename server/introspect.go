@@ -0,0 +1,125 @@
+package server
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	introspectableInterface = "org.freedesktop.DBus.Introspectable"
+	propertiesInterface     = "org.freedesktop.DBus.Properties"
+)
+
+// introspectPaths are the parents of the object path, which are made
+// introspectable too so tools walking the object tree, like busctl tree,
+// find the server.
+var introspectPaths = []struct {
+	path  dbus.ObjectPath
+	child string
+}{
+	{"/", "org"},
+	{"/org", "freedesktop"},
+	{"/org/freedesktop", "Notifications"},
+}
+
+// notificationsInterface describes the Notifications interface, see the
+// D-BUS Protocol section of the spec.
+var notificationsInterface = introspect.Interface{
+	Name: dbusNotificationsInterface,
+	Methods: []introspect.Method{
+		{
+			Name: "GetCapabilities",
+			Args: []introspect.Arg{
+				{Name: "capabilities", Type: "as", Direction: "out"},
+			},
+		},
+		{
+			Name: "Notify",
+			Args: []introspect.Arg{
+				{Name: "app_name", Type: "s", Direction: "in"},
+				{Name: "replaces_id", Type: "u", Direction: "in"},
+				{Name: "app_icon", Type: "s", Direction: "in"},
+				{Name: "summary", Type: "s", Direction: "in"},
+				{Name: "body", Type: "s", Direction: "in"},
+				{Name: "actions", Type: "as", Direction: "in"},
+				{Name: "hints", Type: "a{sv}", Direction: "in"},
+				{Name: "expire_timeout", Type: "i", Direction: "in"},
+				{Name: "id", Type: "u", Direction: "out"},
+			},
+		},
+		{
+			Name: "CloseNotification",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u", Direction: "in"},
+			},
+		},
+		{
+			Name: "GetServerInformation",
+			Args: []introspect.Arg{
+				{Name: "name", Type: "s", Direction: "out"},
+				{Name: "vendor", Type: "s", Direction: "out"},
+				{Name: "version", Type: "s", Direction: "out"},
+				{Name: "spec_version", Type: "s", Direction: "out"},
+			},
+		},
+	},
+	Signals: []introspect.Signal{
+		{
+			Name: "NotificationClosed",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u"},
+				{Name: "reason", Type: "u"},
+			},
+		},
+		{
+			Name: "ActionInvoked",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u"},
+				{Name: "action_key", Type: "s"},
+			},
+		},
+	},
+}
+
+// exportIntrospection exports the Introspectable and Properties interfaces
+// next to the Notifications interface. The Notifications interface has no
+// properties, but tools like d-feet expect the Properties interface.
+func exportIntrospection(conn *dbus.Conn) error {
+	_, err := prop.Export(conn, dbusObjectPath, prop.Map{dbusNotificationsInterface: {}})
+	if err != nil {
+		return err
+	}
+	node := &introspect.Node{
+		Name: string(dbusObjectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			notificationsInterface,
+		},
+	}
+	err = conn.Export(introspect.NewIntrospectable(node), dbusObjectPath, introspectableInterface)
+	if err != nil {
+		return err
+	}
+	for _, p := range introspectPaths {
+		parent := &introspect.Node{
+			Name:     string(p.path),
+			Children: []introspect.Node{{Name: p.child}},
+		}
+		err := conn.Export(introspect.NewIntrospectable(parent), p.path, introspectableInterface)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unexportIntrospection undoes exportIntrospection.
+func unexportIntrospection(conn *dbus.Conn) {
+	conn.Export(nil, dbusObjectPath, propertiesInterface)
+	conn.Export(nil, dbusObjectPath, introspectableInterface)
+	for _, p := range introspectPaths {
+		conn.Export(nil, p.path, introspectableInterface)
+	}
+}
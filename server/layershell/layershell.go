@@ -0,0 +1,652 @@
+//go:build unix
+
+/*
+Package layershell is a server.Renderer drawing notification popups on
+Wayland compositors implementing wlr-layer-shell, like sway, river, Hyprland
+and the other wlroots based ones. It speaks the Wayland protocol itself, so
+together with the server package it makes a notification daemon in pure go,
+without cgo or libwayland:
+
+	r, err := layershell.New(layershell.WithAnchor(layershell.TopRight))
+	...
+	srv, err := server.New(conn, r)
+	...
+	log.Fatal(r.Run(srv))
+
+The popups are stacked in a layer surface in a corner of the screen. A
+click on one invokes its default action, or the action of the button
+clicked, and a right click dismisses it. Text is drawn with a built-in
+bitmap font covering ASCII, other characters are shown as '?'. The
+image-data hint is shown as icon, icon names and files are not looked up.
+*/
+package layershell
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/server"
+)
+
+// Edges of zwlr_layer_surface_v1 anchors.
+const (
+	anchorTop    = 1
+	anchorBottom = 2
+	anchorLeft   = 4
+	anchorRight  = 8
+)
+
+// Anchor is the corner of the screen the popups are shown in.
+type Anchor uint32
+
+const (
+	TopRight    Anchor = anchorTop | anchorRight
+	TopLeft     Anchor = anchorTop | anchorLeft
+	BottomRight Anchor = anchorBottom | anchorRight
+	BottomLeft  Anchor = anchorBottom | anchorLeft
+)
+
+// actionDefault is the key of the action invoked by clicking a popup.
+const actionDefault = "default"
+
+// maxVisible is how many notifications are shown at most, the latest ones.
+const maxVisible = 5
+
+// Colors of the popups.
+var (
+	colorBackground = color.RGBA{0x28, 0x2c, 0x34, 0xff}
+	colorSummary    = color.RGBA{0xee, 0xee, 0xee, 0xff}
+	colorBody       = color.RGBA{0xb8, 0xbc, 0xc4, 0xff}
+	colorButton     = color.RGBA{0x3e, 0x44, 0x51, 0xff}
+	colorBorder     = map[notify.Urgency]color.RGBA{
+		notify.UrgencyLow:      {0x5c, 0x63, 0x70, 0xff},
+		notify.UrgencyNormal:   {0x61, 0xaf, 0xef, 0xff},
+		notify.UrgencyCritical: {0xe0, 0x6c, 0x75, 0xff},
+	}
+)
+
+// Option configures a Renderer created with New.
+type Option func(*Renderer)
+
+// WithAnchor sets the corner the popups are shown in, TopRight by default.
+func WithAnchor(a Anchor) Option {
+	return func(r *Renderer) {
+		r.anchor = a
+	}
+}
+
+// WithWidth sets the width of the popups in pixels, 360 by default.
+func WithWidth(width int) Option {
+	return func(r *Renderer) {
+		r.width = width
+	}
+}
+
+// WithScale sets the size of each pixel of the font, 2 by default. Use 3 or
+// more on high density screens.
+func WithScale(scale int) Option {
+	return func(r *Renderer) {
+		r.face.scale = scale
+	}
+}
+
+// WithMargin sets the distance of the popups from the edges of the screen,
+// in pixels, 10 by default.
+func WithMargin(margin int) Option {
+	return func(r *Renderer) {
+		r.margin = margin
+	}
+}
+
+// Renderer shows notifications as popups in a wlr-layer-shell surface.
+//
+// Show and Hide draw right away, clicks are handled by Run, which must be
+// running for the popups to appear at all: the compositor configures the
+// surface before it is drawn.
+type Renderer struct {
+	c      *conn
+	anchor Anchor
+	width  int
+	margin int
+	face   face
+
+	lock       sync.Mutex
+	handlers   map[uint32]func(e *event) // by object ID
+	registry   uint32
+	compositor uint32
+	shm        uint32
+	layerShell uint32
+	seat       uint32
+	pointer    uint32
+	err        error // a protocol error, fatal
+
+	list         []server.Notification
+	surface      uint32 // wl_surface of the popups, 0 if none are shown
+	layerSurface uint32
+	configured   bool
+	size         image.Point // of the layer surface
+	areas        []area      // clickable, as last drawn
+
+	pointerIn bool
+	pointerAt image.Point
+	clicked   func(s *server.Server) // run by Run without the lock
+	closed    bool
+}
+
+// area is a clickable part of the popups, invoking key on the notification
+// with id.
+type area struct {
+	rect image.Rectangle
+	id   uint32
+	key  string
+}
+
+// New connects to the compositor of $WAYLAND_DISPLAY, configured by opts.
+// It fails if the compositor doesn't support wlr-layer-shell.
+func New(opts ...Option) (*Renderer, error) {
+	c, err := dial()
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the Wayland compositor: %w", err)
+	}
+	r := &Renderer{
+		c:        c,
+		anchor:   TopRight,
+		width:    360,
+		margin:   10,
+		face:     face{scale: 2},
+		handlers: make(map[uint32]func(e *event)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.face.scale < 1 {
+		r.face.scale = 1
+	}
+
+	r.lock.Lock()
+	r.handlers[displayID] = r.displayEvent
+	r.registry = c.newID()
+	r.handlers[r.registry] = r.registryEvent
+	err = c.send(displayID, displayGetRegistry, r.registry)
+	r.lock.Unlock()
+	// one roundtrip for the globals, one for the events of those bound.
+	for i := 0; i < 2 && err == nil; i++ {
+		err = r.roundtrip()
+	}
+	if err == nil {
+		switch {
+		case r.compositor == 0 || r.shm == 0:
+			err = errors.New("wl_compositor or wl_shm missing")
+		case r.layerShell == 0:
+			err = errors.New("the compositor doesn't support wlr-layer-shell")
+		}
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// roundtrip handles events until the compositor handled all requests sent
+// before. Only for New, before Run reads the events.
+func (r *Renderer) roundtrip() error {
+	r.lock.Lock()
+	done := false
+	callback := r.c.newID()
+	r.handlers[callback] = func(e *event) {
+		done = e.opcode == callbackDone
+	}
+	err := r.c.send(displayID, displaySync, callback)
+	r.lock.Unlock()
+	for err == nil && !done {
+		var e event
+		if e, err = r.c.next(); err != nil {
+			break
+		}
+		r.lock.Lock()
+		r.dispatch(&e)
+		err = r.err
+		r.lock.Unlock()
+	}
+	return err
+}
+
+// Run handles the events of the compositor, reporting clicks to s, until
+// Close is called or the connection fails.
+func (r *Renderer) Run(s *server.Server) error {
+	for {
+		e, err := r.c.next()
+		r.lock.Lock()
+		if r.closed {
+			r.lock.Unlock()
+			return nil
+		}
+		if err == nil {
+			r.dispatch(&e)
+			err = r.err
+		}
+		clicked := r.clicked
+		r.clicked = nil
+		r.lock.Unlock()
+		if err != nil {
+			return err
+		}
+		if clicked != nil {
+			clicked(s)
+		}
+	}
+}
+
+// Close disconnects from the compositor, removing the popups.
+func (r *Renderer) Close() error {
+	r.lock.Lock()
+	r.closed = true
+	r.lock.Unlock()
+	return r.c.Close()
+}
+
+// Show adds n to the popups, or replaces the one with the same ID.
+func (r *Renderer) Show(n server.Notification) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i := range r.list {
+		if r.list[i].ID == n.ID {
+			r.list[i] = n
+			r.redraw()
+			return
+		}
+	}
+	r.list = append(r.list, n)
+	r.redraw()
+}
+
+// Hide removes the popup of the notification with id.
+func (r *Renderer) Hide(id uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i := range r.list {
+		if r.list[i].ID == id {
+			r.list = append(r.list[:i], r.list[i+1:]...)
+			r.redraw()
+			return
+		}
+	}
+}
+
+// dispatch hands e to the handler of the object it is for. r.lock must be
+// held.
+func (r *Renderer) dispatch(e *event) {
+	if h, ok := r.handlers[e.sender]; ok {
+		h(e)
+	}
+}
+
+func (r *Renderer) displayEvent(e *event) {
+	switch e.opcode {
+	case displayError:
+		object, code, message := e.uint(), e.uint(), e.string()
+		r.err = fmt.Errorf("wayland: error %v on object %v: %v", code, object, message)
+	case displayDeleteID:
+		delete(r.handlers, e.uint())
+	}
+}
+
+func (r *Renderer) registryEvent(e *event) {
+	if e.opcode != registryGlobal {
+		return
+	}
+	name, iface := e.uint(), e.string()
+	bind := func() uint32 {
+		id := r.c.newID()
+		r.request(r.registry, registryBind, name, iface, uint32(1), id)
+		return id
+	}
+	switch {
+	case iface == ifaceCompositor && r.compositor == 0:
+		r.compositor = bind()
+	case iface == ifaceShm && r.shm == 0:
+		r.shm = bind()
+	case iface == ifaceLayerShell && r.layerShell == 0:
+		r.layerShell = bind()
+	case iface == ifaceSeat && r.seat == 0:
+		// popups are clicked with the pointer of the first seat.
+		r.seat = bind()
+		r.handlers[r.seat] = r.seatEvent
+	}
+}
+
+func (r *Renderer) seatEvent(e *event) {
+	if e.opcode != seatCapabilities || r.pointer != 0 || e.uint()&seatPointer == 0 {
+		return
+	}
+	r.pointer = r.c.newID()
+	r.handlers[r.pointer] = r.pointerEvent
+	r.request(r.seat, seatGetPointer, r.pointer)
+}
+
+func (r *Renderer) pointerEvent(e *event) {
+	switch e.opcode {
+	case pointerEnter:
+		e.uint() // serial
+		r.pointerIn = r.surface != 0 && e.uint() == r.surface
+		r.pointerAt = image.Pt(int(e.fixed()), int(e.fixed()))
+	case pointerLeave:
+		r.pointerIn = false
+	case pointerMotion:
+		e.uint() // time
+		r.pointerAt = image.Pt(int(e.fixed()), int(e.fixed()))
+	case pointerButton:
+		e.uint() // serial
+		e.uint() // time
+		button, state := e.uint(), e.uint()
+		if r.pointerIn && state == buttonPressed {
+			r.click(button)
+		}
+	}
+}
+
+// click sets what Run does for a click with button where the pointer is.
+func (r *Renderer) click(button uint32) {
+	for _, a := range r.areas {
+		if !r.pointerAt.In(a.rect) {
+			continue
+		}
+		id, key := a.id, a.key
+		switch {
+		case button == btnRight || (button == btnLeft && key == ""):
+			r.clicked = func(s *server.Server) {
+				s.Dismiss(id, notify.ReasonDismissedByUser)
+			}
+		case button == btnLeft:
+			r.clicked = func(s *server.Server) {
+				if err := s.InvokeAction(id, key); err != nil {
+					log.Printf("error invoking action %v on %v: %v", key, id, err)
+				}
+			}
+		}
+		return
+	}
+}
+
+func (r *Renderer) layerSurfaceEvent(e *event) {
+	switch e.opcode {
+	case layerSurfaceConfigure:
+		r.request(r.layerSurface, layerSurfaceAckConfigure, e.uint())
+		r.configured = true
+		r.redraw()
+	case layerSurfaceClosed:
+		// the compositor took the surface away, e.g. as its output is gone.
+		// The next change of the popups shows them again.
+		r.unmap()
+	}
+}
+
+// request sends a request, logging failures: they are failures of the
+// connection, which Run then returns.
+func (r *Renderer) request(id uint32, opcode uint16, args ...interface{}) {
+	if err := r.c.send(id, opcode, args...); err != nil && !r.closed {
+		log.Printf("error sending Wayland request: %v", err)
+	}
+}
+
+// redraw draws the popups, creating the layer surface as needed, or
+// removes it if there are none. r.lock must be held.
+func (r *Renderer) redraw() {
+	if r.closed {
+		return
+	}
+	if len(r.list) == 0 {
+		r.unmap()
+		return
+	}
+	img, areas := r.paint()
+	size := img.Bounds().Size()
+	if r.surface == 0 {
+		r.surface = r.c.newID()
+		r.request(r.compositor, compositorCreateSurface, r.surface)
+		r.layerSurface = r.c.newID()
+		r.handlers[r.layerSurface] = r.layerSurfaceEvent
+		r.request(r.layerShell, layerShellGetLayerSurface, r.layerSurface, r.surface, uint32(0), uint32(layerTop), "notifications")
+		r.request(r.layerSurface, layerSurfaceSetAnchor, uint32(r.anchor))
+		m := int32(r.margin)
+		r.request(r.layerSurface, layerSurfaceSetMargin, m, m, m, m)
+	}
+	if size != r.size {
+		r.size = size
+		r.request(r.layerSurface, layerSurfaceSetSize, uint32(size.X), uint32(size.Y))
+	}
+	if !r.configured {
+		// drawn once the compositor sends the configure event.
+		r.request(r.surface, surfaceCommit)
+		return
+	}
+	if err := r.attach(img); err != nil {
+		log.Printf("error drawing notifications: %v", err)
+		return
+	}
+	r.areas = areas
+	r.request(r.surface, surfaceDamage, int32(0), int32(0), int32(size.X), int32(size.Y))
+	r.request(r.surface, surfaceCommit)
+}
+
+// unmap destroys the layer surface. r.lock must be held.
+func (r *Renderer) unmap() {
+	if r.surface == 0 {
+		return
+	}
+	r.request(r.layerSurface, layerSurfaceDestroy)
+	r.request(r.surface, surfaceDestroy)
+	r.surface, r.layerSurface = 0, 0
+	r.configured = false
+	r.size = image.Point{}
+	r.areas = nil
+	r.pointerIn = false
+}
+
+// attach attaches a new buffer holding img to the surface. The buffer is
+// destroyed once the compositor releases it.
+func (r *Renderer) attach(img *image.RGBA) error {
+	size := img.Bounds().Size()
+	stride := size.X * 4
+	// ARGB8888 is a native endian 32 bit value, image.RGBA is premultiplied
+	// like Wayland expects.
+	data := make([]byte, stride*size.Y)
+	for i := 0; i < len(data); i += 4 {
+		p := img.Pix[i : i+4]
+		argb := uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		binary.NativeEndian.PutUint32(data[i:], argb)
+	}
+	f, err := os.CreateTemp(os.Getenv("XDG_RUNTIME_DIR"), "notify-layershell-*")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// shared with the compositor by its descriptor only.
+	os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+
+	pool := r.c.newID()
+	if err := r.c.send(r.shm, shmCreatePool, pool, fd(f.Fd()), int32(len(data))); err != nil {
+		return err
+	}
+	buffer := r.c.newID()
+	r.request(pool, shmPoolCreateBuffer, buffer, int32(0), int32(size.X), int32(size.Y), int32(stride), uint32(shmFormatARGB8888))
+	r.request(pool, shmPoolDestroy)
+	r.handlers[buffer] = func(e *event) {
+		if e.opcode == bufferRelease {
+			r.request(buffer, bufferDestroy)
+		}
+	}
+	r.request(r.surface, surfaceAttach, buffer, int32(0), int32(0))
+	return nil
+}
+
+// popup is the layout of the popup of a notification.
+type popup struct {
+	n       server.Notification
+	icon    image.Image
+	summary []string
+	body    []string
+	buttons [][2]string // key and label
+	height  int
+}
+
+const (
+	padding   = 10 // inside popups, in pixels
+	spacing   = 8  // between popups
+	border    = 2
+	iconSize  = 48
+	bodyLines = 4
+)
+
+// paint draws the popups of the latest notifications, stacked from the
+// anchored edge, and returns the areas that can be clicked.
+func (r *Renderer) paint() (*image.RGBA, []area) {
+	list := r.list
+	if len(list) > maxVisible {
+		list = list[len(list)-maxVisible:]
+	}
+	f := r.face
+	lh := f.lineHeight()
+	popups := make([]popup, len(list))
+	height := 0
+	for i, n := range list {
+		p := popup{n: n}
+		textWidth := r.width - 2*padding
+		if img, err := n.Image(); err == nil {
+			p.icon = img
+			textWidth -= iconSize + padding
+		}
+		p.summary = f.wrap(n.Summary, textWidth, 2)
+		p.body = f.wrap(n.Body, textWidth, bodyLines)
+		x := 0
+		for _, a := range labelledActions(n.Actions) {
+			w := f.width(a[1]) + 2*padding
+			if x+w > r.width-2*padding {
+				break
+			}
+			p.buttons = append(p.buttons, a)
+			x += w + padding
+		}
+		text := (len(p.summary) + len(p.body)) * lh
+		if p.icon != nil && text < iconSize {
+			text = iconSize
+		}
+		p.height = 2*padding + text
+		if len(p.buttons) > 0 {
+			p.height += lh + 2*padding
+		}
+		popups[i] = p
+		height += p.height + spacing
+	}
+	height -= spacing
+	if r.anchor&anchorBottom != 0 {
+		// the latest next to the edge.
+		for i, j := 0, len(popups)-1; i < j; i, j = i+1, j-1 {
+			popups[i], popups[j] = popups[j], popups[i]
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, r.width, height))
+	var areas []area
+	y := 0
+	for _, p := range popups {
+		rect := image.Rect(0, y, r.width, y+p.height)
+		areas = append(areas, r.paintPopup(img, rect, p)...)
+		y += p.height + spacing
+	}
+	return img, areas
+}
+
+// paintPopup draws p into rect of img, and returns its clickable areas, the
+// buttons first.
+func (r *Renderer) paintPopup(img *image.RGBA, rect image.Rectangle, p popup) []area {
+	f := r.face
+	lh := f.lineHeight()
+	fill(img, rect, colorBorder[p.n.Urgency()])
+	fill(img, rect.Inset(border), colorBackground)
+
+	x, y := rect.Min.X+padding, rect.Min.Y+padding
+	if p.icon != nil {
+		drawIcon(img, image.Rect(x, y, x+iconSize, y+iconSize), p.icon)
+		x += iconSize + padding
+	}
+	for _, line := range p.summary {
+		f.draw(img, image.Pt(x, y), line, colorSummary, true)
+		y += lh
+	}
+	for _, line := range p.body {
+		f.draw(img, image.Pt(x, y), line, colorBody, false)
+		y += lh
+	}
+
+	var areas []area
+	x, y = rect.Min.X+padding, rect.Max.Y-padding-lh-padding
+	for _, b := range p.buttons {
+		button := image.Rect(x, y, x+f.width(b[1])+2*padding, y+lh+padding)
+		fill(img, button, colorButton)
+		f.draw(img, image.Pt(x+padding, y+padding/2+f.scale), b[1], colorSummary, false)
+		areas = append(areas, area{rect: button, id: p.n.ID, key: b[0]})
+		x = button.Max.X + padding
+	}
+	key := ""
+	if hasAction(p.n.Actions, actionDefault) {
+		key = actionDefault
+	}
+	return append(areas, area{rect: rect, id: p.n.ID, key: key})
+}
+
+func fill(img draw.Image, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// drawIcon draws src scaled into rect, keeping its aspect ratio.
+func drawIcon(img draw.Image, rect image.Rectangle, src image.Image) {
+	b := src.Bounds()
+	w, h := rect.Dx(), rect.Dy()
+	if b.Dx() > b.Dy() {
+		h = h * b.Dy() / b.Dx()
+	} else {
+		w = w * b.Dx() / b.Dy()
+	}
+	scaled := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			scaled.Set(x, y, src.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	at := rect.Min.Add(image.Pt((rect.Dx()-w)/2, (rect.Dy()-h)/2))
+	draw.Draw(img, scaled.Bounds().Add(at), scaled, image.Point{}, draw.Over)
+}
+
+// labelledActions returns the key and label pairs of actions, leaving out
+// the default action, invoked by clicking the popup instead.
+func labelledActions(actions []string) [][2]string {
+	var ret [][2]string
+	for i := 0; i+1 < len(actions); i += 2 {
+		if actions[i] != actionDefault {
+			ret = append(ret, [2]string{actions[i], actions[i+1]})
+		}
+	}
+	return ret
+}
+
+func hasAction(actions []string, key string) bool {
+	for i := 0; i+1 < len(actions); i += 2 {
+		if actions[i] == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+//go:build unix
+
+package layershell
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// The Wayland wire protocol, just as much of it as the Renderer needs: a
+// handful of requests and events of the core protocol and of
+// wlr-layer-shell-unstable-v1.
+//
+// See: https://wayland.freedesktop.org/docs/html/ch04.html#sect-Protocol-Wire-Format
+
+const displayID = 1 // the wl_display, the only object existing from the start
+
+// Interfaces bound from the registry. All are bound at version 1, which
+// has all the requests and events used.
+const (
+	ifaceCompositor = "wl_compositor"
+	ifaceShm        = "wl_shm"
+	ifaceSeat       = "wl_seat"
+	ifaceLayerShell = "zwlr_layer_shell_v1"
+)
+
+// Request opcodes.
+const (
+	displaySync               = 0
+	displayGetRegistry        = 1
+	registryBind              = 0
+	compositorCreateSurface   = 0
+	shmCreatePool             = 0
+	shmPoolCreateBuffer       = 0
+	shmPoolDestroy            = 1
+	bufferDestroy             = 0
+	surfaceDestroy            = 0
+	surfaceAttach             = 1
+	surfaceDamage             = 2
+	surfaceCommit             = 6
+	seatGetPointer            = 0
+	layerShellGetLayerSurface = 0
+	layerSurfaceSetSize       = 0
+	layerSurfaceSetAnchor     = 1
+	layerSurfaceSetMargin     = 3
+	layerSurfaceAckConfigure  = 6
+	layerSurfaceDestroy       = 7
+)
+
+// Event opcodes.
+const (
+	displayError          = 0
+	displayDeleteID       = 1
+	registryGlobal        = 0
+	callbackDone          = 0
+	bufferRelease         = 0
+	seatCapabilities      = 0
+	pointerEnter          = 0
+	pointerLeave          = 1
+	pointerMotion         = 2
+	pointerButton         = 3
+	layerSurfaceConfigure = 0
+	layerSurfaceClosed    = 1
+)
+
+// Enum values.
+const (
+	shmFormatARGB8888 = 0
+	seatPointer       = 1 // wl_seat capability
+	layerTop          = 2 // zwlr_layer_shell_v1 layer, above windows and below fullscreen ones
+	buttonPressed     = 1 // wl_pointer button state
+
+	btnLeft  = 0x110 // linux/input-event-codes.h
+	btnRight = 0x111
+)
+
+// fd is a request argument sent as a file descriptor.
+type fd int
+
+// conn is a client connection to a Wayland compositor.
+type conn struct {
+	sock *net.UnixConn
+
+	wlock  sync.Mutex // serializes requests
+	lastID uint32
+
+	buf []byte // read and not yet returned by next
+}
+
+// dial connects to the compositor of $WAYLAND_DISPLAY, wayland-0 if unset,
+// a socket in $XDG_RUNTIME_DIR unless it is an absolute path.
+func dial() (*conn, error) {
+	name := os.Getenv("WAYLAND_DISPLAY")
+	if name == "" {
+		name = "wayland-0"
+	}
+	if !filepath.IsAbs(name) {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			return nil, errors.New("XDG_RUNTIME_DIR is not set")
+		}
+		name = filepath.Join(dir, name)
+	}
+	sock, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	return &conn{sock: sock, lastID: displayID}, nil
+}
+
+func (c *conn) Close() error {
+	return c.sock.Close()
+}
+
+// newID allocates the ID of a new object. IDs are not reused, as 2^32
+// are plenty for objects created on each redraw.
+func (c *conn) newID() uint32 {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	c.lastID++
+	return c.lastID
+}
+
+// send sends request opcode to object id. Arguments are uint32 for uint,
+// object and new_id arguments, int32, string and fd.
+func (c *conn) send(id uint32, opcode uint16, args ...interface{}) error {
+	msg := make([]byte, 8, 64)
+	var fds []int
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case uint32:
+			msg = binary.NativeEndian.AppendUint32(msg, a)
+		case int32:
+			msg = binary.NativeEndian.AppendUint32(msg, uint32(a))
+		case string:
+			// NUL terminated, padded to 32 bits.
+			msg = binary.NativeEndian.AppendUint32(msg, uint32(len(a)+1))
+			msg = append(msg, a...)
+			msg = append(msg, make([]byte, 4-len(a)%4)...)
+		case fd:
+			fds = append(fds, int(a))
+		default:
+			panic(fmt.Sprintf("wayland: unsupported argument type %T", arg))
+		}
+	}
+	binary.NativeEndian.PutUint32(msg[0:], id)
+	binary.NativeEndian.PutUint32(msg[4:], uint32(len(msg))<<16|uint32(opcode))
+	var oob []byte
+	if len(fds) > 0 {
+		oob = syscall.UnixRights(fds...)
+	}
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	_, _, err := c.sock.WriteMsgUnix(msg, oob, nil)
+	return err
+}
+
+// event is an event sent by the compositor, its arguments read in order
+// with the methods below.
+type event struct {
+	sender uint32
+	opcode uint16
+	args   []byte
+}
+
+func (e *event) uint() uint32 {
+	if len(e.args) < 4 {
+		e.args = nil
+		return 0
+	}
+	v := binary.NativeEndian.Uint32(e.args)
+	e.args = e.args[4:]
+	return v
+}
+
+func (e *event) int() int32 {
+	return int32(e.uint())
+}
+
+// fixed reads a 24.8 fixed point number.
+func (e *event) fixed() float64 {
+	return float64(e.int()) / 256
+}
+
+func (e *event) string() string {
+	n := int(e.uint())
+	padded := (n + 3) &^ 3
+	if n == 0 || padded > len(e.args) {
+		e.args = nil
+		return ""
+	}
+	s := string(e.args[:n-1])
+	e.args = e.args[padded:]
+	return s
+}
+
+// next reads the next event. It must not be called concurrently.
+func (c *conn) next() (event, error) {
+	for {
+		if len(c.buf) >= 8 {
+			header := binary.NativeEndian.Uint32(c.buf[4:])
+			size := int(header >> 16)
+			if size < 8 {
+				return event{}, fmt.Errorf("wayland: invalid message size %v", size)
+			}
+			if len(c.buf) >= size {
+				e := event{
+					sender: binary.NativeEndian.Uint32(c.buf),
+					opcode: uint16(header),
+					args:   c.buf[8:size:size],
+				}
+				c.buf = c.buf[size:]
+				return e, nil
+			}
+		}
+		if err := c.fill(); err != nil {
+			return event{}, err
+		}
+	}
+}
+
+// fill reads what the compositor sent. None of the events handled carry
+// file descriptors, any sent are closed.
+func (c *conn) fill() error {
+	buf := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(28*4)) // libwayland sends at most 28 at once
+	n, oobn, _, _, err := c.sock.ReadMsgUnix(buf, oob)
+	if oobn > 0 {
+		msgs, _ := syscall.ParseSocketControlMessage(oob[:oobn])
+		for i := range msgs {
+			fds, _ := syscall.ParseUnixRights(&msgs[i])
+			for _, fd := range fds {
+				syscall.Close(fd)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return io.EOF
+	}
+	c.buf = append(c.buf, buf[:n]...)
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build unix
+
+package layershell
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// pipe returns a conn and the compositor end of its socket.
+func pipe(t *testing.T) (*conn, *net.UnixConn) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ends := make([]*net.UnixConn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "wayland")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ends[i] = c.(*net.UnixConn)
+		t.Cleanup(func() { c.Close() })
+	}
+	return &conn{sock: ends[0], lastID: displayID}, ends[1]
+}
+
+// message encodes a message the way libwayland does.
+func message(id uint32, opcode uint16, args ...uint32) []byte {
+	msg := binary.NativeEndian.AppendUint32(nil, id)
+	msg = binary.NativeEndian.AppendUint32(msg, uint32(8+4*len(args))<<16|uint32(opcode))
+	for _, a := range args {
+		msg = binary.NativeEndian.AppendUint32(msg, a)
+	}
+	return msg
+}
+
+// words returns s as the words of a string argument.
+func words(s string) []uint32 {
+	b := append([]byte(s), 0)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	w := []uint32{uint32(len(s) + 1)}
+	for i := 0; i < len(b); i += 4 {
+		w = append(w, binary.NativeEndian.Uint32(b[i:]))
+	}
+	return w
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name string
+		args []interface{}
+		want []uint32
+	}{
+		{"none", nil, nil},
+		{"uint and int", []interface{}{uint32(7), int32(-1)}, []uint32{7, 0xffffffff}},
+		{"empty string", []interface{}{""}, words("")},
+		{"string of 3", []interface{}{"abc"}, words("abc")},
+		{"string of 4", []interface{}{"wl_s"}, words("wl_s")},
+		{"string and uint", []interface{}{ifaceShm, uint32(1)}, append(words(ifaceShm), 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, server := pipe(t)
+			if err := c.send(3, 2, tt.args...); err != nil {
+				t.Fatal(err)
+			}
+			want := message(3, 2, tt.want...)
+			got := make([]byte, len(want)+16)
+			n, err := server.Read(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got[:n], want) {
+				t.Errorf("sent %x, want %x", got[:n], want)
+			}
+		})
+	}
+}
+
+func TestSendFD(t *testing.T) {
+	c, server := pipe(t)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if err := c.send(4, shmCreatePool, uint32(5), fd(w.Fd()), int32(64)); err != nil {
+		t.Fatal(err)
+	}
+	buf, oob := make([]byte, 64), make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := server.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fd travels beside the message, not in it.
+	if want := message(4, shmCreatePool, 5, 64); !bytes.Equal(buf[:n], want) {
+		t.Errorf("sent %x, want %x", buf[:n], want)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("control messages %v, %v", msgs, err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("fds %v, %v", fds, err)
+	}
+	syscall.Close(fds[0])
+}
+
+func TestNext(t *testing.T) {
+	c, server := pipe(t)
+	global := message(2, registryGlobal, append(append([]uint32{9}, words(ifaceSeat)...), 5)...)
+	motion := message(7, pointerMotion, 100, 10<<8|128, 0xffffff00)
+	stream := append(append([]byte{}, global...), motion...)
+	// split inside the header of the second message, as reads may.
+	go func() {
+		server.Write(stream[:len(global)+5])
+		server.Write(stream[len(global)+5:])
+	}()
+
+	e, err := c.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.sender != 2 || e.opcode != registryGlobal {
+		t.Fatalf("event %v.%v, want 2.%v", e.sender, e.opcode, registryGlobal)
+	}
+	if name, iface, version := e.uint(), e.string(), e.uint(); name != 9 || iface != ifaceSeat || version != 5 {
+		t.Errorf("global %v %q %v, want 9 %q 5", name, iface, version, ifaceSeat)
+	}
+
+	e, err = c.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time, x, y := e.uint(), e.fixed(), e.fixed(); time != 100 || x != 10.5 || y != -1 {
+		t.Errorf("motion %v %v %v, want 100 10.5 -1", time, x, y)
+	}
+	// reading past the arguments gives zero values.
+	if v, s := e.uint(), e.string(); v != 0 || s != "" {
+		t.Errorf("past the end: %v %q", v, s)
+	}
+}
+
+func TestNextInvalidSize(t *testing.T) {
+	c, server := pipe(t)
+	msg := message(1, 0)
+	binary.NativeEndian.PutUint32(msg[4:], 4<<16)
+	go server.Write(msg)
+	if _, err := c.next(); err == nil {
+		t.Error("no error for a message shorter than its header")
+	}
+}
+
+func TestEventStringTruncated(t *testing.T) {
+	// a length running past the message.
+	e := event{args: binary.NativeEndian.AppendUint32(nil, 100)}
+	if s := e.string(); s != "" || e.args != nil {
+		t.Errorf("string() = %q, args %v", s, e.args)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"time"
+
+	"github.com/esiqveland/notify"
+)
+
+// Option configures a Server created with New.
+type Option func(*Server)
+
+// WithCapabilities sets the capabilities the Server reports from
+// GetCapabilities, e.g. "actions", "body", "body-markup", "icon-static" or
+// "persistence". Clients use them to decide what to send, so only list what
+// the Renderer actually supports. The default is "actions" and "body".
+//
+// See the Capabilities section of the spec.
+func WithCapabilities(caps ...string) Option {
+	return func(s *Server) {
+		s.caps = append([]string{}, caps...)
+	}
+}
+
+// WithServerInformation sets what the Server reports from
+// GetServerInformation.
+func WithServerInformation(info notify.ServerInformation) Option {
+	return func(s *Server) {
+		s.info = info
+	}
+}
+
+// WithDefaultTimeout sets how long notifications with urgency u are shown
+// when the sender leaves the expiry to the server (an expire_timeout of -1).
+// A timeout of 0 means they never expire.
+// The defaults are 5 seconds for low, 10 seconds for normal urgency, and
+// never for critical notifications.
+func WithDefaultTimeout(u notify.Urgency, timeout time.Duration) Option {
+	return func(s *Server) {
+		s.timeouts[u] = timeout
+	}
+}
+
+// NotifyHook is called for every incoming notification before it is shown.
+// It can change n, e.g. to rewrite hints or lower the urgency, and can log
+// or forward it. Returning an error rejects the notification: the sender gets
+// the error as a D-Bus error, as is if it is a *dbus.Error, as
+// org.freedesktop.DBus.Error.Failed otherwise, unless it is ErrDropped,
+// which discards the notification quietly. n.ID is not assigned yet.
+type NotifyHook func(n *Notification) error
+
+// CloseHook is called after a notification is closed, for any reason.
+type CloseHook func(id uint32, reason notify.Reason)
+
+// WithNotifyHook adds a hook run on incoming notifications. Hooks run in the
+// order they are added, each seeing the changes of the previous ones, and
+// the first error stops the chain.
+func WithNotifyHook(hook NotifyHook) Option {
+	return func(s *Server) {
+		s.notifyHooks = append(s.notifyHooks, hook)
+	}
+}
+
+// WithCloseHook adds a hook run when a notification is closed.
+func WithCloseHook(hook CloseHook) Option {
+	return func(s *Server) {
+		s.closeHooks = append(s.closeHooks, hook)
+	}
+}
+
+// WithClock makes the Server time the expiry of notifications with c
+// instead of notify.SystemClock.
+func WithClock(c notify.Clock) Option {
+	return func(s *Server) {
+		s.clock = c
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/godbus/dbus/v5"
+)
+
+// AppPolicy limits the notifications of an application. Zero fields don't
+// limit.
+type AppPolicy struct {
+	// MaxActive is how many notifications of the application are shown at
+	// once. The oldest are closed, as expired, to show new ones.
+	MaxActive int
+	// Timeout replaces the expiry the application asks for, also for
+	// notifications it wants shown until closed.
+	Timeout time.Duration
+	// Mute discards all notifications of the application; senders get IDs
+	// as usual.
+	Mute bool
+	// MaxUrgency caps the urgency, e.g. at UrgencyNormal for applications
+	// abusing critical notifications, which don't expire.
+	MaxUrgency *notify.Urgency
+}
+
+// WithAppPolicy sets the policy of the application app. Notifications are
+// of app if the executable of the sending process is named app, or,
+// failing that, if their desktop-entry hint is app. The executable is
+// found from the process ID the bus reports for the sender, so unlike the
+// hint it can't be set to anything by the application; it is the
+// Executable of the Credentials of the notification, only known on Linux.
+// Policies apply after the notify hooks.
+func WithAppPolicy(app string, p AppPolicy) Option {
+	return func(s *Server) {
+		if s.policies == nil {
+			s.policies = make(map[string]AppPolicy)
+		}
+		s.policies[app] = p
+	}
+}
+
+// policyOf returns the application n is of and its policy, if it has one.
+func (s *Server) policyOf(n Notification) (string, AppPolicy, bool) {
+	if len(s.policies) == 0 {
+		return "", AppPolicy{}, false
+	}
+	if n.Credentials != nil && n.Credentials.Executable != "" {
+		exe := n.Credentials.Executable
+		if p, ok := s.policies[exe]; ok {
+			return exe, p, true
+		}
+	}
+	if entry, ok := n.HintString(notify.HintDesktopEntry); ok && entry != "" {
+		if p, ok := s.policies[entry]; ok {
+			return entry, p, true
+		}
+	}
+	return "", AppPolicy{}, false
+}
+
+// apply changes n as p says.
+func (p AppPolicy) apply(n *Notification) {
+	if p.Timeout > 0 {
+		n.ExpireTimeout = int32(p.Timeout / time.Millisecond)
+	}
+	if p.MaxUrgency != nil && n.Urgency() > *p.MaxUrgency {
+		// the hints are this notification's own, decoded from the call.
+		if n.Hints == nil {
+			n.Hints = make(map[string]dbus.Variant)
+		}
+		n.Hints[notify.HintUrgency] = dbus.MakeVariant(byte(*p.MaxUrgency))
+	}
+}
+
+// limitActive records that the notification with id is of app, and closes
+// the oldest notifications of app beyond max.
+func (s *Server) limitActive(app string, id uint32, max int) {
+	s.lock.Lock()
+	s.apps[id] = app
+	var ids []uint32
+	for other, a := range s.apps {
+		if _, ok := s.active[other]; ok && a == app {
+			ids = append(ids, other)
+		}
+	}
+	s.lock.Unlock()
+	if len(ids) <= max {
+		return
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, old := range ids[:len(ids)-max] {
+		s.close(old, notify.ReasonExpired)
+	}
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/internal/configfile"
+	"github.com/esiqveland/notify/internal/toml"
+	"github.com/godbus/dbus/v5"
+)
+
+// Rule actions.
+const (
+	RuleDrop      = "drop"       // discard the notification
+	RuleDowngrade = "downgrade"  // lower the urgency, to the urgency in "to" or by one level
+	RuleRewrite   = "rewrite"    // replace the summary and body with "set_summary" and "set_body"
+	RuleRateLimit = "rate-limit" // discard notifications of an application beyond "limit" per "period"
+)
+
+// Rules filter incoming notifications to keep spam off the screen. They are
+// read from a TOML file, a [[rule]] table per rule:
+//
+//	# at most 3 notifications a minute from the chat application
+//	[[rule]]
+//	app = "chat"
+//	action = "rate-limit"
+//	limit = 3
+//	period = "1m"
+//
+//	[[rule]]
+//	summary = '(?i)\b(prize|offer)\b'
+//	action = "drop"
+//
+//	[[rule]]
+//	app = "ci"
+//	urgency = "critical"
+//	action = "downgrade"
+//	to = "normal"
+//
+//	[[rule]]
+//	summary = '^Build (\d+) (passed|failed)$'
+//	action = "rewrite"
+//	set_summary = "#$1 $2"
+//
+// A rule matches the notifications with the app name in app, with a summary
+// matching the regular expression in summary, and with the urgency in
+// urgency, "low", "normal" or "critical"; a rule without them matches all.
+// The actions are RuleDrop, RuleDowngrade, RuleRewrite and RuleRateLimit.
+// A rewrite with a summary expression expands $1 and the like in
+// set_summary and set_body to its submatches. Rate limits count the
+// notifications of each application on their own.
+//
+// Every matching rule applies, in order, each seeing the changes of the
+// ones before, until the notification is discarded. Discarded
+// notifications don't reach the Renderer, but the sender gets an ID as
+// usual: spammers needn't know.
+//
+// The file is checked for changes with every notification, so edits take
+// effect without restarting the server; if it doesn't parse, the rules read
+// before stay. Install the rules with WithNotifyHook(rules.Filter).
+type Rules struct {
+	path  string
+	clock notify.Clock
+
+	lock     sync.Mutex
+	modified time.Time
+	rules    []*rule
+}
+
+// rulesFile is the form of the rules file.
+type rulesFile struct {
+	Rule []ruleConfig `json:"rule"`
+}
+
+type ruleConfig struct {
+	App     string `json:"app"`
+	Summary string `json:"summary"`
+	Urgency string `json:"urgency"`
+
+	Action     string  `json:"action"`
+	To         string  `json:"to"`
+	SetSummary *string `json:"set_summary"`
+	SetBody    *string `json:"set_body"`
+	Limit      int     `json:"limit"`
+	Period     string  `json:"period"`
+}
+
+// rule is a parsed ruleConfig, with the state of its rate limit.
+type rule struct {
+	ruleConfig
+	summary *regexp.Regexp
+	urgency *notify.Urgency
+	to      *notify.Urgency
+	period  time.Duration
+
+	sent  map[string][]time.Time // of the notifications let through in the last period, by app name
+	swept time.Time
+}
+
+// LoadRules reads the rules file at path. A missing file gives no rules,
+// until it is created.
+func LoadRules(path string) (*Rules, error) {
+	r := &Rules{path: path, clock: notify.SystemClock}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the file if it changed since it was last read.
+// r.lock must be held, except from LoadRules.
+func (r *Rules) reload() error {
+	return configfile.Reload(r.path, &r.modified, func(data []byte) error {
+		var f rulesFile
+		if err := toml.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("%v: %w", r.path, err)
+		}
+		rules := make([]*rule, len(f.Rule))
+		for i, config := range f.Rule {
+			var err error
+			if rules[i], err = parseRule(config); err != nil {
+				return fmt.Errorf("%v: rule %v: %w", r.path, i+1, err)
+			}
+		}
+		r.rules = rules
+		return nil
+	})
+}
+
+func parseRule(config ruleConfig) (*rule, error) {
+	ru := &rule{ruleConfig: config, sent: make(map[string][]time.Time)}
+	var err error
+	if config.Summary != "" {
+		if ru.summary, err = regexp.Compile(config.Summary); err != nil {
+			return nil, err
+		}
+	}
+	if config.Urgency != "" {
+		if ru.urgency, err = parseUrgency(config.Urgency); err != nil {
+			return nil, err
+		}
+	}
+	switch config.Action {
+	case RuleDrop:
+	case RuleDowngrade:
+		if config.To != "" {
+			if ru.to, err = parseUrgency(config.To); err != nil {
+				return nil, err
+			}
+		}
+	case RuleRewrite:
+		if config.SetSummary == nil && config.SetBody == nil {
+			return nil, errors.New("rewrite without set_summary or set_body")
+		}
+	case RuleRateLimit:
+		if ru.period, err = time.ParseDuration(config.Period); err != nil || ru.period <= 0 {
+			return nil, fmt.Errorf("invalid period %q", config.Period)
+		}
+		if config.Limit < 0 {
+			return nil, fmt.Errorf("invalid limit %v", config.Limit)
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q", config.Action)
+	}
+	return ru, nil
+}
+
+func parseUrgency(s string) (*notify.Urgency, error) {
+	var u notify.Urgency
+	switch strings.ToLower(s) {
+	case "low":
+		u = notify.UrgencyLow
+	case "normal":
+		u = notify.UrgencyNormal
+	case "critical":
+		u = notify.UrgencyCritical
+	default:
+		return nil, fmt.Errorf("invalid urgency %q, want low, normal or critical", s)
+	}
+	return &u, nil
+}
+
+// Filter applies the rules to n. It returns ErrDropped for notifications
+// discarded by them; it is a NotifyHook.
+func (r *Rules) Filter(n *Notification) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.reload(); err != nil {
+		// keep the rules we have, a half written file must not let spam
+		// through.
+		log.Printf("error reloading notification rules: %v", err)
+	}
+	now := r.clock.Now()
+	for _, ru := range r.rules {
+		match := ru.match(*n)
+		if match == nil {
+			continue
+		}
+		switch ru.Action {
+		case RuleDrop:
+			return ErrDropped
+		case RuleDowngrade:
+			ru.downgrade(n)
+		case RuleRewrite:
+			ru.rewrite(n, match)
+		case RuleRateLimit:
+			if !ru.allow(n.AppName, now) {
+				return ErrDropped
+			}
+		}
+	}
+	return nil
+}
+
+// match returns the submatches of the summary expression if the rule
+// matches n, nil if it doesn't.
+func (ru *rule) match(n Notification) []int {
+	if ru.App != "" && n.AppName != ru.App {
+		return nil
+	}
+	if ru.urgency != nil && n.Urgency() != *ru.urgency {
+		return nil
+	}
+	if ru.summary == nil {
+		return []int{}
+	}
+	return ru.summary.FindStringSubmatchIndex(n.Summary)
+}
+
+func (ru *rule) downgrade(n *Notification) {
+	u := n.Urgency()
+	switch {
+	case ru.to != nil && *ru.to < u:
+		u = *ru.to
+	case ru.to == nil && u > notify.UrgencyLow:
+		u--
+	default:
+		return
+	}
+	// the hints are this notification's own, decoded from the call.
+	if n.Hints == nil {
+		n.Hints = make(map[string]dbus.Variant)
+	}
+	n.Hints[notify.HintUrgency] = dbus.MakeVariant(byte(u))
+}
+
+func (ru *rule) rewrite(n *Notification, match []int) {
+	expand := func(template string) string {
+		if ru.summary == nil {
+			return template
+		}
+		return string(ru.summary.ExpandString(nil, template, n.Summary, match))
+	}
+	summary, body := n.Summary, n.Body
+	if ru.SetSummary != nil {
+		summary = expand(*ru.SetSummary)
+	}
+	if ru.SetBody != nil {
+		body = expand(*ru.SetBody)
+	}
+	n.Summary, n.Body = summary, body
+}
+
+// allow reports whether a notification of app at now is within the rate
+// limit, and counts it if it is.
+func (ru *rule) allow(app string, now time.Time) bool {
+	if now.Sub(ru.swept) >= ru.period {
+		// forget applications quiet for a period.
+		ru.swept = now
+		for a, times := range ru.sent {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) >= ru.period {
+				delete(ru.sent, a)
+			}
+		}
+	}
+	times := ru.sent[app]
+	for len(times) > 0 && now.Sub(times[0]) >= ru.period {
+		times = times[1:]
+	}
+	if len(times) >= ru.Limit {
+		ru.sent[app] = times
+		return false
+	}
+	ru.sent[app] = append(times, now)
+	return true
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/internal/credentials"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusObjectPath             = "/org/freedesktop/Notifications" // the DBUS object path
+	dbusNotificationsInterface = "org.freedesktop.Notifications"  // DBUS Interface
+	signalNotificationClosed   = "org.freedesktop.Notifications.NotificationClosed"
+	signalActionInvoked        = "org.freedesktop.Notifications.ActionInvoked"
+	errorNotFound              = "org.freedesktop.Notifications.Error.NotFound"
+
+	actionDefault = "default" // key of the action invoked when the notification itself is clicked
+)
+
+// ErrNameTaken is returned by New if another notification server already
+// owns the org.freedesktop.Notifications name.
+var ErrNameTaken = errors.New("another notification server is running")
+
+// ErrNotFound is returned for operations on a notification that is not, or
+// no longer, active.
+var ErrNotFound = errors.New("no such notification")
+
+// ErrDropped is returned by a NotifyHook to discard a notification: it is
+// not shown, but the sender gets an ID as if it were.
+var ErrDropped = errors.New("notification dropped")
+
+// Notification is a notification received by a Server.
+type Notification struct {
+	notify.Notification
+	ID     uint32 // the ID handed out to the sender
+	Sender string // unique bus name of the sending connection
+	// Credentials are those of the process behind Sender, as reported by
+	// the bus, or nil if it couldn't tell. Unlike AppName the sender can't
+	// fake them.
+	Credentials *notify.Credentials
+}
+
+// Renderer displays the notifications received by a Server.
+//
+// Show and Hide are called from the goroutines handling D-Bus calls, and may
+// be called concurrently.
+type Renderer interface {
+	// Show displays n, replacing any notification with the same ID.
+	Show(n Notification)
+	// Hide removes the notification with id from display.
+	Hide(id uint32)
+}
+
+// Server exports the org.freedesktop.Notifications interface on a bus
+// connection and forwards received notifications to a Renderer.
+type Server struct {
+	conn     *dbus.Conn
+	renderer Renderer
+	caps     []string
+	info     notify.ServerInformation
+	timeouts map[notify.Urgency]time.Duration // used for an expire_timeout of -1
+	clock    notify.Clock                     // runs the expiry timers
+
+	notifyHooks []NotifyHook
+	closeHooks  []CloseHook
+	policies    map[string]AppPolicy // by application, see WithAppPolicy
+	senders     *credentials.Cache   // see Notification.Credentials
+
+	lock   sync.Mutex
+	lastID uint32
+	active map[uint32]*Notification
+	timers map[uint32]notify.Alarm
+	apps   map[uint32]string // applications of active notifications with a MaxActive policy
+}
+
+// New creates a Server that exports the notification interface on conn,
+// takes ownership of the org.freedesktop.Notifications name, and shows
+// notifications using renderer, configured by opts.
+//
+// Caller is responsible to call Close() to release the name.
+func New(conn *dbus.Conn, renderer Renderer, opts ...Option) (*Server, error) {
+	s := &Server{
+		conn:     conn,
+		renderer: renderer,
+		caps:     []string{"actions", "body"},
+		info: notify.ServerInformation{
+			Name:        "notify",
+			Vendor:      "esiqveland",
+			Version:     "0.1",
+			SpecVersion: "1.2",
+		},
+		timeouts: map[notify.Urgency]time.Duration{
+			notify.UrgencyLow:    5 * time.Second,
+			notify.UrgencyNormal: 10 * time.Second,
+			// critical notifications never expire, see the spec.
+			notify.UrgencyCritical: 0,
+		},
+		active:  make(map[uint32]*Notification),
+		timers:  make(map[uint32]notify.Alarm),
+		apps:    make(map[uint32]string),
+		senders: credentials.New(conn),
+		clock:   notify.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	err := conn.Export(handler{s}, dbusObjectPath, dbusNotificationsInterface)
+	if err != nil {
+		return nil, err
+	}
+	if err := exportIntrospection(conn); err != nil {
+		s.unexport()
+		return nil, err
+	}
+	reply, err := conn.RequestName(dbusNotificationsInterface, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		s.unexport()
+		return nil, err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		s.unexport()
+		return nil, ErrNameTaken
+	}
+	return s, nil
+}
+
+// Close releases the org.freedesktop.Notifications name and stops handling
+// calls. The connection is left open.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	for id, t := range s.timers {
+		t.Stop()
+		delete(s.timers, id)
+	}
+	s.lock.Unlock()
+	_, err := s.conn.ReleaseName(dbusNotificationsInterface)
+	s.unexport()
+	return err
+}
+
+func (s *Server) unexport() {
+	s.conn.Export(nil, dbusObjectPath, dbusNotificationsInterface)
+	unexportIntrospection(s.conn)
+}
+
+// notify stores n, allocating an ID unless it replaces an active
+// notification, shows it and starts its expiry timer.
+func (s *Server) notify(n Notification) uint32 {
+	s.lock.Lock()
+	id := n.ReplacesID
+	if _, ok := s.active[id]; id == 0 || !ok {
+		id = s.newID()
+	}
+	n.ID = id
+	note := &n
+	s.active[id] = note
+	if t, ok := s.timers[id]; ok {
+		t.Stop()
+		delete(s.timers, id)
+	}
+	if timeout := s.expireTimeout(n); timeout > 0 {
+		s.timers[id] = s.clock.AfterFunc(timeout, func() {
+			s.remove(id, note, notify.ReasonExpired)
+		})
+	}
+	s.lock.Unlock()
+
+	s.renderer.Show(n)
+	return id
+}
+
+// newID allocates a notification ID. s.lock must be held.
+func (s *Server) newID() uint32 {
+	s.lastID++
+	// IDs are never zero, see the spec.
+	if s.lastID == 0 {
+		s.lastID++
+	}
+	return s.lastID
+}
+
+// drop hands out an ID for a notification discarded by a hook.
+func (s *Server) drop() uint32 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.newID()
+}
+
+// expireTimeout returns how long n is shown, or 0 if it never expires.
+func (s *Server) expireTimeout(n Notification) time.Duration {
+	switch {
+	case n.ExpireTimeout > 0:
+		return time.Duration(n.ExpireTimeout) * time.Millisecond
+	case n.ExpireTimeout == 0:
+		return 0
+	}
+	return s.timeouts[n.Urgency()]
+}
+
+// close removes the notification with id and emits NotificationClosed.
+// It returns false if there is no such notification.
+func (s *Server) close(id uint32, reason notify.Reason) bool {
+	return s.remove(id, nil, reason)
+}
+
+// remove is close, but if match is set only removes the notification if
+// it is still match, so a timer of a replaced notification doesn't close
+// its replacement.
+func (s *Server) remove(id uint32, match *Notification, reason notify.Reason) bool {
+	s.lock.Lock()
+	n, ok := s.active[id]
+	if ok && match != nil && n != match {
+		ok = false
+	}
+	if ok {
+		delete(s.active, id)
+		delete(s.apps, id)
+		if t, found := s.timers[id]; found {
+			t.Stop()
+			delete(s.timers, id)
+		}
+	}
+	s.lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	s.renderer.Hide(id)
+	s.conn.Emit(dbusObjectPath, signalNotificationClosed, id, uint32(reason))
+	for _, hook := range s.closeHooks {
+		hook(id, reason)
+	}
+	return true
+}
+
+// InvokeAction reports that the user invoked the action key of the
+// notification with id: it emits ActionInvoked, and closes the notification
+// with ReasonDismissedByUser unless it is resident.
+// Renderers call it when the user clicks a notification (the "default"
+// action) or one of its buttons.
+func (s *Server) InvokeAction(id uint32, key string) error {
+	s.lock.Lock()
+	n, ok := s.active[id]
+	s.lock.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	if !hasAction(n.Actions, key) {
+		return errors.New("no such action: " + key)
+	}
+
+	err := s.conn.Emit(dbusObjectPath, signalActionInvoked, id, key)
+	if err != nil {
+		return err
+	}
+	if n.Resident() {
+		return nil
+	}
+	s.close(id, notify.ReasonDismissedByUser)
+	return nil
+}
+
+// Dismiss closes the notification with id and emits NotificationClosed with
+// reason. Renderers call it with ReasonDismissedByUser when the user closes a
+// notification, or ReasonExpired when they expire it themselves.
+func (s *Server) Dismiss(id uint32, reason notify.Reason) error {
+	if !s.close(id, reason) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func hasAction(actions []string, key string) bool {
+	for i := 0; i < len(actions); i += 2 {
+		if actions[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// handler holds the methods called over D-Bus, so they don't become part
+// of the Server's go API.
+type handler struct {
+	s *Server
+}
+
+func (h handler) Notify(sender dbus.Sender, appName string, replacesID uint32, appIcon, summary, body string,
+	actions []string, hints map[string]dbus.Variant, expireTimeout int32) (uint32, *dbus.Error) {
+	n := Notification{
+		Notification: notify.Notification{
+			AppName:       appName,
+			ReplacesID:    replacesID,
+			AppIcon:       appIcon,
+			Summary:       summary,
+			Body:          body,
+			Actions:       actions,
+			Hints:         hints,
+			ExpireTimeout: expireTimeout,
+		},
+		Sender:      string(sender),
+		Credentials: h.s.senders.Lookup(string(sender)),
+	}
+	for _, hook := range h.s.notifyHooks {
+		if err := hook(&n); err != nil {
+			if errors.Is(err, ErrDropped) {
+				return h.s.drop(), nil
+			}
+			if dbusErr, ok := err.(*dbus.Error); ok {
+				return 0, dbusErr
+			}
+			return 0, dbus.MakeFailedError(err)
+		}
+	}
+	app, policy, ok := h.s.policyOf(n)
+	if !ok {
+		return h.s.notify(n), nil
+	}
+	if policy.Mute {
+		return h.s.drop(), nil
+	}
+	policy.apply(&n)
+	id := h.s.notify(n)
+	if policy.MaxActive > 0 {
+		h.s.limitActive(app, id, policy.MaxActive)
+	}
+	return id, nil
+}
+
+// CloseNotification closes the notification with id. If it no longer
+// exists an empty D-Bus error is sent back, as the spec says.
+func (h handler) CloseNotification(id uint32) *dbus.Error {
+	if !h.s.close(id, notify.ReasonClosedByCall) {
+		return dbus.NewError(errorNotFound, nil)
+	}
+	return nil
+}
+
+func (h handler) GetCapabilities() ([]string, *dbus.Error) {
+	return h.s.caps, nil
+}
+
+func (h handler) GetServerInformation() (string, string, string, string, *dbus.Error) {
+	info := h.s.info
+	return info.Name, info.Vendor, info.Version, info.SpecVersion, nil
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/esiqveland/notify"
+)
+
+// Terminal is a Renderer that shows notifications as an interactive list on
+// a terminal, for headless or SSH sessions and for testing.
+//
+// The terminal is expected to be in raw mode, so single key presses are
+// read as they are typed:
+//
+//	j, k, arrow keys	move the selection
+//	enter			invoke the default action
+//	1-9			invoke the n-th action
+//	d			dismiss
+//	q			quit
+type Terminal struct {
+	in  io.Reader
+	out io.Writer
+
+	lock     sync.Mutex
+	list     []Notification
+	selected int
+}
+
+// NewTerminal creates a Terminal reading key presses from in and drawing
+// to out.
+func NewTerminal(in io.Reader, out io.Writer) *Terminal {
+	return &Terminal{
+		in:  in,
+		out: out,
+	}
+}
+
+// Show adds n to the list, or replaces the entry with the same ID.
+func (t *Terminal) Show(n Notification) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i := range t.list {
+		if t.list[i].ID == n.ID {
+			t.list[i] = n
+			t.draw()
+			return
+		}
+	}
+	t.list = append(t.list, n)
+	t.draw()
+}
+
+// Hide removes the notification with id from the list.
+func (t *Terminal) Hide(id uint32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i := range t.list {
+		if t.list[i].ID == id {
+			t.list = append(t.list[:i], t.list[i+1:]...)
+			break
+		}
+	}
+	if t.selected >= len(t.list) && t.selected > 0 {
+		t.selected = len(t.list) - 1
+	}
+	t.draw()
+}
+
+// Run reads key presses and reports interactions to s, until q is pressed
+// or the input ends.
+func (t *Terminal) Run(s *Server) error {
+	t.lock.Lock()
+	t.draw()
+	t.lock.Unlock()
+
+	in := bufio.NewReader(t.in)
+	for {
+		key, err := in.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch key {
+		case 'q', 3: // ctrl-c doesn't raise a signal in raw mode
+			return nil
+		case 'j':
+			t.move(1)
+		case 'k':
+			t.move(-1)
+		case 0x1b: // arrow keys come as ESC [ A and ESC [ B
+			if b, _ := in.ReadByte(); b != '[' {
+				continue
+			}
+			switch b, _ := in.ReadByte(); b {
+			case 'A':
+				t.move(-1)
+			case 'B':
+				t.move(1)
+			}
+		case '\r', '\n':
+			t.invoke(s, actionDefault)
+		case 'd':
+			if n, ok := t.current(); ok {
+				s.Dismiss(n.ID, notify.ReasonDismissedByUser)
+			}
+		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			n, ok := t.current()
+			if !ok {
+				continue
+			}
+			actions := labelledActions(n.Actions)
+			if i := int(key - '1'); i < len(actions) {
+				t.invoke(s, actions[i][0])
+			}
+		}
+	}
+}
+
+func (t *Terminal) move(delta int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.selected += delta
+	if t.selected >= len(t.list) {
+		t.selected = len(t.list) - 1
+	}
+	if t.selected < 0 {
+		t.selected = 0
+	}
+	t.draw()
+}
+
+func (t *Terminal) current() (Notification, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.selected >= len(t.list) {
+		return Notification{}, false
+	}
+	return t.list[t.selected], true
+}
+
+func (t *Terminal) invoke(s *Server, key string) {
+	n, ok := t.current()
+	if !ok || !hasAction(n.Actions, key) {
+		return
+	}
+	if err := s.InvokeAction(n.ID, key); err != nil {
+		log.Printf("error invoking action %v on %v: %v", key, n.ID, err)
+	}
+}
+
+// draw redraws the whole list. Must be called with t.lock held.
+func (t *Terminal) draw() {
+	var b strings.Builder
+	// clear screen, and use \r\n since the terminal is in raw mode.
+	b.WriteString("\x1b[H\x1b[2J")
+	if len(t.list) == 0 {
+		b.WriteString("No notifications.\r\n")
+	}
+	for i, n := range t.list {
+		marker := "  "
+		if i == t.selected {
+			marker = "> "
+		}
+		// the text comes from any application on the bus.
+		fmt.Fprintf(&b, "%s[%d] %s: %s\r\n", marker, n.ID, notify.StripControl(n.AppName), notify.StripControl(n.Summary))
+		for _, line := range strings.Split(n.Body, "\n") {
+			if line = notify.StripControl(line); line != "" {
+				fmt.Fprintf(&b, "      %s\r\n", line)
+			}
+		}
+		if actions := labelledActions(n.Actions); len(actions) > 0 {
+			b.WriteString("     ")
+			for j, action := range actions {
+				fmt.Fprintf(&b, " %d) %s", j+1, notify.StripControl(action[1]))
+			}
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString("\r\nj/k: move  enter: open  1-9: action  d: dismiss  q: quit\r\n")
+	io.WriteString(t.out, b.String())
+}
+
+// labelledActions returns the (key, label) pairs of actions, leaving out
+// the default action, which is invoked with enter instead.
+func labelledActions(actions []string) [][2]string {
+	var ret [][2]string
+	for i := 0; i+1 < len(actions); i += 2 {
+		if actions[i] != actionDefault {
+			ret = append(ret, [2]string{actions[i], actions[i+1]})
+		}
+	}
+	return ret
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/esiqveland/notify"
+)
+
+func TestTerminalStripsEscapes(t *testing.T) {
+	var out strings.Builder
+	term := NewTerminal(strings.NewReader(""), &out)
+	term.Show(Notification{
+		ID: 1,
+		Notification: notify.Notification{
+			AppName: "evil\x1b]0;title\x07",
+			Summary: "copied \x1b]52;c;ZWNobyBwd25lZA==\x07 to clipboard",
+			Body:    "line\u009b2J\nsecond\r line",
+			Actions: []string{"open", "Open\x1b[2J"},
+		},
+	})
+	// the only escape left is the one clearing the screen.
+	got := strings.Replace(out.String(), "\x1b[H\x1b[2J", "", 1)
+	if i := strings.IndexAny(got, "\x1b\x07\u009b"); i >= 0 {
+		t.Fatalf("control character left in %q at %v", got, i)
+	}
+	if !strings.Contains(got, "copied ]52;c;ZWNobyBwd25lZA== to clipboard") {
+		t.Errorf("summary not shown: %q", got)
+	}
+}
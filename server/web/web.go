@@ -0,0 +1,288 @@
+/*
+Package web is a server.Renderer showing notifications on a web page, for
+kiosks and remote displays where the browser is the only screen:
+
+	r := web.New()
+	srv, err := server.New(conn, r)
+	...
+	log.Fatal(http.ListenAndServe("localhost:8080", r.Handler(srv)))
+
+The handler serves the page at / and streams the notifications to it over
+a WebSocket at /ws, as JSON messages:
+
+	{"type": "show", "notification": {"id": 3, "app_name": "mail", "summary": ...,
+	 "body": ..., "urgency": "normal", "icon": "data:image/png;base64,...",
+	 "actions": [{"key": "reply", "label": "Reply"}]}}
+	{"type": "hide", "id": 3}
+
+Connecting pages get a show message for every active notification first.
+The page sends back the clicks on a notification and its buttons, and
+closing it, which the handler reports to the Server with InvokeAction and
+Dismiss:
+
+	{"type": "action", "id": 3, "key": "reply"}
+	{"type": "dismiss", "id": 3}
+
+Anyone who can open the page can invoke actions, so listen on localhost or
+a trusted network only. WebSocket connections from pages of other sites
+are refused, and so are requests for host names other than the loopback
+ones and those given to New, which a site rebinding its name to this
+machine's address would send:
+
+	r := web.New("kiosk.lan")
+*/
+package web
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/esiqveland/notify"
+	"github.com/esiqveland/notify/server"
+	"golang.org/x/net/websocket"
+)
+
+// clientBufferSize is how many messages a page may fall behind before it is
+// disconnected; it gets all notifications again when it reconnects.
+const clientBufferSize = 64
+
+// maxMessageSize caps the messages read from pages.
+const maxMessageSize = 4096
+
+//go:embed page.html
+var page []byte
+
+// Notification is the JSON form of a notification sent to pages.
+type Notification struct {
+	ID      uint32   `json:"id"`
+	AppName string   `json:"app_name,omitempty"`
+	Summary string   `json:"summary"`
+	Body    string   `json:"body,omitempty"`
+	Urgency string   `json:"urgency"`
+	Icon    string   `json:"icon,omitempty"` // a data URL of the image-data hint
+	Actions []Action `json:"actions,omitempty"`
+}
+
+// Action is an action of a Notification, the default action with the key
+// "default" included.
+type Action struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// message is a message to or from a page.
+type message struct {
+	Type         string        `json:"type"`
+	Notification *Notification `json:"notification,omitempty"`
+	ID           uint32        `json:"id,omitempty"`
+	Key          string        `json:"key,omitempty"`
+}
+
+// Renderer keeps the active notifications and sends them to the connected
+// pages.
+type Renderer struct {
+	lock    sync.Mutex
+	list    []*Notification
+	clients map[chan message]struct{}
+	hosts   map[string]bool // besides the loopback ones, see allowedHost
+}
+
+// New creates a Renderer. Serve its pages with Handler. It answers requests
+// for localhost, 127.0.0.1 and [::1], on any port, and for hosts, the names
+// or addresses other machines reach it under.
+func New(hosts ...string) *Renderer {
+	r := &Renderer{
+		clients: make(map[chan message]struct{}),
+		hosts:   make(map[string]bool),
+	}
+	for _, h := range hosts {
+		r.hosts[strings.ToLower(strings.Trim(h, "[]"))] = true
+	}
+	return r
+}
+
+// Show adds n to the page, or replaces the notification with the same ID.
+func (r *Renderer) Show(n server.Notification) {
+	note := convert(n)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	replaced := false
+	for i := range r.list {
+		if r.list[i].ID == n.ID {
+			r.list[i] = note
+			replaced = true
+		}
+	}
+	if !replaced {
+		r.list = append(r.list, note)
+	}
+	r.publish(message{Type: "show", Notification: note})
+}
+
+// Hide removes the notification with id from the page.
+func (r *Renderer) Hide(id uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i := range r.list {
+		if r.list[i].ID == id {
+			r.list = append(r.list[:i], r.list[i+1:]...)
+			break
+		}
+	}
+	r.publish(message{Type: "hide", ID: id})
+}
+
+// publish sends m to all pages, disconnecting those falling behind.
+// r.lock must be held.
+func (r *Renderer) publish(m message) {
+	for c := range r.clients {
+		select {
+		case c <- m:
+		default:
+			log.Printf("web page is falling behind, disconnecting it")
+			delete(r.clients, c)
+			close(c)
+		}
+	}
+}
+
+// subscribe returns a channel with show messages for the active
+// notifications, followed by the changes from now on, and a function to
+// call when done with it.
+func (r *Renderer) subscribe() (<-chan message, func()) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	c := make(chan message, clientBufferSize+len(r.list))
+	for _, note := range r.list {
+		c <- message{Type: "show", Notification: note}
+	}
+	r.clients[c] = struct{}{}
+	return c, func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if _, ok := r.clients[c]; ok {
+			delete(r.clients, c)
+			close(c)
+		}
+	}
+}
+
+// Handler returns the http.Handler serving the page, reporting the clicks
+// on it to s.
+func (r *Renderer) Handler(s *server.Server) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	mux.Handle("GET /ws", websocket.Server{
+		Handshake: sameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			r.serve(ws, s)
+		},
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.allowedHost(req.Host) {
+			http.Error(w, "unknown host", http.StatusMisdirectedRequest)
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+// allowedHost reports whether host, from the Host header, names this
+// server. Otherwise a page of another site may have rebound its name to
+// our address, which makes its origin match.
+func (r *Renderer) allowedHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return r.hosts[host]
+}
+
+// sameOrigin refuses WebSocket connections from pages of other sites, which
+// browsers let connect anywhere.
+func sameOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := url.Parse(req.Header.Get("Origin"))
+	if err != nil || origin.Host != req.Host {
+		return errors.New("cross-origin WebSocket connection")
+	}
+	config.Origin = origin
+	return nil
+}
+
+// serve streams the notifications to the page on ws, and reports what the
+// page sends back to s.
+func (r *Renderer) serve(ws *websocket.Conn, s *server.Server) {
+	ws.MaxPayloadBytes = maxMessageSize
+	messages, cancel := r.subscribe()
+	defer cancel()
+	go func() {
+		for m := range messages {
+			if err := websocket.JSON.Send(ws, m); err != nil {
+				break
+			}
+		}
+		// disconnected or falling behind, stop the loop below too.
+		ws.Close()
+	}()
+
+	for {
+		var m message
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			return
+		}
+		var err error
+		switch m.Type {
+		case "action":
+			err = s.InvokeAction(m.ID, m.Key)
+		case "dismiss":
+			err = s.Dismiss(m.ID, notify.ReasonDismissedByUser)
+		}
+		if err != nil && !errors.Is(err, server.ErrNotFound) {
+			log.Printf("error handling %v of %v from web page: %v", m.Type, m.ID, err)
+		}
+	}
+}
+
+// convert returns the JSON form of n.
+func convert(n server.Notification) *Notification {
+	note := &Notification{
+		ID:      n.ID,
+		AppName: n.AppName,
+		Summary: n.Summary,
+		Body:    n.Body,
+	}
+	switch n.Urgency() {
+	case notify.UrgencyLow:
+		note.Urgency = "low"
+	case notify.UrgencyCritical:
+		note.Urgency = "critical"
+	default:
+		note.Urgency = "normal"
+	}
+	for i := 0; i+1 < len(n.Actions); i += 2 {
+		note.Actions = append(note.Actions, Action{Key: n.Actions[i], Label: n.Actions[i+1]})
+	}
+	if img, err := n.Image(); err == nil {
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err == nil {
+			note.Icon = "data:image/png;base64," + base64.StdEncoding.EncodeToString(b.Bytes())
+		}
+	}
+	return note
+}
@@ -0,0 +1,26 @@
+package web
+
+import "testing"
+
+func TestAllowedHost(t *testing.T) {
+	r := New("kiosk.lan", "[fd00::1]")
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost:8080", true},
+		{"LOCALHOST", true},
+		{"127.0.0.1:8080", true},
+		{"[::1]:8080", true},
+		{"kiosk.lan:8080", true},
+		{"[fd00::1]:8080", true},
+		{"evil.example:8080", false},
+		{"127.0.0.2:8080", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := r.allowedHost(tt.host); got != tt.want {
+			t.Errorf("allowedHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	login1Destination     = "org.freedesktop.login1"
+	login1ObjectPath      = "/org/freedesktop/login1"
+	login1ListSessions    = "org.freedesktop.login1.Manager.ListSessions"
+	login1SessionProperty = "org.freedesktop.login1.Session."
+)
+
+// Session is a graphical login session found through logind.
+type Session struct {
+	ID         string
+	UID        uint32
+	User       string
+	Seat       string
+	BusAddress string // address of the user's session bus
+}
+
+// SessionResult is the outcome of delivering a notification to one session.
+type SessionResult struct {
+	Session Session
+	ID      uint32 // notification ID, if delivered
+	Err     error
+}
+
+// ActiveSessions lists the active graphical sessions known to logind, using
+// the system bus connection system. Sessions of the same user share a
+// session bus, so only the first of them is returned.
+//
+// Sessions without a discoverable session bus are left out.
+func ActiveSessions(system *dbus.Conn) ([]Session, error) {
+	type listedSession struct {
+		ID   string
+		UID  uint32
+		User string
+		Seat string
+		Path dbus.ObjectPath
+	}
+	var listed []listedSession
+	manager := system.Object(login1Destination, login1ObjectPath)
+	if err := manager.Call(login1ListSessions, 0).Store(&listed); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint32]bool)
+	var sessions []Session
+	for _, s := range listed {
+		if seen[s.UID] {
+			continue
+		}
+		obj := system.Object(login1Destination, s.Path)
+		if !graphicalSession(obj) {
+			continue
+		}
+		address := sessionBusAddress(obj, s.UID)
+		if address == "" {
+			continue
+		}
+		seen[s.UID] = true
+		sessions = append(sessions, Session{
+			ID:         s.ID,
+			UID:        s.UID,
+			User:       s.User,
+			Seat:       s.Seat,
+			BusAddress: address,
+		})
+	}
+	return sessions, nil
+}
+
+// SendToSessions delivers note to every active graphical session, for system
+// daemons running as root or a service user that has access to the users'
+// session buses. It returns the result for each session; the error is only
+// set if the sessions could not be listed.
+func SendToSessions(system *dbus.Conn, note Notification) ([]SessionResult, error) {
+	sessions, err := ActiveSessions(system)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SessionResult, 0, len(sessions))
+	for _, s := range sessions {
+		id, err := sendToSession(s, note)
+		results = append(results, SessionResult{Session: s, ID: id, Err: err})
+	}
+	return results, nil
+}
+
+func sendToSession(s Session, note Notification) (uint32, error) {
+	conn, err := dbus.Connect(s.BusAddress)
+	if err != nil {
+		return 0, fmt.Errorf("notify: connecting to session bus of %v: %w", s.User, err)
+	}
+	defer conn.Close()
+	return SendNotification(conn, note)
+}
+
+// graphicalSession reports whether the session behind obj is an active user
+// session on a display server.
+func graphicalSession(obj dbus.BusObject) bool {
+	var class, kind string
+	var active bool
+	if err := getProperty(obj, "Class", &class); err != nil || class != "user" {
+		return false
+	}
+	if err := getProperty(obj, "Active", &active); err != nil || !active {
+		return false
+	}
+	if err := getProperty(obj, "Type", &kind); err != nil {
+		return false
+	}
+	switch kind {
+	case "x11", "wayland", "mir":
+		return true
+	}
+	return false
+}
+
+// sessionBusAddress finds the session bus of a session: the
+// DBUS_SESSION_BUS_ADDRESS of its leader process, or else the standard
+// systemd location of the user bus.
+func sessionBusAddress(obj dbus.BusObject, uid uint32) string {
+	var leader uint32
+	if err := getProperty(obj, "Leader", &leader); err == nil && leader != 0 {
+		if address := environValue(fmt.Sprintf("/proc/%d/environ", leader), "DBUS_SESSION_BUS_ADDRESS"); address != "" {
+			return address
+		}
+	}
+	path := fmt.Sprintf("/run/user/%d/bus", uid)
+	if _, err := os.Stat(path); err == nil {
+		return "unix:path=" + path
+	}
+	return ""
+}
+
+func getProperty(obj dbus.BusObject, name string, value interface{}) error {
+	v, err := obj.GetProperty(login1SessionProperty + name)
+	if err != nil {
+		return err
+	}
+	return v.Store(value)
+}
+
+// environValue returns the value of key in a /proc/<pid>/environ file.
+func environValue(path, key string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, kv := range bytes.Split(data, []byte{0}) {
+		if pair := strings.SplitN(string(kv), "=", 2); len(pair) == 2 && pair[0] == key {
+			return pair[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// shared is the session bus connection of NewShared.
+var shared struct {
+	lock sync.Mutex
+	conn *dbus.Conn
+	refs int
+}
+
+// NewShared is New on a session bus connection shared by every Notifier
+// from NewShared in the process. The connection is opened by the first one
+// and closed when the last one is closed, so libraries each wanting a
+// Notifier don't each open a connection to the bus, nor close one the
+// application still uses.
+//
+// As with NewChild, the Notifiers all see the signals of each other's
+// notifications.
+func NewShared(opts ...Option) (Notifier, error) {
+	conn, err := acquireShared()
+	if err != nil {
+		return nil, err
+	}
+	n, err := newNotifier(conn, opts...)
+	if err != nil {
+		releaseShared()
+		return nil, err
+	}
+	n.release = releaseShared
+	return n, nil
+}
+
+// acquireShared returns the shared connection, dialing it if there is none.
+func acquireShared() (*dbus.Conn, error) {
+	shared.lock.Lock()
+	defer shared.lock.Unlock()
+	if shared.conn == nil {
+		conn, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return nil, err
+		}
+		shared.conn = conn
+	}
+	shared.refs++
+	return shared.conn, nil
+}
+
+// releaseShared drops a reference to the shared connection, closing it with
+// the last one.
+func releaseShared() error {
+	shared.lock.Lock()
+	defer shared.lock.Unlock()
+	shared.refs--
+	if shared.refs > 0 {
+		return nil
+	}
+	conn := shared.conn
+	shared.conn = nil
+	return conn.Close()
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// SendSimple sends a plain notification with just a summary and body, with
+// the server's default timeout, for callers sending many, e.g. progress per
+// file copied.
+//
+// If n is from New and none of the options changing what is sent are set
+// (defaults, policies, profiles and backends, redaction, quiet hours, storm
+// guard, size limits, retries, the circuit breaker, tracing, dry runs,
+// receipts, restart detection, sounds, the persistent outbox) and it doesn't
+// send through the portal, the notification goes to the server directly: no
+// hints map is built and no hints are converted.
+// Otherwise it is sent with SendNotification as usual. Either way most of
+// the cost of a call is godbus encoding it and decoding the reply.
+func SendSimple(n Notifier, summary, body string) (uint32, error) {
+	if n, ok := n.(*notifier); ok && n.plain() {
+		return n.sendSimple(summary, body)
+	}
+	return n.SendNotification(Notification{
+		Summary:       summary,
+		Body:          body,
+		ExpireTimeout: -1,
+	})
+}
+
+// plain reports whether SendNotification would send a notification with
+// only a summary and body unchanged, but for the hints of sharedHints and
+// the detected app identity.
+// Every option adding a stage to sending sets staged, so the fast path
+// can't skip it.
+func (n *notifier) plain() bool {
+	return !n.staged && !n.portal
+}
+
+func (n *notifier) sendSimple(summary, body string) (uint32, error) {
+	n.sends.RLock()
+	defer n.sends.RUnlock()
+	timeout := int32(-1)
+	if n.defaultTimeout != nil {
+		timeout = *n.defaultTimeout
+	}
+	call := n.object().CallWithContext(context.Background(), callNotify, 0,
+		n.app.Name, uint32(0), n.app.Icon, summary, body, []string(nil), n.sharedHints(), timeout)
+	if call.Err != nil {
+		return 0, call.Err
+	}
+	if len(call.Body) == 1 {
+		if id, ok := call.Body[0].(uint32); ok {
+			return id, nil
+		}
+	}
+	var id uint32
+	err := call.Store(&id)
+	return id, err
+}
+
+// sharedHints returns the hints SendNotification adds to every
+// notification, built once and shared by all calls: godbus only reads it.
+func (n *notifier) sharedHints() map[string]dbus.Variant {
+	n.simpleOnce.Do(func() {
+		n.simpleHints = make(map[string]dbus.Variant)
+		if n.senderPID {
+			n.simpleHints[HintSenderPID] = n.pid
+		}
+		if n.appID != "" {
+			n.simpleHints[HintDesktopEntry] = dbus.MakeVariant(n.appID)
+		}
+	})
+	return n.simpleHints
+}
@@ -0,0 +1,27 @@
+package notify
+
+import "testing"
+
+// BenchmarkSendSimple compares SendSimple to sending the same notification
+// with SendNotification, on a Notifier with the default options. What is
+// left for SendSimple is putting the arguments of the call in interfaces.
+func BenchmarkSendSimple(b *testing.B) {
+	n := newFakeNotifier(b)
+	b.Run("SendSimple", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := SendSimple(n, "Copying files", "photo.jpg"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("SendNotification", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			note := Notification{Summary: "Copying files", Body: "photo.jpg", ExpireTimeout: -1}
+			if _, err := n.SendNotification(note); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
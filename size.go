@@ -0,0 +1,194 @@
+package notify
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// imageHints are the hints that carry raw pixels, the usual reason for an
+// oversized notification.
+var imageHints = []string{"image-data", "image_data", "icon_data"}
+
+// SizeAction says what a Notifier does with a notification that is larger
+// than the maximum set with WithMaxMessageSize.
+type SizeAction int
+
+const (
+	SizeFail      SizeAction = iota // fail with a *SizeError
+	SizeDropImage                   // drop image hints, fail if still too large
+	SizeDownscale                   // halve image-data until it fits, then drop it, then fail
+)
+
+// SizeError is returned by SendNotification for a notification whose Notify
+// call is larger than the maximum set with WithMaxMessageSize.
+type SizeError struct {
+	Size int // encoded size in bytes
+	Max  int
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("notify: notification of %v bytes exceeds the maximum of %v", e.Size, e.Max)
+}
+
+// WithMaxMessageSize limits the encoded size of the Notify call, including
+// the pixels of image hints, to max bytes. Too large messages are fixed up or
+// refused according to action before the bus is called, instead of failing
+// with whatever error the bus or server gives.
+func WithMaxMessageSize(max int, action SizeAction) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.maxSize = max
+		n.sizeAction = action
+	}
+}
+
+// fitSize applies the size limit to note.
+func (n *notifier) fitSize(note Notification) (Notification, error) {
+	if n.maxSize <= 0 {
+		return note, nil
+	}
+	size, err := messageSize(note)
+	if err != nil || size <= n.maxSize {
+		// encoding errors are left to the actual call to report.
+		return note, nil
+	}
+	if n.sizeAction == SizeDownscale {
+		for {
+			smaller, ok := downscaleImage(note)
+			if !ok {
+				break
+			}
+			note = smaller
+			if size, err = messageSize(note); err != nil || size <= n.maxSize {
+				n.tracef("downscaled image to %v bytes", size)
+				return note, nil
+			}
+		}
+	}
+	if n.sizeAction != SizeFail {
+		note = withoutHints(note, imageHints...)
+		if size, err = messageSize(note); err != nil || size <= n.maxSize {
+			n.tracef("dropped image hints, now %v bytes", size)
+			return note, nil
+		}
+	}
+	return note, &SizeError{Size: size, Max: n.maxSize}
+}
+
+// messageSize returns the size of the Notify call for note on the wire.
+func messageSize(note Notification) (int, error) {
+	body := []interface{}{
+		note.AppName,
+		note.ReplacesID,
+		note.AppIcon,
+		note.Summary,
+		note.Body,
+		note.Actions,
+		note.Hints,
+		note.ExpireTimeout,
+	}
+	msg := &dbus.Message{
+		Type: dbus.TypeMethodCall,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldPath:        dbus.MakeVariant(dbus.ObjectPath(dbusObjectPath)),
+			dbus.FieldInterface:   dbus.MakeVariant(dbusNotificationsInterface),
+			dbus.FieldMember:      dbus.MakeVariant("Notify"),
+			dbus.FieldDestination: dbus.MakeVariant(dbusNotificationsInterface),
+			dbus.FieldSignature:   dbus.MakeVariant(dbus.SignatureOf(body...)),
+		},
+		Body: body,
+	}
+	var w countingWriter
+	if err := msg.EncodeTo(&w, binary.LittleEndian); err != nil {
+		return 0, err
+	}
+	return int(w), nil
+}
+
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// downscaleImage returns note with its image-data hint at half the width and
+// height, or false if there is no image that can be made smaller.
+func downscaleImage(note Notification) (Notification, bool) {
+	for _, key := range imageHints {
+		v, ok := note.Hints[key]
+		if !ok {
+			continue
+		}
+		img, ok := toImageData(v.Value())
+		if !ok || img.Width < 2 && img.Height < 2 {
+			return note, false
+		}
+		smaller, ok := halve(img)
+		if !ok {
+			return note, false
+		}
+		note = withoutHints(note, key)
+		note.Hints[key] = dbus.MakeVariant(smaller)
+		return note, true
+	}
+	return note, false
+}
+
+// toImageData reads value, a struct with the fields of image-data.
+func toImageData(value interface{}) (ImageData, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Struct || v.NumField() != 7 {
+		return ImageData{}, false
+	}
+	var img ImageData
+	ptrs := []interface{}{&img.Width, &img.Height, &img.Rowstride, &img.HasAlpha,
+		&img.BitsPerSample, &img.Channels, &img.Data}
+	for i, ptr := range ptrs {
+		dst := reflect.ValueOf(ptr).Elem()
+		f := v.Field(i)
+		if !f.CanInterface() || !f.Type().ConvertibleTo(dst.Type()) {
+			return ImageData{}, false
+		}
+		dst.Set(f.Convert(dst.Type()))
+	}
+	return img, true
+}
+
+// halve scales img to half its size by taking every other pixel.
+func halve(img ImageData) (ImageData, bool) {
+	if img.BitsPerSample%8 != 0 || img.Channels <= 0 || img.Width <= 0 || img.Height <= 0 {
+		return ImageData{}, false
+	}
+	pixel := int(img.Channels * img.BitsPerSample / 8)
+	w, h := (int(img.Width)+1)/2, (int(img.Height)+1)/2
+	stride := w * pixel
+	data := make([]byte, stride*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			src := 2*y*int(img.Rowstride) + 2*x*pixel
+			if src+pixel > len(img.Data) {
+				return ImageData{}, false
+			}
+			copy(data[y*stride+x*pixel:], img.Data[src:src+pixel])
+		}
+	}
+	img.Width, img.Height, img.Rowstride, img.Data = int32(w), int32(h), int32(stride), data
+	return img, true
+}
+
+// withoutHints returns note without hints keys, in a copy of the Hints map.
+func withoutHints(note Notification, keys ...string) Notification {
+	hints := make(map[string]dbus.Variant, len(note.Hints))
+	for k, v := range note.Hints {
+		hints[k] = v
+	}
+	for _, k := range keys {
+		delete(hints, k)
+	}
+	note.Hints = hints
+	return note
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// DefaultCategorySounds map the categories of the spec to the names of
+// the freedesktop sound theme played for them, see WithCategorySounds. As
+// with profiles, a category also covers its subcategories.
+var DefaultCategorySounds = map[string]string{
+	"call.incoming":        "phone-incoming-call",
+	"call.ended":           "phone-hangup",
+	"device.added":         "device-added",
+	"device.removed":       "device-removed",
+	"device.error":         "dialog-error",
+	"email.arrived":        "message-new-email",
+	"email.bounced":        "dialog-warning",
+	"im.received":          "message-new-instant",
+	"im.error":             "dialog-error",
+	"network.connected":    "network-connectivity-established",
+	"network.disconnected": "network-connectivity-lost",
+	"network.error":        "network-connectivity-error",
+	"presence.online":      "service-login",
+	"presence.offline":     "service-logout",
+	"transfer.complete":    "complete-download",
+	"transfer.error":       "dialog-error",
+}
+
+// sounds is the sound mapping of a Notifier.
+type sounds struct {
+	byCategory map[string]string
+
+	once      sync.Once
+	supported bool // the server advertises "sound"
+}
+
+// WithCategorySounds makes the Notifier play a themed sound for
+// notifications by their category, when the server advertises the "sound"
+// capability: the sound of DefaultCategorySounds, or of overrides. A
+// category in overrides replaces the defaults for it and its
+// subcategories, "im" those of "im.received" and "im.error"; an empty sound
+// keeps them silent.
+// Notifications that set sound-name, sound-file or suppress-sound
+// themselves are left alone.
+func WithCategorySounds(overrides map[string]string) Option {
+	return func(n *notifier) {
+		n.staged = true
+		s := &sounds{byCategory: make(map[string]string)}
+		for category, sound := range DefaultCategorySounds {
+			s.byCategory[category] = sound
+		}
+		for category := range overrides {
+			for c := range s.byCategory {
+				if c == category || strings.HasPrefix(c, category+".") {
+					delete(s.byCategory, c)
+				}
+			}
+		}
+		for category, sound := range overrides {
+			s.byCategory[category] = sound
+		}
+		n.sounds = s
+	}
+}
+
+// lookup returns the sound for category or its closest parent.
+func (s *sounds) lookup(category string) string {
+	for category != "" {
+		if sound, ok := s.byCategory[category]; ok {
+			return sound
+		}
+		i := strings.LastIndexByte(category, '.')
+		if i < 0 {
+			break
+		}
+		category = category[:i]
+	}
+	return ""
+}
+
+// categorySound sets the sound-name hint of note from its category. The
+// capabilities are asked for once, on the first notification with a sound.
+func (n *notifier) categorySound(note Notification) Notification {
+	if n.sounds == nil {
+		return note
+	}
+	for _, hint := range []string{HintSoundName, "sound-file", "suppress-sound"} {
+		if _, ok := note.Hints[hint]; ok {
+			return note
+		}
+	}
+	sound := n.sounds.lookup(hintString(note, HintCategory))
+	if sound == "" {
+		return note
+	}
+	n.sounds.once.Do(func() {
+		n.sounds.supported = hasCapability(n, "sound")
+	})
+	if !n.sounds.supported {
+		return note
+	}
+	return withHint(note, HintSoundName, dbus.MakeVariant(sound))
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/esiqveland/notify/internal/configfile"
+)
+
+// WithPersistentOutbox makes sends of notifications of at least urgency min
+// at-least-once: each is journaled as a file in dir before it is sent, and
+// the file removed once the server returned an ID. Notifications journaled
+// by an earlier run, e.g. one that crashed or found no server, are sent again
+// by New, as new notifications. A notification may be shown twice when the
+// process dies between the send and the removal.
+//
+// Notifications held back by quiet hours are journaled once released. If
+// journaling fails the notification is still sent. dir is created if needed,
+// and must not be shared with other Notifiers.
+func WithPersistentOutbox(dir string, min Urgency) Option {
+	return func(n *notifier) {
+		n.staged = true
+		n.spool = &spool{dir: dir, min: min, run: fmt.Sprintf("%020d", time.Now().UnixNano())}
+	}
+}
+
+// spool journals the notifications being sent, see WithPersistentOutbox.
+type spool struct {
+	seq uint64 // accessed atomically, first for alignment
+	dir string
+	min Urgency
+	run string // prefix of the files of this Notifier, ordering runs
+}
+
+// journal writes note to a new file and returns its path.
+func (s *spool) journal(note Notification) (string, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(note)
+	if err != nil {
+		return "", err
+	}
+	seq := atomic.AddUint64(&s.seq, 1)
+	path := filepath.Join(s.dir, fmt.Sprintf("%v-%020d.json", s.run, seq))
+	if err := configfile.Write(path, data); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// pending returns the paths of the notifications journaled before, oldest
+// first, with the notifications.
+func (s *spool) pending() ([]string, []Notification, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, s.run+"-") {
+			paths = append(paths, filepath.Join(s.dir, name))
+		}
+	}
+	sort.Strings(paths)
+	var kept []string
+	var notes []Notification
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var note Notification
+		if err := json.Unmarshal(data, &note); err != nil {
+			// written by something else, leave it alone.
+			continue
+		}
+		note.ReplacesID = 0
+		kept = append(kept, path)
+		notes = append(notes, note)
+	}
+	return kept, notes, nil
+}
+
+// sendJournaled sends note, journaled if its urgency calls for it.
+func (n *notifier) sendJournaled(note Notification) (uint32, error) {
+	if n.spool == nil || n.dryRun || urgency(note) < n.spool.min {
+		return n.send(note)
+	}
+	path, err := n.spool.journal(note)
+	if err != nil {
+		n.tracef("journaling notification: %v", err)
+	}
+	id, err := n.send(note)
+	if err == nil && path != "" {
+		os.Remove(path)
+	}
+	return id, err
+}
+
+// replaySpool sends the notifications journaled by earlier runs, in the
+// background. Those failing again stay journaled for the next run.
+func (n *notifier) replaySpool() {
+	if n.spool == nil || n.dryRun {
+		return
+	}
+	paths, notes, err := n.spool.pending()
+	if err != nil {
+		n.tracef("reading outbox %v: %v", n.spool.dir, err)
+		return
+	}
+	if len(paths) == 0 {
+		return
+	}
+	n.handlers.Add(1)
+	go func() {
+		defer n.handlers.Done()
+		for i, note := range notes {
+			select {
+			case <-n.closing:
+				return
+			default:
+			}
+			n.tracef("re-sending journaled notification %v", filepath.Base(paths[i]))
+			if _, err := n.send(note); err == nil {
+				os.Remove(paths[i])
+			}
+		}
+	}()
+}
@@ -0,0 +1,227 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// stormUpdateInterval limits how often the summary of a storm is replaced,
+// so the flood doesn't just move to the summary.
+const stormUpdateInterval = time.Second
+
+// StormGuard protects against floods of notifications: once more than Limit
+// notifications with the same key are sent within Window, further ones are
+// not shown but counted in a single summarizing notification, e.g.
+// "137 new log errors", which is updated in place. The storm ends once no
+// notification with the key was sent for Window.
+//
+// SendNotification returns the ID of the summary for notifications
+// collapsed into it.
+type StormGuard struct {
+	Limit  int
+	Window time.Duration
+
+	// Key groups notifications. The default uses the stack tag hint, else the
+	// category hint, else the AppName.
+	Key func(note Notification) string
+	// Summary builds the summarizing notification for count collapsed
+	// notifications, the latest of which is last. The default reads
+	// "<count> new notifications", or "1 new notification", with the
+	// summary of last as body.
+	Summary func(key string, count int, last Notification) Notification
+}
+
+// WithStormGuard makes the Notifier collapse floods of notifications as
+// described by g.
+func WithStormGuard(g StormGuard) Option {
+	return func(n *notifier) {
+		n.staged = true
+		if g.Key == nil {
+			g.Key = stormKey
+		}
+		if g.Summary == nil {
+			g.Summary = stormSummary
+		}
+		n.storms = &storms{
+			guard: g,
+			keys:  make(map[string]*storm),
+		}
+	}
+}
+
+func stormKey(note Notification) string {
+	if tag := hintString(note, HintStackTag); tag != "" {
+		return "tag:" + tag
+	}
+	if category := hintString(note, HintCategory); category != "" {
+		return "category:" + category
+	}
+	return "app:" + note.AppName
+}
+
+func stormSummary(key string, count int, last Notification) Notification {
+	hints := map[string]dbus.Variant{}
+	for _, h := range []string{HintUrgency, HintCategory, HintStackTag, HintDesktopEntry, HintSenderPID} {
+		if v, ok := last.Hints[h]; ok {
+			hints[h] = v
+		}
+	}
+	return Notification{
+		AppName:       last.AppName,
+		AppIcon:       last.AppIcon,
+		Summary:       newNotifications(count),
+		Body:          last.Summary,
+		Hints:         hints,
+		ExpireTimeout: -1,
+	}
+}
+
+func newNotifications(count int) string {
+	if count == 1 {
+		return "1 new notification"
+	}
+	return fmt.Sprintf("%d new notifications", count)
+}
+
+type storms struct {
+	guard StormGuard
+
+	lock sync.Mutex
+	keys map[string]*storm
+}
+
+// storm is the state of one key.
+type storm struct {
+	key    string
+	sent   []time.Time // shown notifications within the window
+	count  int         // notifications collapsed into the summary
+	last   Notification
+	seen   time.Time // of the last collapsed notification
+	id     uint32    // of the summary
+	shown  bool      // the summary was sent, or is being
+	update Alarm     // pending replacement of the summary
+
+	// sending is held while the summary is delivered, which happens
+	// without holding the lock of storms.
+	sending sync.Mutex
+}
+
+// collapse counts note towards its storm. It returns false if note should be
+// sent as usual, or else the ID of the summary it went into.
+func (n *notifier) collapse(note Notification) (uint32, bool, error) {
+	if n.storms == nil {
+		return 0, false, nil
+	}
+	st := n.storms
+	st.lock.Lock()
+	now := n.clock.Now()
+	st.expire(now)
+	key := st.guard.Key(note)
+	s, ok := st.keys[key]
+	if !ok {
+		s = &storm{key: key}
+		st.keys[key] = s
+	}
+	if s.count == 0 && len(s.sent) < st.guard.Limit {
+		s.sent = append(s.sent, now)
+		st.lock.Unlock()
+		return 0, false, nil
+	}
+
+	s.count++
+	s.last = note
+	s.seen = now
+	first := !s.shown
+	s.shown = true
+	if !first && s.update == nil {
+		s.update = n.clock.AfterFunc(stormUpdateInterval, func() {
+			n.updateStorm(s)
+		})
+	}
+	st.lock.Unlock()
+
+	// wait for a summary being delivered, for its ID.
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	if first {
+		n.tracef("storm of %v, collapsing notifications", key)
+		id, err := n.sendSummary(s)
+		return id, true, err
+	}
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	return s.id, true, nil
+}
+
+// updateStorm replaces the summary of s with its current count.
+func (n *notifier) updateStorm(s *storm) {
+	n.storms.lock.Lock()
+	s.update = nil
+	n.storms.lock.Unlock()
+
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	if _, err := n.sendSummary(s); err != nil {
+		log.Printf("error updating summary of notification storm: %v", err)
+	}
+}
+
+// sendSummary delivers the summary of s, replacing the one shown. s.sending
+// must be held.
+func (n *notifier) sendSummary(s *storm) (uint32, error) {
+	st := n.storms
+	st.lock.Lock()
+	note := st.summary(s)
+	st.lock.Unlock()
+	id, err := n.deliver(note)
+	if err != nil {
+		return 0, err
+	}
+	st.lock.Lock()
+	s.id = id
+	st.lock.Unlock()
+	return id, nil
+}
+
+func (st *storms) summary(s *storm) Notification {
+	note := st.guard.Summary(s.key, s.count, s.last)
+	note.ReplacesID = s.id
+	return note
+}
+
+// expire forgets sends older than the window, and storms that are over.
+func (st *storms) expire(now time.Time) {
+	cutoff := now.Add(-st.guard.Window)
+	for key, s := range st.keys {
+		i := 0
+		for i < len(s.sent) && s.sent[i].Before(cutoff) {
+			i++
+		}
+		s.sent = s.sent[i:]
+		if s.count > 0 && s.seen.Before(cutoff) && s.update == nil {
+			s.count, s.id, s.shown = 0, 0, false
+		}
+		if s.count == 0 && len(s.sent) == 0 {
+			delete(st.keys, key)
+		}
+	}
+}
+
+// stopStorms stops pending summary updates.
+func (n *notifier) stopStorms() {
+	if n.storms == nil {
+		return
+	}
+	n.storms.lock.Lock()
+	defer n.storms.lock.Unlock()
+	for _, s := range n.storms.keys {
+		if s.update != nil {
+			s.update.Stop()
+			s.update = nil
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStormSummary(t *testing.T) {
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{1, "1 new notification"},
+		{2, "2 new notifications"},
+		{137, "137 new notifications"},
+	}
+	for _, tt := range tests {
+		if got := stormSummary("app:test", tt.count, Notification{}).Summary; got != tt.want {
+			t.Errorf("stormSummary(%v) = %q, want %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestStormCollapse(t *testing.T) {
+	n := newFakeNotifier(t, WithStormGuard(StormGuard{Limit: 1, Window: time.Hour}))
+	for i := 0; i < 3; i++ {
+		id, err := n.SendNotification(Notification{AppName: "test", Summary: "error"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 {
+			t.Fatalf("send %v: got ID %v, want 1", i, id)
+		}
+	}
+	n.storms.lock.Lock()
+	defer n.storms.lock.Unlock()
+	if s := n.storms.keys["app:test"]; s == nil || s.count != 2 || s.id != 1 {
+		t.Fatalf("storm = %+v, want 2 collapsed into summary 1", s)
+	}
+}
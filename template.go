@@ -0,0 +1,57 @@
+package notify
+
+// Template describes one kind of notification of an application, rendered
+// from a payload of type T, e.g.
+//
+//	var newMail = notify.Template[Mail]{
+//		Base:    notify.Notification{AppIcon: "mail-unread", ExpireTimeout: -1},
+//		Summary: func(m Mail) string { return m.From },
+//		Body:    func(m Mail) string { return m.Subject },
+//		Hints: func(m Mail) map[string]interface{} {
+//			return map[string]interface{}{"category": "email.arrived"}
+//		},
+//	}
+//
+//	notify.Send(n, newMail, mail)
+//
+// Sending a newMail with anything but a Mail doesn't compile, so apps with
+// many kinds of notifications can't mix up what goes into which.
+type Template[T any] struct {
+	// Base holds what all notifications of the kind share: AppName,
+	// AppIcon, Actions, Hints and ExpireTimeout.
+	Base Notification
+	// Summary and Body render the text; a nil one keeps that of Base.
+	Summary func(T) string
+	Body    func(T) string
+	// Hints renders hints set on top of those of Base, converted as by
+	// MakeHints. Optional.
+	Hints func(T) map[string]interface{}
+}
+
+// Render returns the notification for payload.
+func (t Template[T]) Render(payload T) (Notification, error) {
+	note := t.Base
+	if t.Summary != nil {
+		note.Summary = t.Summary(payload)
+	}
+	if t.Body != nil {
+		note.Body = t.Body(payload)
+	}
+	note.Actions = append([]string(nil), t.Base.Actions...)
+	note = withoutHints(note)
+	if t.Hints != nil {
+		if err := note.SetHints(t.Hints(payload)); err != nil {
+			return Notification{}, err
+		}
+	}
+	return note, nil
+}
+
+// Send renders payload with t and sends it with n.
+func Send[T any](n Notifier, t Template[T], payload T) (uint32, error) {
+	note, err := t.Render(payload)
+	if err != nil {
+		return 0, err
+	}
+	return n.SendNotification(note)
+}
@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// A TemplateDir holds the templates of an application's kinds of
+// notifications in a directory, one JSON file per kind, so wording and icons
+// can be changed without rebuilding or restarting the application. The kind
+// is the name of the file without .json, disk-full.json for "disk-full":
+//
+//	{
+//	  "app_icon": "drive-harddisk",
+//	  "summary": "Disk {{.Mount}} is full",
+//	  "body": "Only {{.Free}} left",
+//	  "actions": ["default", "Open"],
+//	  "hints": {"urgency": 2, "category": "device"},
+//	  "timeout": -1
+//	}
+//
+// App icon, summary, body and string hint values are text/template
+// templates, executed with the data passed to Render. They can use the
+// tokens of the Theme set with SetTheme, e.g. {{icon "disk"}}. Hint values
+// are converted as by MakeHints.
+//
+// A file is checked for changes each time its kind is rendered, like
+// Profiles, and files added later are picked up. A TemplateDir is safe for
+// concurrent use.
+type TemplateDir struct {
+	dir string
+
+	lock  sync.Mutex
+	kinds map[string]*fileTemplate
+	theme *Theme
+}
+
+// templateFile is the JSON form of a template.
+type templateFile struct {
+	AppName string                 `json:"app_name,omitempty"`
+	AppIcon string                 `json:"app_icon,omitempty"`
+	Summary string                 `json:"summary,omitempty"`
+	Body    string                 `json:"body,omitempty"`
+	Actions []string               `json:"actions,omitempty"`
+	Hints   map[string]interface{} `json:"hints,omitempty"`
+	Timeout *int32                 `json:"timeout,omitempty"` // ExpireTimeout in milliseconds, -1 if not set
+}
+
+// fileTemplate is a parsed template file.
+type fileTemplate struct {
+	modified time.Time
+	base     Notification
+	appIcon  *template.Template
+	summary  *template.Template
+	body     *template.Template
+	hints    map[string]interface{} // *template.Template for strings
+}
+
+// LoadTemplateDir reads the templates in dir. It fails if one of them
+// can't be parsed.
+func LoadTemplateDir(dir string) (*TemplateDir, error) {
+	d := &TemplateDir{dir: dir, kinds: make(map[string]*fileTemplate)}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		kind := strings.TrimSuffix(filepath.Base(path), ".json")
+		if _, err := d.lookup(kind); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// SetTheme makes d render with theme: its tokens resolve in the templates
+// and its dunst colors are set on the notifications. nil unsets it.
+func (d *TemplateDir) SetTheme(theme *Theme) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.theme = theme
+}
+
+func (d *TemplateDir) currentTheme() *Theme {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.theme
+}
+
+// Kinds returns the kinds of notifications loaded, sorted.
+func (d *TemplateDir) Kinds() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	kinds := make([]string, 0, len(d.kinds))
+	for kind := range d.kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// lookup returns the template of kind, read again if the file changed.
+// A file that fails to parse keeps the template read before, if any.
+func (d *TemplateDir) lookup(kind string) (*fileTemplate, error) {
+	if kind == "" || strings.ContainsAny(kind, `/\`) {
+		return nil, fmt.Errorf("notify: invalid template kind %q", kind)
+	}
+	path := filepath.Join(d.dir, kind+".json")
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	t := d.kinds[kind]
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		delete(d.kinds, kind)
+		return nil, fmt.Errorf("notify: no template %q in %v", kind, d.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t != nil && info.ModTime().Equal(t.modified) {
+		return t, nil
+	}
+	parsed, err := d.parseTemplateFile(path, kind)
+	if err != nil {
+		if t != nil {
+			// keep the template we have, a half written file must not
+			// break sends.
+			log.Printf("error reloading notification template: %v", err)
+			return t, nil
+		}
+		return nil, err
+	}
+	parsed.modified = info.ModTime()
+	d.kinds[kind] = parsed
+	return parsed, nil
+}
+
+func (d *TemplateDir) parseTemplateFile(path, kind string) (*fileTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f templateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("notify: %v: %w", path, err)
+	}
+	t := &fileTemplate{
+		base: Notification{
+			AppName:       f.AppName,
+			Actions:       f.Actions,
+			ExpireTimeout: -1,
+		},
+		hints: make(map[string]interface{}, len(f.Hints)),
+	}
+	if f.Timeout != nil {
+		t.base.ExpireTimeout = *f.Timeout
+	}
+	parse := func(name, text string) (*template.Template, error) {
+		tmpl, err := template.New(kind + "." + name).Funcs(themeFuncs(d.currentTheme)).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("notify: %v: %w", path, err)
+		}
+		return tmpl, nil
+	}
+	if t.appIcon, err = parse("app_icon", f.AppIcon); err != nil {
+		return nil, err
+	}
+	if t.summary, err = parse("summary", f.Summary); err != nil {
+		return nil, err
+	}
+	if t.body, err = parse("body", f.Body); err != nil {
+		return nil, err
+	}
+	for key, value := range f.Hints {
+		if s, ok := value.(string); ok {
+			if value, err = parse(key, s); err != nil {
+				return nil, err
+			}
+		}
+		t.hints[key] = value
+	}
+	return t, nil
+}
+
+// Render returns the notification of kind for data.
+func (d *TemplateDir) Render(kind string, data interface{}) (Notification, error) {
+	t, err := d.lookup(kind)
+	if err != nil {
+		return Notification{}, err
+	}
+	execute := func(tmpl *template.Template) (string, error) {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	note := t.base.Clone()
+	if note.AppIcon, err = execute(t.appIcon); err != nil {
+		return Notification{}, err
+	}
+	if note.Summary, err = execute(t.summary); err != nil {
+		return Notification{}, err
+	}
+	if note.Body, err = execute(t.body); err != nil {
+		return Notification{}, err
+	}
+	hints := make(map[string]interface{}, len(t.hints))
+	for key, value := range t.hints {
+		if tmpl, ok := value.(*template.Template); ok {
+			if value, err = execute(tmpl); err != nil {
+				return Notification{}, err
+			}
+		}
+		hints[key] = value
+	}
+	if err := note.SetHints(hints); err != nil {
+		return Notification{}, err
+	}
+	if theme := d.currentTheme(); theme != nil {
+		note = theme.Apply(note)
+	}
+	return note, nil
+}
+
+// Send renders kind for data and sends it with n.
+func (d *TemplateDir) Send(n Notifier, kind string, data interface{}) (uint32, error) {
+	note, err := d.Render(kind, data)
+	if err != nil {
+		return 0, err
+	}
+	return n.SendNotification(note)
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/esiqveland/notify/internal/configfile"
+)
+
+// Color hints of dunst, "#rrggbb" or "#rrggbbaa" STRINGs.
+const (
+	HintDunstForeground = "fgcolor"
+	HintDunstBackground = "bgcolor"
+	HintDunstFrame      = "frcolor"
+	HintDunstHighlight  = "hlcolor" // of the progress bar
+)
+
+// A Theme gives a suite of tools a shared notification look: named icons
+// and colors, used by templates as {{icon "disk"}} and {{color "accent"}},
+// and colors for the dunst color hints of every notification. It is read
+// from a JSON file:
+//
+//	{
+//	  "icons":  {"disk": "drive-harddisk-symbolic"},
+//	  "colors": {"accent": "#5e81ac", "background": "#2e3440"},
+//	  "dunst":  {"hlcolor": "accent", "bgcolor": "background"}
+//	}
+//
+// Like Profiles, the file is checked for changes at send time. A Theme is
+// safe for concurrent use.
+type Theme struct {
+	path string
+
+	lock     sync.Mutex
+	modified time.Time
+	theme    themeFile
+}
+
+// themeFile is the JSON form of a Theme.
+type themeFile struct {
+	Icons  map[string]string `json:"icons,omitempty"`
+	Colors map[string]string `json:"colors,omitempty"`
+	// Dunst maps color hints, e.g. HintDunstHighlight, to names in Colors.
+	Dunst map[string]string `json:"dunst,omitempty"`
+}
+
+// LoadTheme reads the theme file at path. A missing file gives an empty
+// theme, until it is created.
+func LoadTheme(path string) (*Theme, error) {
+	t := &Theme{path: path}
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// reload reads the file if it changed since it was last read.
+// t.lock must be held, except from LoadTheme.
+func (t *Theme) reload() error {
+	return configfile.Reload(t.path, &t.modified, func(data []byte) error {
+		var f themeFile
+		if data != nil {
+			if err := json.Unmarshal(data, &f); err != nil {
+				return fmt.Errorf("notify: %v: %w", t.path, err)
+			}
+		}
+		t.theme = f
+		return nil
+	})
+}
+
+// current returns the theme as it is on disk now.
+func (t *Theme) current() themeFile {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := t.reload(); err != nil {
+		// keep the theme we have, a half written file must not reset it.
+		log.Printf("error reloading notification theme: %v", err)
+	}
+	return t.theme
+}
+
+// Icon returns the icon the theme names name, or name itself if it names
+// none, as token names are commonly icon names already.
+func (t *Theme) Icon(name string) string {
+	if icon, ok := t.current().Icons[name]; ok {
+		return icon
+	}
+	return name
+}
+
+// Color returns the color the theme names name, and whether it does.
+func (t *Theme) Color(name string) (string, bool) {
+	color, ok := t.current().Colors[name]
+	return color, ok
+}
+
+// Apply returns note with the dunst color hints of the theme, except those
+// note sets already.
+func (t *Theme) Apply(note Notification) Notification {
+	theme := t.current()
+	for hint, name := range theme.Dunst {
+		color, ok := theme.Colors[name]
+		if !ok {
+			continue
+		}
+		if _, set := note.Hints[hint]; !set {
+			note = note.WithHint(hint, color)
+		}
+	}
+	return note
+}
+
+// themeFuncs are the template functions resolving the tokens of t. Without
+// a theme, icons are their token and colors an error.
+func themeFuncs(t func() *Theme) map[string]interface{} {
+	return map[string]interface{}{
+		"icon": func(name string) string {
+			if theme := t(); theme != nil {
+				return theme.Icon(name)
+			}
+			return name
+		},
+		"color": func(name string) (string, error) {
+			if theme := t(); theme != nil {
+				if color, ok := theme.Color(name); ok {
+					return color, nil
+				}
+			}
+			return "", fmt.Errorf("notify: no theme color %q", name)
+		},
+	}
+}
@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	actionPause  = "pause"
+	actionResume = "resume"
+	actionCancel = "cancel"
+
+	// timerTick is how often the countdown is updated.
+	timerTick = time.Second
+)
+
+// Timer is a countdown, e.g. a pomodoro, shown as a notification with the
+// remaining time, a progress bar and Pause/Resume and Cancel actions. When
+// it runs out it is replaced by a completion notification with a sound.
+//
+// Pass the ActionInvoked signals to HandleAction for the actions to work.
+type Timer struct {
+	n     Notifier
+	clock Clock
+	total time.Duration
+	label string
+
+	lock      sync.Mutex
+	id        uint32
+	remaining time.Duration // as of started, or while paused
+	started   time.Time     // zero while paused
+	alarm     Alarm         // the next tick
+	ended     bool
+	done      chan struct{}
+}
+
+// StartTimer starts a countdown of d labelled label, e.g. "Focus", on the
+// clock of n (see WithClock).
+func StartTimer(n Notifier, d time.Duration, label string) (*Timer, error) {
+	clock := ClockOf(n)
+	t := &Timer{
+		n:         n,
+		clock:     clock,
+		total:     d,
+		label:     label,
+		remaining: d,
+		started:   clock.Now(),
+		done:      make(chan struct{}),
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := t.update(); err != nil {
+		return nil, err
+	}
+	t.alarm = clock.AfterFunc(timerTick, t.tick)
+	return t, nil
+}
+
+// tick updates the countdown, or finishes it once it ran out.
+func (t *Timer) tick() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.ended {
+		return
+	}
+	if !t.started.IsZero() {
+		if t.left() <= 0 {
+			t.finish()
+			t.end()
+			return
+		}
+		if err := t.update(); err != nil {
+			log.Printf("error updating timer notification: %v", err)
+		}
+	}
+	t.alarm = t.clock.AfterFunc(timerTick, t.tick)
+}
+
+// end stops the ticks and closes Done. t.lock must be held.
+func (t *Timer) end() {
+	t.ended = true
+	t.alarm.Stop()
+	close(t.done)
+}
+
+// left returns the remaining time. t.lock must be held.
+func (t *Timer) left() time.Duration {
+	if t.started.IsZero() {
+		return t.remaining
+	}
+	return t.remaining - t.clock.Now().Sub(t.started)
+}
+
+// Remaining returns the time left on the countdown.
+func (t *Timer) Remaining() time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if left := t.left(); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Done is closed when the countdown ran out or was canceled.
+func (t *Timer) Done() <-chan struct{} {
+	return t.done
+}
+
+// update replaces the countdown notification. t.lock must be held.
+func (t *Timer) update() error {
+	left := t.left().Round(time.Second)
+	if left < 0 {
+		left = 0
+	}
+	toggle := []string{actionPause, "Pause"}
+	state := "remaining"
+	if t.started.IsZero() {
+		toggle = []string{actionResume, "Resume"}
+		state = "paused"
+	}
+	note := Notification{
+		ReplacesID:    t.id,
+		AppIcon:       "alarm-symbolic",
+		Summary:       t.label,
+		Body:          fmt.Sprintf("%d:%02d %s", int(left.Minutes()), int(left.Seconds())%60, state),
+		Actions:       append(toggle, actionCancel, "Cancel"),
+		ExpireTimeout: 0,
+	}
+	if t.total > 0 {
+		note.SetProgress(int(100 * (t.total - left) / t.total))
+	}
+	note.SetHint(HintResident, true)
+	note.SetHint(HintTransient, true)
+	id, err := t.n.SendNotification(note)
+	if err != nil {
+		return err
+	}
+	t.id = id
+	return nil
+}
+
+// finish replaces the countdown with the completion notification.
+// t.lock must be held.
+func (t *Timer) finish() {
+	note := Notification{
+		ReplacesID:    t.id,
+		AppIcon:       "alarm-symbolic",
+		Summary:       t.label,
+		Body:          "Time's up",
+		ExpireTimeout: -1,
+	}
+	note.SetHint(HintSoundName, "complete")
+	note.SetUrgency(UrgencyCritical)
+	id, err := t.n.SendNotification(note)
+	if err != nil {
+		log.Printf("error sending timer notification: %v", err)
+		return
+	}
+	t.id = id
+}
+
+// Pause stops the countdown until Resume.
+func (t *Timer) Pause() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.started.IsZero() {
+		return
+	}
+	t.remaining, t.started = t.left(), time.Time{}
+	t.update()
+}
+
+// Resume continues a paused countdown.
+func (t *Timer) Resume() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.started.IsZero() {
+		return
+	}
+	t.started = t.clock.Now()
+	t.update()
+}
+
+// Cancel stops the countdown and closes its notification.
+func (t *Timer) Cancel() error {
+	t.lock.Lock()
+	if t.ended {
+		t.lock.Unlock()
+		return nil
+	}
+	t.end()
+	id := t.id
+	t.lock.Unlock()
+	_, err := t.n.CloseNotification(id)
+	return err
+}
+
+// HandleAction pauses, resumes or cancels the timer if signal is for its
+// notification. Call it from the loop reading ActionInvoked.
+func (t *Timer) HandleAction(signal *ActionInvokedSignal) {
+	t.lock.Lock()
+	mine := signal.Id == t.id
+	t.lock.Unlock()
+	if !mine {
+		return
+	}
+	switch signal.ActionKey {
+	case actionPause:
+		t.Pause()
+	case actionResume:
+		t.Resume()
+	case actionCancel:
+		t.Cancel()
+	}
+}
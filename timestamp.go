@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RelativeTime formats t relative to now for notification bodies, e.g.
+// "just now", "2 min ago", "3 h ago", "yesterday" or "5 days ago".
+// Times in the future are formatted as "just now".
+func RelativeTime(t, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%d min ago", age/time.Minute)
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%d h ago", age/time.Hour)
+	case age < 48*time.Hour:
+		return "yesterday"
+	}
+	return fmt.Sprintf("%d days ago", age/(24*time.Hour))
+}
+
+// refreshAfter returns when the relative form of a time of the given age
+// next changes, on a coarse schedule: every minute in the first hour, every
+// hour in the first day, then every day.
+func refreshAfter(age time.Duration) time.Duration {
+	if age < 0 {
+		return -age + time.Minute
+	}
+	unit := 24 * time.Hour
+	switch {
+	case age < time.Hour:
+		unit = time.Minute
+	case age < 24*time.Hour:
+		unit = time.Hour
+	}
+	return unit - age%unit
+}
+
+// Timestamped shows a notification about something that happened at a given
+// time, with the time in the body as a relative time ("2 min ago") that is
+// kept current by replacing the notification as it ages.
+//
+// Unlike KeepAlive, it doesn't bring the notification back once it closed
+// for any reason: pass the NotificationClosed signals to HandleClosed to stop refreshing.
+type Timestamped struct {
+	n       Notifier
+	clock   Clock
+	at      time.Time
+	content func(ago string) Notification
+
+	lock  sync.Mutex
+	id    uint32
+	alarm Alarm
+	done  bool
+}
+
+// NewTimestamped sends the notification returned by content, called with
+// the time since at formatted by RelativeTime, and calls content again to
+// replace it whenever that text changes, on the clock of n (see
+// WithClock). The ReplacesID returned by content is ignored.
+// Call Close to stop and remove the notification.
+func NewTimestamped(n Notifier, at time.Time, content func(ago string) Notification) (*Timestamped, error) {
+	t := &Timestamped{n: n, clock: ClockOf(n), at: at, content: content}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err := t.refresh(); err != nil {
+		t.stop()
+		return nil, err
+	}
+	return t, nil
+}
+
+// ID returns the ID of the notification.
+func (t *Timestamped) ID() uint32 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.id
+}
+
+// refresh replaces the notification and schedules the next refresh.
+// t.lock must be held.
+func (t *Timestamped) refresh() error {
+	now := t.clock.Now()
+	note := t.content(RelativeTime(t.at, now))
+	note.ReplacesID = t.id
+	id, err := t.n.SendNotification(note)
+	if err == nil {
+		t.id = id
+	}
+	// on error the daemon is most likely restarting, the next refresh tries
+	// again.
+	t.alarm = t.clock.AfterFunc(refreshAfter(now.Sub(t.at)), func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		if !t.done {
+			t.refresh()
+		}
+	})
+	return err
+}
+
+// HandleClosed stops refreshing if signal is for the notification.
+func (t *Timestamped) HandleClosed(signal *NotificationClosedSignal) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if signal.Id == t.id {
+		t.stop()
+	}
+}
+
+// stop cancels the next refresh. t.lock must be held.
+func (t *Timestamped) stop() {
+	t.done = true
+	if t.alarm != nil {
+		t.alarm.Stop()
+	}
+}
+
+// Close stops refreshing and closes the notification.
+func (t *Timestamped) Close() error {
+	t.lock.Lock()
+	t.stop()
+	id := t.id
+	t.lock.Unlock()
+	_, err := t.n.CloseNotification(id)
+	return err
+}
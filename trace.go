@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// traceValueLimit caps how much of a hint value is logged, so image-data
+// doesn't flood the log.
+const traceValueLimit = 64
+
+// WithTrace makes the Notifier log every method call it makes, the reply it
+// gets, and every notification signal it receives to logger.
+// Hints are logged with the D-Bus signature of their variant, which helps
+// finding out why a server ignores a hint sent with the wrong type.
+// A nil logger logs to the standard logger.
+func WithTrace(logger *log.Logger) Option {
+	return func(n *notifier) {
+		n.staged = true
+		if logger == nil {
+			logger = log.Default()
+		}
+		n.trace = logger
+	}
+}
+
+func (n *notifier) tracef(format string, v ...interface{}) {
+	if n.trace != nil {
+		n.trace.Printf(format, v...)
+	}
+}
+
+func (n *notifier) traceNotify(note Notification) {
+	if n.trace == nil {
+		return
+	}
+	n.trace.Printf("call %v app_name=%q replaces_id=%v app_icon=%q summary=%q body=%q actions=%q hints={%v} expire_timeout=%v",
+		callNotify, note.AppName, note.ReplacesID, note.AppIcon, note.Summary, note.Body,
+		note.Actions, formatHints(note.Hints), note.ExpireTimeout)
+}
+
+func (n *notifier) traceSignal(signal *dbus.Signal) {
+	if n.trace == nil {
+		return
+	}
+	types := make([]string, len(signal.Body))
+	for i, v := range signal.Body {
+		types[i] = dbus.SignatureOf(v).String()
+	}
+	n.trace.Printf("signal %v sender=%v signature=%v body=%v",
+		signal.Name, signal.Sender, strings.Join(types, ""), signal.Body)
+}
+
+// formatHints formats hints as key:signature=value, sorted by key.
+func formatHints(hints map[string]dbus.Variant) string {
+	keys := make([]string, 0, len(hints))
+	for k := range hints {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		v := hints[k]
+		value := fmt.Sprint(v.Value())
+		if len(value) > traceValueLimit {
+			value = value[:traceValueLimit] + "..."
+		}
+		parts[i] = fmt.Sprintf("%v:%v=%v", k, v.Signature(), value)
+	}
+	return strings.Join(parts, " ")
+}